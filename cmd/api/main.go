@@ -11,7 +11,9 @@ import (
 
 	"github.com/user/car-project/internal/config"
 	"github.com/user/car-project/internal/db"
+	"github.com/user/car-project/internal/repository"
 	"github.com/user/car-project/internal/routes"
+	"github.com/user/car-project/internal/service"
 	"github.com/user/car-project/internal/utils"
 )
 
@@ -34,6 +36,10 @@ import (
 // @in header
 // @name Authorization
 
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
+
 func main() {
 	// Initialize logger
 	utils.InitLogger()
@@ -41,6 +47,10 @@ func main() {
 
 	// Load config
 	cfg := config.LoadConfig()
+	jwtKeys, err := cfg.JWTKeys()
+	if err != nil {
+		logger.Fatalf("Invalid JWT configuration: %v", err)
+	}
 
 	// Initialize database
 	// Note: Uncomment this if you have a running postgres instance
@@ -49,7 +59,25 @@ func main() {
 	}
 
 	// Setup routes
-	r := routes.SetupRouter(cfg.JWTSecret, cfg.JWTExpiryHours, cfg.OpenAIAPIKey, cfg.RAGEmbeddingModel, cfg.RAGChatModel, cfg.RAGTopK)
+	reports := service.NewReportService(repository.NewReportRepository(db.DB), cfg.ReportCacheTTL)
+	r := routes.SetupRouter(cfg, jwtKeys, reports)
+
+	// Background jobs stop when the server shuts down
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if db.DB != nil {
+		// Expired refresh tokens and denylist entries are dropped hourly whatever the trash settings
+		tokens := service.NewTokenService(repository.NewTokenRepository(db.DB), repository.NewUserRepository(db.DB),
+			nil, jwtKeys, cfg.JWTAccessTTL, cfg.JWTRefreshTTL)
+		go tokens.RunCleanup(jobCtx, time.Hour)
+	}
+	if db.DB != nil && cfg.TrashPurgeInterval > 0 {
+		trash := service.NewTrashService(repository.NewCarRepository(db.DB), repository.NewUserRepository(db.DB), cfg.TrashRetention)
+		go trash.RunPurger(jobCtx, cfg.TrashPurgeInterval)
+	}
+	if db.DB != nil && cfg.ReportRefreshInterval > 0 {
+		go reports.RunRefresher(jobCtx, cfg.ReportRefreshInterval)
+	}
 
 	srv := &http.Server{
 		Addr:    ":" + cfg.Port,
@@ -74,6 +102,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Println("Shutting down server...")
+	stopJobs()
 
 	// The context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
@@ -0,0 +1,76 @@
+// import bulk-loads cars from a CSV or XLSX file, using the same validation as
+// POST /api/v1/cars/import. Without -commit it only prints the validation report.
+//
+//	go run ./cmd/import -file stock.xlsx            # dry run
+//	go run ./cmd/import -file stock.xlsx -commit    # create the cars
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/user/car-project/internal/config"
+	"github.com/user/car-project/internal/db"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/spreadsheet"
+)
+
+func main() {
+	file := flag.String("file", "", "spreadsheet to import (.csv or .xlsx)")
+	commit := flag.Bool("commit", false, "create the cars if every row is valid (default: validate only)")
+	allowDuplicates := flag.Bool("allow-duplicates", false, "import rows that are likely duplicates of stored cars")
+	userID := flag.Int64("user", 0, "user ID recorded as the actor in the audit log")
+	flag.Parse()
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	format, err := spreadsheet.FormatOf(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rows, err := spreadsheet.Read(format, f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("Failed to parse %s: %v", *file, err)
+	}
+
+	cfg := config.LoadConfig()
+	if cfg.DBURL == "" {
+		log.Fatal("DBURL not found in config")
+	}
+	db.InitDB(cfg.DBURL)
+
+	ctx := context.Background()
+	if *userID != 0 {
+		ctx = repository.WithAuditActor(ctx, repository.AuditActor{UserID: userID})
+	}
+	svc := service.NewCarImportService(repository.NewCarRepository(db.DB))
+	report, err := svc.ImportCars(ctx, rows, *commit, *allowDuplicates)
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(report)
+
+	switch {
+	case report.InvalidRows > 0:
+		log.Printf("%d of %d rows have errors; no cars were created", report.InvalidRows, report.TotalRows)
+		os.Exit(1)
+	case report.Committed:
+		log.Printf("Imported %d cars", len(report.CarIDs))
+	default:
+		log.Printf("All %d rows are valid; run again with -commit to import them", report.TotalRows)
+	}
+}
@@ -31,6 +31,7 @@ func main() {
 	permissions := seedPermissions()
 	seedRoleUser(users, roles)
 	seedPermissionRole(permissions, roles)
+	seedUserScopes(users)
 
 	makes := seedCarMakes()
 	models := seedCarModels(makes)
@@ -124,13 +125,23 @@ func seedRoles() map[string]int64 {
 func seedPermissions() map[string]int64 {
 	log.Println("Seeding Permissions...")
 	perms := make(map[string]int64)
-	permNames := []string{"car-create", "car-read", "car-update", "car-delete", "rag-ask", "rag-index"}
+	permNames := []string{"car-create", "car-read", "car-update", "car-delete", "rag-ask", "rag-index",
+		"user-manage", "role-manage", "permission-manage", "data-scope-bypass", "payment-read", "api-key-manage", "audit-read", "trash-manage",
+		"invoice-manage", "car-merge", "order-create", "price-override", "report-read", "report-manage"}
+	adminPerms := map[string]bool{"user-manage": true, "role-manage": true, "permission-manage": true, "data-scope-bypass": true,
+		"api-key-manage": true, "audit-read": true, "trash-manage": true, "report-manage": true}
 
 	for _, name := range permNames {
 		var id int64
 		slug := name
-		query := `INSERT INTO permissions (name, slug, module) VALUES ($1, $2, 'car') RETURNING id`
-		err := db.DB.QueryRow(query, name, slug).Scan(&id)
+		module := "car"
+		if adminPerms[name] {
+			module = "admin"
+		} else if name == "payment-read" || name == "invoice-manage" || name == "order-create" || name == "price-override" || name == "report-read" {
+			module = "finance"
+		}
+		query := `INSERT INTO permissions (name, slug, module) VALUES ($1, $2, $3) RETURNING id`
+		err := db.DB.QueryRow(query, name, slug, module).Scan(&id)
 		if err != nil {
 			log.Printf("Failed to seed permission %s: %v", name, err)
 		} else {
@@ -175,6 +186,26 @@ func seedPermissionRole(perms map[string]int64, roles map[string]int64) {
 		assignPerm(roleID, perms["rag-index"])
 	}
 
+	// Admin only: user, role and permission management
+	assignPerm(roles["admin"], perms["user-manage"])
+	assignPerm(roles["admin"], perms["role-manage"])
+	assignPerm(roles["admin"], perms["permission-manage"])
+	assignPerm(roles["admin"], perms["data-scope-bypass"])
+	assignPerm(roles["admin"], perms["payment-read"])
+	assignPerm(roles["admin"], perms["api-key-manage"])
+	assignPerm(roles["admin"], perms["audit-read"])
+	assignPerm(roles["admin"], perms["trash-manage"])
+	assignPerm(roles["admin"], perms["invoice-manage"])
+	assignPerm(roles["admin"], perms["car-merge"])
+	assignPerm(roles["admin"], perms["order-create"])
+	assignPerm(roles["admin"], perms["price-override"])
+	assignPerm(roles["admin"], perms["report-read"])
+	assignPerm(roles["admin"], perms["report-manage"])
+	assignPerm(roles["seller"], perms["order-create"])
+	assignPerm(roles["accountman"], perms["payment-read"])
+	assignPerm(roles["accountman"], perms["invoice-manage"])
+	assignPerm(roles["accountman"], perms["report-read"])
+
 	// Accountman & Call Center: Read Only + RAG ask
 	readOnlyRoles := []int64{roles["accountman"], roles["call center"]}
 	for _, roleID := range readOnlyRoles {
@@ -190,6 +221,22 @@ func assignPerm(roleID, permID int64) {
 	}
 }
 
+// seedBranches are the car locations; payment showrooms are named "<branch> Showroom".
+var seedBranches = []string{"Dhaka", "Chittagong"}
+
+// seedUserScopes limits every non-admin seed user to the first branch.
+func seedUserScopes(users []int64) {
+	log.Println("Seeding User Scopes...")
+	for _, userID := range users[1:] {
+		for scopeType, value := range map[string]string{"location": seedBranches[0], "showroom": seedBranches[0] + " Showroom"} {
+			_, err := db.DB.Exec("INSERT INTO user_scopes (user_id, scope_type, value) VALUES ($1, $2, $3)", userID, scopeType, value)
+			if err != nil {
+				log.Printf("Failed to seed %s scope for user %d: %v", scopeType, userID, err)
+			}
+		}
+	}
+}
+
 func seedCarMakes() []int64 {
 	log.Println("Seeding Car Makes...")
 	var ids []int64
@@ -234,9 +281,10 @@ func seedCars(models []int64) []int64 {
 			var id int64
 			refNo := fmt.Sprintf("REF-%d", i+1)
 			chassis := fmt.Sprintf("CH-%d", i+1)
-			query := `INSERT INTO cars (model_id, ref_no, chassis_no_full, year, engine_cc, fuel, transmission, drive, steering) 
-					  VALUES ($1, $2, $3, 2020, 1500, 'Petrol', 'Automatic', 'FWD', 'Right') RETURNING id`
-			err := db.DB.QueryRow(query, models[i], refNo, chassis).Scan(&id)
+			location := seedBranches[i%len(seedBranches)]
+			query := `INSERT INTO cars (model_id, ref_no, chassis_no_full, year, engine_cc, fuel, transmission, drive, steering, location) 
+					  VALUES ($1, $2, $3, 2020, 1500, 'Petrol', 'Automatic', 'FWD', 'Right', $4) RETURNING id`
+			err := db.DB.QueryRow(query, models[i], refNo, chassis, location).Scan(&id)
 			if err != nil {
 				log.Printf("Failed to seed car %d: %v", i, err)
 			} else {
@@ -377,8 +425,9 @@ func seedPaymentHistory(cars []int64) []int64 {
 	var ids []int64
 	for i, carID := range cars {
 		var id int64
-		query := `INSERT INTO payment_history (car_id, purchase_amount, customer_name) VALUES ($1, 6000.00, 'Customer') RETURNING id`
-		err := db.DB.QueryRow(query, carID).Scan(&id)
+		showroom := seedBranches[i%len(seedBranches)] + " Showroom"
+		query := `INSERT INTO payment_history (car_id, purchase_amount, customer_name, showroom_name) VALUES ($1, 6000.00, 'Customer', $2) RETURNING id`
+		err := db.DB.QueryRow(query, carID, showroom).Scan(&id)
 		if err != nil {
 			log.Printf("Failed to seed payment history %d: %v", i, err)
 		} else {
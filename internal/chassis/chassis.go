@@ -0,0 +1,241 @@
+// Package chassis validates and decodes chassis numbers: 17-character VINs (ISO 3779) and
+// the shorter frame numbers of Japanese domestic-market cars, such as "ZVW30-1234567".
+//
+// VINs are decoded offline. The manufacturer comes from an embedded table of world
+// manufacturer identifiers (WMI), the country from the ISO 3779 region codes and the model
+// year from the tenth character. The check digit is verified for every VIN but only
+// enforced where it is mandatory (North America and China); elsewhere many manufacturers
+// do not use it.
+package chassis
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Kinds of chassis number.
+const (
+	KindVIN   = "vin"
+	KindFrame = "frame"
+)
+
+// ErrInvalid is matched by every *Error.
+var ErrInvalid = errors.New("invalid chassis number")
+
+// Error explains why a chassis number is invalid. It matches ErrInvalid with errors.Is.
+type Error struct {
+	Reason string
+}
+
+func (e *Error) Error() string { return "invalid chassis number: " + e.Reason }
+func (e *Error) Unwrap() error { return ErrInvalid }
+
+// Info is a decoded chassis number. Fields that cannot be decoded are empty.
+type Info struct {
+	// Number is the normalized chassis number, as it should be stored.
+	Number       string `json:"number"`
+	Kind         string `json:"kind"`
+	WMI          string `json:"wmi,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Country      string `json:"country,omitempty"`
+	ModelYear    *int   `json:"model_year,omitempty"`
+	// CheckDigitValid is set for VINs; a wrong check digit is only an error where it is mandatory.
+	CheckDigitValid *bool `json:"check_digit_valid,omitempty"`
+	// ModelCode is the chassis model code of a frame number, e.g. ZVW30.
+	ModelCode string `json:"model_code,omitempty"`
+	Serial    string `json:"serial"`
+}
+
+var (
+	vinPattern   = regexp.MustCompile(`^[A-HJ-NPR-Z0-9]{17}$`)
+	framePattern = regexp.MustCompile(`^([A-Z][A-Z0-9]{1,6})-([0-9]{4,8})$`)
+)
+
+// Normalize upper-cases s and removes spaces, so that "zvw30 - 1234567" and "ZVW30-1234567"
+// are the same number.
+func Normalize(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+}
+
+// Decode normalizes and decodes a VIN or frame number. Anything else is an *Error.
+func Decode(number string) (*Info, error) {
+	return decode(number, time.Now())
+}
+
+// Validate reports whether number is a valid VIN or frame number, as an *Error if not.
+func Validate(number string) error {
+	_, err := Decode(number)
+	return err
+}
+
+func decode(number string, now time.Time) (*Info, error) {
+	n := Normalize(number)
+	if m := framePattern.FindStringSubmatch(n); m != nil {
+		return &Info{Number: n, Kind: KindFrame, Country: "Japan", ModelCode: m[1], Serial: m[2]}, nil
+	}
+	if len(n) != 17 {
+		return nil, &Error{Reason: "expected a 17-character VIN or a frame number such as ZVW30-1234567"}
+	}
+	if !vinPattern.MatchString(n) {
+		return nil, &Error{Reason: "a VIN may only contain digits and the letters A-Z except I, O and Q"}
+	}
+
+	info := &Info{Number: n, Kind: KindVIN, WMI: n[:3], Serial: n[11:]}
+	info.Manufacturer = manufacturer(n)
+	info.Country = country(n)
+
+	valid := n[8] == checkDigit(n)
+	info.CheckDigitValid = &valid
+	if !valid && checkDigitRequired(n) {
+		return nil, &Error{Reason: "check digit (9th character) should be " + string(checkDigit(n))}
+	}
+	if year, ok := modelYear(n, now); ok {
+		info.ModelYear = &year
+	}
+	return info, nil
+}
+
+// Check digit transliteration and position weights, from 49 CFR 565.
+var (
+	vinWeights = [17]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+	vinValues  = map[byte]int{
+		'A': 1, 'B': 2, 'C': 3, 'D': 4, 'E': 5, 'F': 6, 'G': 7, 'H': 8,
+		'J': 1, 'K': 2, 'L': 3, 'M': 4, 'N': 5, 'P': 7, 'R': 9,
+		'S': 2, 'T': 3, 'U': 4, 'V': 5, 'W': 6, 'X': 7, 'Y': 8, 'Z': 9,
+	}
+)
+
+func checkDigit(vin string) byte {
+	sum := 0
+	for i := 0; i < 17; i++ {
+		c := vin[i]
+		v, ok := vinValues[c]
+		if !ok {
+			v = int(c - '0')
+		}
+		sum += v * vinWeights[i]
+	}
+	if sum%11 == 10 {
+		return 'X'
+	}
+	return byte('0' + sum%11)
+}
+
+// checkDigitRequired reports whether the VIN was issued in North America or China, where the
+// check digit is mandatory.
+func checkDigitRequired(vin string) bool {
+	return (vin[0] >= '1' && vin[0] <= '5') || vin[0] == 'L'
+}
+
+// yearCodes are the model year characters, the first standing for 1980; they repeat every 30 years.
+const yearCodes = "ABCDEFGHJKLMNPRSTVWXY123456789"
+
+// modelYear decodes the tenth character. In North America the seventh character tells the
+// two 30-year cycles apart; elsewhere the latest year not after next year is assumed.
+// European manufacturers are not required to encode the year, so their VINs are not decoded.
+func modelYear(vin string, now time.Time) (int, bool) {
+	i := strings.IndexByte(yearCodes, vin[9])
+	if i < 0 || (vin[0] >= 'S' && vin[0] <= 'Z') {
+		return 0, false
+	}
+	year := 1980 + i
+	if vin[0] >= '1' && vin[0] <= '5' {
+		if vin[6] >= 'A' && vin[6] <= 'Z' {
+			year += 30
+		}
+		return year, true
+	}
+	for year+30 <= now.Year()+1 {
+		year += 30
+	}
+	return year, true
+}
+
+//go:embed wmi.csv
+var wmiCSV []byte
+
+// wmiTable maps WMIs, and two-character prefixes, to manufacturers.
+var wmiTable = func() map[string]string {
+	table := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(wmiCSV))
+	header := true
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if header {
+			header = false
+			continue
+		}
+		code, name, ok := strings.Cut(line, ",")
+		if ok {
+			table[code] = name
+		}
+	}
+	return table
+}()
+
+func manufacturer(vin string) string {
+	if name, ok := wmiTable[vin[:3]]; ok {
+		return name
+	}
+	return wmiTable[vin[:2]]
+}
+
+// regionOrder is the order of characters in ISO 3779 region code ranges.
+const regionOrder = "ABCDEFGHJKLMNPRSTUVWXYZ1234567890"
+
+// regions assigns ranges of the first two VIN characters to countries.
+var regions = []struct {
+	from, to string
+	country  string
+}{
+	{"AA", "AH", "South Africa"}, {"AJ", "AN", "Ivory Coast"},
+	{"BA", "BE", "Angola"}, {"BF", "BK", "Kenya"}, {"BL", "BR", "Tanzania"},
+	{"CA", "CE", "Benin"}, {"CF", "CK", "Madagascar"}, {"CL", "CR", "Tunisia"},
+	{"DA", "DE", "Egypt"}, {"DF", "DK", "Morocco"}, {"DL", "DR", "Zambia"},
+	{"EA", "EE", "Ethiopia"}, {"EF", "EK", "Mozambique"},
+	{"FA", "FE", "Ghana"}, {"FF", "FK", "Nigeria"},
+	{"JA", "J0", "Japan"},
+	{"KA", "KE", "Sri Lanka"}, {"KF", "KK", "Israel"}, {"KL", "KR", "South Korea"}, {"KS", "K0", "Kazakhstan"},
+	{"LA", "L0", "China"},
+	{"MA", "ME", "India"}, {"MF", "MK", "Indonesia"}, {"ML", "MR", "Thailand"}, {"MS", "M0", "Myanmar"},
+	{"NA", "NE", "Iran"}, {"NF", "NK", "Pakistan"}, {"NL", "NR", "Turkey"},
+	{"PA", "PE", "Philippines"}, {"PF", "PK", "Singapore"}, {"PL", "PR", "Malaysia"},
+	{"RA", "RE", "United Arab Emirates"}, {"RF", "RK", "Taiwan"}, {"RL", "RR", "Vietnam"}, {"RS", "R0", "Saudi Arabia"},
+	{"SA", "SM", "United Kingdom"}, {"SN", "ST", "Germany"}, {"SU", "SZ", "Poland"}, {"S1", "S4", "Latvia"},
+	{"TA", "TH", "Switzerland"}, {"TJ", "TP", "Czech Republic"}, {"TR", "TV", "Hungary"}, {"TW", "T1", "Portugal"},
+	{"UH", "UM", "Denmark"}, {"UN", "UT", "Ireland"}, {"UU", "UZ", "Romania"}, {"U5", "U7", "Slovakia"},
+	{"VA", "VE", "Austria"}, {"VF", "VR", "France"}, {"VS", "VW", "Spain"}, {"VX", "V2", "Serbia"},
+	{"V3", "V5", "Croatia"}, {"V6", "V0", "Estonia"},
+	{"WA", "W0", "Germany"},
+	{"XA", "XE", "Bulgaria"}, {"XF", "XK", "Greece"}, {"XL", "XR", "Netherlands"}, {"XS", "XW", "Russia"},
+	{"XX", "X2", "Luxembourg"}, {"X3", "X0", "Russia"},
+	{"YA", "YE", "Belgium"}, {"YF", "YK", "Finland"}, {"YL", "YR", "Malta"}, {"YS", "YW", "Sweden"},
+	{"YX", "Y2", "Norway"}, {"Y3", "Y5", "Belarus"}, {"Y6", "Y0", "Ukraine"},
+	{"ZA", "ZR", "Italy"}, {"ZX", "Z2", "Slovenia"}, {"Z3", "Z5", "Lithuania"},
+	{"1A", "10", "United States"}, {"4A", "40", "United States"}, {"5A", "50", "United States"},
+	{"2A", "20", "Canada"}, {"3A", "3W", "Mexico"},
+	{"6A", "6W", "Australia"}, {"7A", "7E", "New Zealand"},
+	{"8A", "8E", "Argentina"}, {"8F", "8K", "Chile"}, {"8L", "8R", "Ecuador"}, {"8S", "8W", "Peru"}, {"8X", "82", "Venezuela"},
+	{"9A", "9E", "Brazil"}, {"9F", "9K", "Colombia"}, {"9S", "9W", "Uruguay"}, {"93", "99", "Brazil"},
+}
+
+func country(vin string) string {
+	for _, r := range regions {
+		if r.from[0] != vin[0] {
+			continue
+		}
+		pos := strings.IndexByte(regionOrder, vin[1])
+		if pos >= strings.IndexByte(regionOrder, r.from[1]) && pos <= strings.IndexByte(regionOrder, r.to[1]) {
+			return r.country
+		}
+	}
+	return ""
+}
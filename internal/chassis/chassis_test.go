@@ -0,0 +1,117 @@
+package chassis
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("NorthAmericanVIN", func(t *testing.T) {
+		info, err := decode("1m8gdm9axkp042788", now)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if info.Number != "1M8GDM9AXKP042788" || info.Kind != KindVIN || info.WMI != "1M8" {
+			t.Errorf("Expected a normalized VIN, got %+v", info)
+		}
+		if info.Country != "United States" {
+			t.Errorf("Expected United States, got %q", info.Country)
+		}
+		if info.ModelYear == nil || *info.ModelYear != 1989 {
+			t.Errorf("Expected model year 1989, got %v", info.ModelYear)
+		}
+		if info.CheckDigitValid == nil || !*info.CheckDigitValid {
+			t.Errorf("Expected a valid check digit")
+		}
+	})
+
+	t.Run("JapaneseVIN", func(t *testing.T) {
+		info, err := decode("JTDBR32E160012345", now)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if info.Manufacturer != "Toyota" || info.Country != "Japan" {
+			t.Errorf("Expected a Toyota from Japan, got %q from %q", info.Manufacturer, info.Country)
+		}
+		if info.ModelYear == nil || *info.ModelYear != 2006 {
+			t.Errorf("Expected model year 2006, got %v", info.ModelYear)
+		}
+		if info.CheckDigitValid == nil || *info.CheckDigitValid {
+			t.Errorf("Expected the unused check digit to be reported as invalid")
+		}
+	})
+
+	t.Run("TwoCharacterWMIFallback", func(t *testing.T) {
+		info, err := decode("JHZZZ000000000000", now)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if info.Manufacturer != "Honda" {
+			t.Errorf("Expected Honda, got %q", info.Manufacturer)
+		}
+	})
+
+	t.Run("EuropeanVINHasNoYear", func(t *testing.T) {
+		info, err := decode("WVWZZZ1JZXW000001", now)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if info.Manufacturer != "Volkswagen" || info.Country != "Germany" || info.ModelYear != nil {
+			t.Errorf("Expected a Volkswagen from Germany without a year, got %+v", info)
+		}
+	})
+
+	t.Run("FrameNumber", func(t *testing.T) {
+		info, err := decode(" zvw30 - 1234567 ", now)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if info.Number != "ZVW30-1234567" || info.Kind != KindFrame || info.ModelCode != "ZVW30" || info.Serial != "1234567" {
+			t.Errorf("Expected frame ZVW30-1234567, got %+v", info)
+		}
+	})
+
+	invalid := map[string]string{
+		"WrongLength":        "JTD123",
+		"ForbiddenLetter":    "JTDBR32E16001234O",
+		"RequiredCheckDigit": "1M8GDM9A1KP042788",
+		"Empty":              "",
+	}
+	for name, number := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := decode(number, now)
+			var chassisErr *Error
+			if !errors.As(err, &chassisErr) || !errors.Is(err, ErrInvalid) {
+				t.Errorf("Expected an *Error for %q, got %v", number, err)
+			}
+		})
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	if d := checkDigit("11111111111111111"); d != '1' {
+		t.Errorf("Expected 1, got %c", d)
+	}
+	if d := checkDigit("1M8GDM9AXKP042788"); d != 'X' {
+		t.Errorf("Expected X, got %c", d)
+	}
+}
+
+func TestCountry(t *testing.T) {
+	tests := map[string]string{
+		"KMHxx": "South Korea",
+		"SALxx": "United Kingdom",
+		"VF1xx": "France",
+		"3VWxx": "Mexico",
+		"9BWxx": "Brazil",
+		"ZTZxx": "",
+	}
+	for vin, want := range tests {
+		if got := country(vin); got != want {
+			t.Errorf("Expected %q for %s, got %q", want, vin[:3], got)
+		}
+	}
+}
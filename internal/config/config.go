@@ -1,23 +1,72 @@
 package config
 
 import (
+	"errors"
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/user/car-project/internal/utils"
 )
 
+// DefaultJWTSecret is used when JWT_SECRET is unset; JWTKeys rejects it outside development.
+const DefaultJWTSecret = "default-secret"
+
 type Config struct {
-	DBURL          string
-	Port           string
-	JWTSecret      string
-	JWTExpiryHours int
+	// AppEnv is "development" for local runs; anything else is treated as a deployed environment
+	AppEnv        string
+	DBURL         string
+	Port          string
+	JWTSecret     string
+	JWTAccessTTL  time.Duration
+	JWTRefreshTTL time.Duration
+	// Asymmetric signing: PEM private key to sign with and extra PEM keys accepted for verification
+	JWTSigningKeyFile string
+	JWTVerifyKeyFiles []string
+	// Embed role and permission slugs into access tokens
+	JWTEmbedPermissions bool
+	// Reverse proxies (IPs or CIDRs) whose X-Forwarded-For is trusted for the client IP;
+	// empty means the client IP is always the connection's address
+	TrustedProxies []string
+	// Login lockout
+	LoginMaxFailuresPerUser int
+	LoginMaxFailuresPerIP   int
+	LoginLockout            time.Duration
+	// Per-process cache of resolved user permissions; 0 disables it
+	PermissionCacheTTL time.Duration
+	// Per-process cache of computed reports; 0 disables it
+	ReportCacheTTL time.Duration
+	// The materialized views behind the reports are refreshed every ReportRefreshInterval
+	// (0 disables it)
+	ReportRefreshInterval time.Duration
+	// Password policy and reset
+	PasswordPolicy   utils.PasswordPolicy
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+	NotifyFile       string
+	// Soft-deleted cars and users are purged once they have been in the trash this long;
+	// the purge job runs every TrashPurgeInterval (0 disables it)
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+	// Generated documents: storage directory, optional directory of template overrides and
+	// the seller printed on spec sheets and invoices
+	DocumentsDir        string
+	DocumentTemplateDir string
+	CompanyName         string
+	CompanyAddress      string
+	CompanyPhone        string
 	// RAG / OpenAI
 	OpenAIAPIKey      string
 	RAGEmbeddingModel string
 	RAGChatModel      string
 	RAGTopK           int
+	// RAG provider resilience (retries, batching, rate limiting)
+	RAGMaxRetries      int
+	RAGEmbedBatchSize  int
+	RAGTokensPerMinute int
 }
 
 func LoadConfig() *Config {
@@ -40,18 +89,133 @@ func LoadConfig() *Config {
 		port = "8080"
 	}
 
+	appEnv := os.Getenv("APP_ENV")
+	if appEnv == "" {
+		appEnv = "production"
+	}
+
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
-		jwtSecret = "default-secret"
+		jwtSecret = DefaultJWTSecret
+	}
+	var jwtVerifyKeyFiles []string
+	for _, f := range strings.Split(os.Getenv("JWT_VERIFY_KEY_FILES"), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			jwtVerifyKeyFiles = append(jwtVerifyKeyFiles, f)
+		}
+	}
+
+	var trustedProxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			trustedProxies = append(trustedProxies, p)
+		}
+	}
+
+	jwtAccessTTL := 15 * time.Minute
+	if ttl := os.Getenv("JWT_ACCESS_TTL_MINUTES"); ttl != "" {
+		if val, err := strconv.Atoi(ttl); err == nil && val > 0 {
+			jwtAccessTTL = time.Duration(val) * time.Minute
+		}
+	} else if expiry := os.Getenv("JWT_EXPIRY_HOURS"); expiry != "" {
+		// JWT_EXPIRY_HOURS set the lifetime of the single token issued before refresh tokens.
+		log.Println("JWT_EXPIRY_HOURS is deprecated; set JWT_ACCESS_TTL_MINUTES and JWT_REFRESH_TTL_HOURS instead")
+		if val, err := strconv.Atoi(expiry); err == nil && val > 0 {
+			jwtAccessTTL = time.Duration(val) * time.Hour
+		}
+	}
+
+	jwtRefreshTTL := 30 * 24 * time.Hour
+	if ttl := os.Getenv("JWT_REFRESH_TTL_HOURS"); ttl != "" {
+		if val, err := strconv.Atoi(ttl); err == nil && val > 0 {
+			jwtRefreshTTL = time.Duration(val) * time.Hour
+		}
+	}
+
+	loginMaxFailuresPerUser := 5
+	if v := os.Getenv("LOGIN_MAX_FAILURES_PER_USER"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val >= 0 {
+			loginMaxFailuresPerUser = val
+		}
+	}
+	loginMaxFailuresPerIP := 20
+	if v := os.Getenv("LOGIN_MAX_FAILURES_PER_IP"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val >= 0 {
+			loginMaxFailuresPerIP = val
+		}
+	}
+	loginLockout := 15 * time.Minute
+	if v := os.Getenv("LOGIN_LOCKOUT_MINUTES"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val > 0 {
+			loginLockout = time.Duration(val) * time.Minute
+		}
+	}
+
+	permissionCacheTTL := time.Minute
+	if v := os.Getenv("PERMISSION_CACHE_TTL_SECONDS"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val >= 0 {
+			permissionCacheTTL = time.Duration(val) * time.Second
+		}
+	}
+
+	reportCacheTTL := 5 * time.Minute
+	if v := os.Getenv("REPORT_CACHE_TTL_SECONDS"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val >= 0 {
+			reportCacheTTL = time.Duration(val) * time.Second
+		}
+	}
+	reportRefreshInterval := 15 * time.Minute
+	if v := os.Getenv("REPORT_REFRESH_INTERVAL_MINUTES"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val >= 0 {
+			reportRefreshInterval = time.Duration(val) * time.Minute
+		}
+	}
+
+	passwordPolicy := utils.PasswordPolicy{
+		MinLength:     8,
+		RequireUpper:  envBool("PASSWORD_REQUIRE_UPPER", true),
+		RequireLower:  envBool("PASSWORD_REQUIRE_LOWER", true),
+		RequireDigit:  envBool("PASSWORD_REQUIRE_DIGIT", true),
+		RequireSymbol: envBool("PASSWORD_REQUIRE_SYMBOL", false),
+	}
+	if v := os.Getenv("PASSWORD_MIN_LENGTH"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val > 0 {
+			passwordPolicy.MinLength = val
+		}
+	}
+	passwordResetTTL := time.Hour
+	if v := os.Getenv("PASSWORD_RESET_TTL_MINUTES"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val > 0 {
+			passwordResetTTL = time.Duration(val) * time.Minute
+		}
+	}
+	notifyFile := os.Getenv("NOTIFY_FILE")
+	if notifyFile == "" {
+		notifyFile = "notifications.log"
 	}
 
-	jwtExpiryHours := 24
-	if expiry := os.Getenv("JWT_EXPIRY_HOURS"); expiry != "" {
-		if val, err := strconv.Atoi(expiry); err == nil {
-			jwtExpiryHours = val
+	trashRetention := 30 * 24 * time.Hour
+	if v := os.Getenv("TRASH_RETENTION_DAYS"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val > 0 {
+			trashRetention = time.Duration(val) * 24 * time.Hour
+		}
+	}
+	trashPurgeInterval := time.Hour
+	if v := os.Getenv("TRASH_PURGE_INTERVAL_MINUTES"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val >= 0 {
+			trashPurgeInterval = time.Duration(val) * time.Minute
 		}
 	}
 
+	documentsDir := os.Getenv("DOCUMENTS_DIR")
+	if documentsDir == "" {
+		documentsDir = "storage/documents"
+	}
+	companyName := os.Getenv("COMPANY_NAME")
+	if companyName == "" {
+		companyName = "Car Project"
+	}
+
 	openAIKey := os.Getenv("OPENAI_API_KEY")
 	ragEmbedModel := os.Getenv("RAG_EMBEDDING_MODEL")
 	if ragEmbedModel == "" {
@@ -68,14 +232,98 @@ func LoadConfig() *Config {
 		}
 	}
 
+	ragMaxRetries := 5
+	if v := os.Getenv("RAG_MAX_RETRIES"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val >= 0 {
+			ragMaxRetries = val
+		}
+	}
+	ragEmbedBatchSize := 100
+	if v := os.Getenv("RAG_EMBED_BATCH_SIZE"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val > 0 {
+			ragEmbedBatchSize = val
+		}
+	}
+	ragTokensPerMinute := 0
+	if v := os.Getenv("RAG_TOKENS_PER_MINUTE"); v != "" {
+		if val, err := strconv.Atoi(v); err == nil && val >= 0 {
+			ragTokensPerMinute = val
+		}
+	}
+
 	return &Config{
-		DBURL:             dbURL,
-		Port:              port,
-		JWTSecret:         jwtSecret,
-		JWTExpiryHours:    jwtExpiryHours,
+		AppEnv:        appEnv,
+		DBURL:         dbURL,
+		Port:          port,
+		JWTSecret:     jwtSecret,
+		JWTAccessTTL:  jwtAccessTTL,
+		JWTRefreshTTL: jwtRefreshTTL,
+
+		JWTSigningKeyFile: os.Getenv("JWT_SIGNING_KEY_FILE"),
+		JWTVerifyKeyFiles: jwtVerifyKeyFiles,
+
+		JWTEmbedPermissions: envBool("JWT_EMBED_PERMISSIONS", false),
+
+		TrustedProxies: trustedProxies,
+
+		LoginMaxFailuresPerUser: loginMaxFailuresPerUser,
+		LoginMaxFailuresPerIP:   loginMaxFailuresPerIP,
+		LoginLockout:            loginLockout,
+
+		PermissionCacheTTL: permissionCacheTTL,
+		ReportCacheTTL:     reportCacheTTL,
+
+		ReportRefreshInterval: reportRefreshInterval,
+
+		PasswordPolicy:   passwordPolicy,
+		PasswordResetTTL: passwordResetTTL,
+		PasswordResetURL: os.Getenv("PASSWORD_RESET_URL"),
+		NotifyFile:       notifyFile,
+
+		TrashRetention:     trashRetention,
+		TrashPurgeInterval: trashPurgeInterval,
+
+		DocumentsDir:        documentsDir,
+		DocumentTemplateDir: os.Getenv("DOCUMENT_TEMPLATE_DIR"),
+		CompanyName:         companyName,
+		CompanyAddress:      os.Getenv("COMPANY_ADDRESS"),
+		CompanyPhone:        os.Getenv("COMPANY_PHONE"),
+
 		OpenAIAPIKey:      openAIKey,
 		RAGEmbeddingModel: ragEmbedModel,
 		RAGChatModel:      ragChatModel,
 		RAGTopK:           ragTopK,
+
+		RAGMaxRetries:      ragMaxRetries,
+		RAGEmbedBatchSize:  ragEmbedBatchSize,
+		RAGTokensPerMinute: ragTokensPerMinute,
+	}
+}
+
+// IsDevelopment reports whether the app runs in local development mode.
+func (c *Config) IsDevelopment() bool {
+	return c.AppEnv == "development"
+}
+
+// JWTKeys builds the access token key set. With JWT_SIGNING_KEY_FILE set, tokens are signed
+// with that RSA or Ed25519 key and JWT_SECRET is ignored; otherwise they are signed with
+// JWT_SECRET, which must not be the built-in default outside development.
+func (c *Config) JWTKeys() (*utils.KeySet, error) {
+	if c.JWTSigningKeyFile != "" {
+		return utils.LoadKeySet(c.JWTSigningKeyFile, c.JWTVerifyKeyFiles)
+	}
+	if c.JWTSecret == DefaultJWTSecret && !c.IsDevelopment() {
+		return nil, errors.New("JWT_SECRET is not set; configure JWT_SECRET or JWT_SIGNING_KEY_FILE, or set APP_ENV=development")
+	}
+	return utils.NewHMACKeySet(c.JWTSecret), nil
+}
+
+// envBool reads a boolean env var, falling back to def when unset or unparsable.
+func envBool(key string, def bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if val, err := strconv.ParseBool(v); err == nil {
+			return val
+		}
 	}
+	return def
 }
@@ -0,0 +1,66 @@
+package docgen
+
+import (
+	"time"
+
+	"github.com/user/car-project/internal/models"
+)
+
+// Template names.
+const (
+	SpecSheetTemplate = "spec_sheet"
+	InvoiceTemplate   = "invoice"
+)
+
+// Company is the seller printed on documents.
+type Company struct {
+	Name    string
+	Address string
+	Phone   string
+}
+
+// SpecSheet is the data of the spec_sheet template.
+type SpecSheet struct {
+	Company     Company
+	Car         models.Car
+	Make        string
+	Model       string
+	Grade       *models.CarGrade
+	Detail      *models.CarDetail
+	SubDetails  []models.CarSubDetail
+	PhotoURL    string
+	GeneratedAt time.Time
+}
+
+// Invoice is the data of the invoice template.
+type Invoice struct {
+	Company  Company
+	Number   string
+	Date     time.Time
+	Order    models.Order
+	Customer Customer
+	Lines    []InvoiceLine
+	Subtotal float64
+	Total    float64
+}
+
+// Customer is the buyer printed on an invoice.
+type Customer struct {
+	Name    string
+	NID     string
+	TIN     string
+	Address string
+	Phone   string
+	Email   string
+}
+
+// InvoiceLine is one car on an invoice.
+type InvoiceLine struct {
+	No          int
+	Description string
+	RefNo       string
+	ChassisNo   string
+	Quantity    int
+	UnitPrice   float64
+	Amount      float64
+}
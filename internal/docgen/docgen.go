@@ -0,0 +1,234 @@
+// Package docgen renders printable PDF documents, such as car spec sheets and invoices, from
+// templates that can be replaced without rebuilding the server.
+//
+// A template is a Go text/template that produces a small line-based markup, which is laid
+// out on A4 pages:
+//
+//	# Title                  large bold heading
+//	## Section               section heading with a rule underneath
+//	**Bold line**            a line of bold text
+//	>> Right-aligned text    a line aligned to the right margin (may be **bold**)
+//	---                      horizontal rule
+//	![caption](url)          image, scaled to the page width
+//	| a | b |                table row; a |---|---:| row makes the rows above it the header
+//	                         and sets each column's alignment (:--- left, ---: right, :---: centre)
+//	any other line           paragraph text, wrapped at the margins
+//
+// An empty line adds vertical space and a line starting with a backslash is printed as
+// text without it. Besides the standard template functions, templates can use money, date,
+// number, val, cell and text; see templateFuncs.
+package docgen
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/user/car-project/internal/utils"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// ImageLoader fetches the image referenced by an ![caption](src) line.
+type ImageLoader func(ctx context.Context, src string) ([]byte, error)
+
+// Renderer renders templates to PDF.
+type Renderer struct {
+	dir    string
+	images ImageLoader
+}
+
+// NewRenderer returns a Renderer that uses <dir>/<name>.tmpl in place of a built-in template
+// when that file exists; dir may be empty to always use the built-in ones. Templates are read
+// on every render, so edits take effect immediately. images may be nil to leave images out.
+func NewRenderer(dir string, images ImageLoader) *Renderer {
+	return &Renderer{dir: dir, images: images}
+}
+
+// Render executes the named template with data and writes the PDF, titled title, to w.
+func (r *Renderer) Render(ctx context.Context, w io.Writer, name, title string, data interface{}) error {
+	tmpl, err := r.load(name)
+	if err != nil {
+		return err
+	}
+	var markup bytes.Buffer
+	if err := tmpl.Execute(&markup, data); err != nil {
+		return fmt.Errorf("template %s: %w", name, err)
+	}
+	return layoutMarkup(ctx, w, title, markup.String(), r.images)
+}
+
+func (r *Renderer) load(name string) (*template.Template, error) {
+	file := name + ".tmpl"
+	var src []byte
+	var err error
+	if r.dir != "" {
+		src, err = os.ReadFile(filepath.Join(r.dir, file))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if src == nil {
+		if src, err = builtinTemplates.ReadFile("templates/" + file); err != nil {
+			return nil, fmt.Errorf("unknown template %q", name)
+		}
+	}
+	tmpl, err := template.New(file).Funcs(templateFuncs).Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// templateFuncs are available in every template. Pointer arguments are dereferenced and
+// nil prints as an empty string ("-" for val, cell and text).
+var templateFuncs = template.FuncMap{
+	// money formats an amount with two decimals and thousands separators: 1,250,000.00.
+	"money": func(v interface{}) string {
+		if f, ok := indirect(v).(float64); ok {
+			return utils.FormatMoney(f)
+		}
+		return ""
+	},
+	// date formats a time as 02 Jan 2006.
+	"date": func(v interface{}) string {
+		if t, ok := indirect(v).(time.Time); ok && !t.IsZero() {
+			return t.Format("02 Jan 2006")
+		}
+		return ""
+	},
+	// number formats an integer with thousands separators: 12,500.
+	"number": func(v interface{}) string {
+		rv := reflect.ValueOf(indirect(v))
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return utils.GroupThousands(rv.Int())
+		}
+		return ""
+	},
+	// val prints a value, or "-" when it is nil or empty.
+	"val": val,
+	// cell is val made safe for a table cell: pipes are escaped and line breaks removed.
+	"cell": func(v interface{}) string {
+		s := strings.Join(strings.Fields(val(v)), " ")
+		return strings.ReplaceAll(s, "|", `\|`)
+	},
+	// text is val made safe as paragraph text: lines that would be read as markup are escaped.
+	"text": func(v interface{}) string {
+		lines := strings.Split(strings.ReplaceAll(val(v), "\r\n", "\n"), "\n")
+		for i, line := range lines {
+			if line != "" && strings.ContainsAny(line[:1], `#*>-!|\`) {
+				lines[i] = `\` + line
+			}
+		}
+		return strings.Join(lines, "\n")
+	},
+}
+
+func indirect(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+func val(v interface{}) string {
+	v = indirect(v)
+	if v == nil {
+		return "-"
+	}
+	s := fmt.Sprint(v)
+	if t, ok := v.(time.Time); ok {
+		s = t.Format("02 Jan 2006")
+	}
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
+
+// errInternalAddress is returned when an image URL resolves to an address on this host or
+// its networks.
+var errInternalAddress = errors.New("image URL resolves to a loopback, link-local or private address")
+
+// publicOnly is a net.Dialer Control function that refuses to connect to loopback,
+// link-local, private and unspecified addresses, so image URLs cannot reach internal
+// services. It sees the resolved address of every connection, redirects included.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return errInternalAddress
+	}
+	return nil
+}
+
+// HTTPImageLoader returns an ImageLoader that downloads http and https URLs from public
+// addresses, giving up after timeout and refusing images larger than maxBytes.
+func HTTPImageLoader(timeout time.Duration, maxBytes int64) ImageLoader {
+	return httpImageLoader(timeout, maxBytes, publicOnly)
+}
+
+func httpImageLoader(timeout time.Duration, maxBytes int64, control func(network, address string, c syscall.RawConn) error) ImageLoader {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+	client := &http.Client{
+		Timeout: timeout,
+		// No proxy: the dialer must see the image host's own address.
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: timeout},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 3 {
+				return errors.New("too many redirects")
+			}
+			return nil
+		},
+	}
+	return func(ctx context.Context, src string) ([]byte, error) {
+		if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+			return nil, fmt.Errorf("unsupported image URL %q", src)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("image %s: %s", src, resp.Status)
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) > maxBytes {
+			return nil, fmt.Errorf("image %s is larger than %d bytes", src, maxBytes)
+		}
+		return data, nil
+	}
+}
@@ -0,0 +1,161 @@
+package docgen
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/user/car-project/internal/models"
+)
+
+// pageText returns the decompressed content streams of a rendered PDF.
+func pageText(t *testing.T, out []byte) string {
+	t.Helper()
+	var text strings.Builder
+	for _, m := range regexp.MustCompile(`(?s)/FlateDecode >>\nstream\n(.*?)\nendstream`).FindAllSubmatch(out, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(m[1]))
+		if err != nil {
+			t.Fatalf("Expected a Flate stream, got %v", err)
+		}
+		b, _ := io.ReadAll(zr)
+		text.Write(b)
+	}
+	return text.String()
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestRenderInvoice(t *testing.T) {
+	invoice := Invoice{
+		Company:  Company{Name: "Car Project Ltd", Address: "Dhaka"},
+		Number:   "INV-000042",
+		Date:     time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC),
+		Order:    models.Order{ID: 42, Status: "approved"},
+		Customer: Customer{Name: "Rahim | Sons", NID: "1990123456"},
+		Lines: []InvoiceLine{
+			{No: 1, Description: "Toyota Axio", RefNo: "R-1", Quantity: 1, UnitPrice: 1250000, Amount: 1250000},
+		},
+		Subtotal: 1250000,
+		Total:    1250000,
+	}
+	var buf bytes.Buffer
+	if err := NewRenderer("", nil).Render(context.Background(), &buf, InvoiceTemplate, "Invoice INV-000042", invoice); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("Expected a PDF")
+	}
+	text := pageText(t, buf.Bytes())
+	for _, want := range []string{"(INVOICE)", "(INV-000042)", "(05 Mar 2026)", "(Rahim | Sons)", "(1,250,000.00)", "(Total: BDT 1,250,000.00)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %s in the page text", want)
+		}
+	}
+}
+
+func TestRenderSpecSheet(t *testing.T) {
+	year := int16(2018)
+	sheet := SpecSheet{
+		Company:    Company{Name: "Car Project Ltd"},
+		Car:        models.Car{RefNo: strPtr("R-7"), Year: &year},
+		Make:       "Toyota",
+		Model:      "Axio",
+		Grade:      &models.CarGrade{GradeOverall: strPtr("4.5")},
+		SubDetails: []models.CarSubDetail{{Title: strPtr("Safety"), Description: strPtr("# ABS\n- Airbags")}},
+		PhotoURL:   "https://example.com/axio.jpg",
+	}
+	images := func(ctx context.Context, src string) ([]byte, error) { return nil, errors.New("offline") }
+
+	var buf bytes.Buffer
+	if err := NewRenderer("", images).Render(context.Background(), &buf, SpecSheetTemplate, "Spec sheet", sheet); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	text := pageText(t, buf.Bytes())
+	for _, want := range []string{"(Toyota Axio 2018)", "(4.5)", "(Photo unavailable)", "(# ABS)", "(- Airbags)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %s in the page text", want)
+		}
+	}
+}
+
+func TestRendererOverrideDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "invoice.tmpl"), []byte("# Custom {{.Number}}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := NewRenderer(dir, nil)
+
+	t.Run("UsesOverride", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := r.Render(context.Background(), &buf, InvoiceTemplate, "", Invoice{Number: "INV-1"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if text := pageText(t, buf.Bytes()); !strings.Contains(text, "(Custom INV-1)") {
+			t.Errorf("Expected the custom template, got %q", text)
+		}
+	})
+
+	t.Run("FallsBackToBuiltin", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := r.Render(context.Background(), &buf, SpecSheetTemplate, "", SpecSheet{}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("UnknownTemplate", func(t *testing.T) {
+		if err := r.Render(context.Background(), io.Discard, "missing", "", nil); err == nil {
+			t.Errorf("Expected an error for an unknown template")
+		}
+	})
+}
+
+func TestSplitCells(t *testing.T) {
+	tests := map[string][]string{
+		"| a | b |":      {"a", "b"},
+		`| a \| b | c |`: {"a | b", "c"},
+		"| a | b":        {"a", "b"},
+		"|---:|:---:|":   {"---:", ":---:"},
+		"| | x |":        {"", "x"},
+	}
+	for line, want := range tests {
+		if got := splitCells(line); !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %q for %q, got %q", want, line, got)
+		}
+	}
+}
+
+func TestHTTPImageLoaderRefusesInternalAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("image"))
+	}))
+	defer srv.Close()
+
+	if _, err := HTTPImageLoader(time.Second, 1<<20)(context.Background(), srv.URL); !errors.Is(err, errInternalAddress) {
+		t.Errorf("Expected errInternalAddress for a loopback URL, got %v", err)
+	}
+
+	// A public image that redirects to an internal one is refused on the second hop.
+	redirect := httptest.NewServer(http.RedirectHandler(srv.URL, http.StatusFound))
+	defer redirect.Close()
+	firstHopOnly := func(network, address string, c syscall.RawConn) error {
+		if address == redirect.Listener.Addr().String() {
+			return nil
+		}
+		return publicOnly(network, address, c)
+	}
+	if _, err := httpImageLoader(time.Second, 1<<20, firstHopOnly)(context.Background(), redirect.URL); !errors.Is(err, errInternalAddress) {
+		t.Errorf("Expected errInternalAddress after a redirect, got %v", err)
+	}
+}
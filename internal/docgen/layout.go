@@ -0,0 +1,308 @@
+package docgen
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/user/car-project/internal/pdf"
+)
+
+// Page layout, in points on a portrait A4 page.
+const (
+	margin      = 48
+	fontSize    = 10
+	lineHeight  = 14
+	rowHeight   = 16
+	cellPadding = 5
+	maxImageH   = 260
+)
+
+var (
+	imageLine     = regexp.MustCompile(`^!\[([^\]]*)\]\((.+)\)$`)
+	separatorCell = regexp.MustCompile(`^:?-+:?$`)
+)
+
+type align int
+
+const (
+	alignLeft align = iota
+	alignRight
+	alignCenter
+)
+
+// layout places markup elements on pages from top to bottom, starting a new page when the
+// next element does not fit.
+type layout struct {
+	ctx    context.Context
+	doc    *pdf.Document
+	page   *pdf.Page
+	y      float64
+	images ImageLoader
+}
+
+func layoutMarkup(ctx context.Context, w io.Writer, title, markup string, images ImageLoader) error {
+	l := &layout{ctx: ctx, doc: pdf.New(w, pdf.A4Width, pdf.A4Height), images: images}
+	l.doc.SetTitle(title)
+	l.newPage()
+
+	lines := strings.Split(markup, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		switch {
+		case strings.HasPrefix(line, `\`):
+			l.paragraph(line[1:], pdf.Helvetica)
+		case strings.TrimSpace(line) == "":
+			l.y += lineHeight / 2
+		case strings.HasPrefix(line, "# "):
+			l.ensure(34)
+			l.y += 24
+			l.page.Text(margin, l.y, pdf.HelveticaBold, 18, strings.TrimSpace(line[2:]))
+			l.y += 8
+		case strings.HasPrefix(line, "## "):
+			l.ensure(36)
+			l.y += 22
+			l.page.Text(margin, l.y, pdf.HelveticaBold, 12, strings.TrimSpace(line[3:]))
+			l.y += 5
+			l.page.Line(margin, l.y, l.right(), l.y, 0.5)
+			l.y += 3
+		case line == "---":
+			l.ensure(12)
+			l.y += 6
+			l.page.Line(margin, l.y, l.right(), l.y, 1)
+			l.y += 6
+		case strings.HasPrefix(line, ">> "):
+			text, font := boldText(strings.TrimSpace(line[3:]))
+			l.ensure(lineHeight)
+			l.y += lineHeight
+			l.page.TextRight(l.right(), l.y, font, fontSize, pdf.Truncate(font, fontSize, text, l.width()))
+		case strings.HasPrefix(line, "|"):
+			j := i
+			for j < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[j]), "|") {
+				j++
+			}
+			l.table(lines[i:j])
+			i = j - 1
+		case imageLine.MatchString(line):
+			m := imageLine.FindStringSubmatch(line)
+			l.image(m[1], m[2])
+		default:
+			text, font := boldText(line)
+			l.paragraph(text, font)
+		}
+	}
+	return l.doc.Close()
+}
+
+func (l *layout) newPage() {
+	l.page = l.doc.AddPage()
+	l.y = margin
+}
+
+// ensure starts a new page unless height more points fit on this one, and reports whether it did.
+func (l *layout) ensure(height float64) bool {
+	if l.y+height <= l.doc.Height()-margin {
+		return false
+	}
+	l.newPage()
+	return true
+}
+
+func (l *layout) right() float64 { return l.doc.Width() - margin }
+func (l *layout) width() float64 { return l.doc.Width() - 2*margin }
+
+// boldText strips the ** markers of a bold line.
+func boldText(s string) (string, pdf.Font) {
+	if len(s) > 4 && strings.HasPrefix(s, "**") && strings.HasSuffix(s, "**") {
+		return s[2 : len(s)-2], pdf.HelveticaBold
+	}
+	return s, pdf.Helvetica
+}
+
+// paragraph draws text wrapped at the margins.
+func (l *layout) paragraph(text string, font pdf.Font) {
+	var line string
+	flush := func() {
+		l.ensure(lineHeight)
+		l.y += lineHeight
+		l.page.Text(margin, l.y, font, fontSize, line)
+		line = ""
+	}
+	for _, word := range strings.Fields(text) {
+		next := word
+		if line != "" {
+			next = line + " " + word
+		}
+		if line != "" && pdf.TextWidth(font, fontSize, next) > l.width() {
+			flush()
+			next = word
+		}
+		line = pdf.Truncate(font, fontSize, next, l.width())
+	}
+	if line != "" {
+		flush()
+	}
+}
+
+// image draws an image scaled to fit the page width, or the caption in a grey box when it
+// cannot be loaded.
+func (l *layout) image(caption, src string) {
+	var img *pdf.Image
+	if l.images != nil {
+		if data, err := l.images(l.ctx, src); err == nil {
+			img, _ = l.doc.AddImage(data)
+		}
+	}
+	if img == nil {
+		l.ensure(48)
+		l.page.FillRect(margin, l.y+6, l.width(), 36, 0.92)
+		if caption == "" {
+			caption = "Image"
+		}
+		l.page.Text(margin+cellPadding, l.y+28, pdf.Helvetica, fontSize, caption+" unavailable")
+		l.y += 48
+		return
+	}
+	w, h := float64(img.Width), float64(img.Height)
+	if w > l.width() {
+		w, h = l.width(), h*l.width()/w
+	}
+	if h > maxImageH {
+		w, h = w*maxImageH/h, maxImageH
+	}
+	l.ensure(h + 12)
+	l.page.DrawImage(img, margin, l.y+6, w, h)
+	l.y += h + 12
+}
+
+// table draws markdown table rows. Rows above a separator row are the header, which is
+// repeated when the table continues on a new page.
+func (l *layout) table(lines []string) {
+	var header, body [][]string
+	var aligns []align
+	for _, line := range lines {
+		cells := splitCells(line)
+		if aligns == nil && isSeparator(cells) {
+			header, body = body, nil
+			aligns = make([]align, len(cells))
+			for i, c := range cells {
+				switch {
+				case strings.HasPrefix(c, ":") && strings.HasSuffix(c, ":"):
+					aligns[i] = alignCenter
+				case strings.HasSuffix(c, ":"):
+					aligns[i] = alignRight
+				}
+			}
+			continue
+		}
+		body = append(body, cells)
+	}
+
+	widths := l.columnWidths(append(append([][]string{}, header...), body...))
+	drawHeader := func() {
+		for _, row := range header {
+			l.page.FillRect(margin, l.y, l.width(), rowHeight, 0.9)
+			l.row(row, widths, aligns, pdf.HelveticaBold)
+		}
+	}
+	l.ensure(float64(len(header)+1) * rowHeight)
+	l.y += 4
+	drawHeader()
+	for _, row := range body {
+		if l.ensure(rowHeight) {
+			drawHeader()
+		}
+		l.row(row, widths, aligns, pdf.Helvetica)
+		l.page.Line(margin, l.y, l.right(), l.y, 0.25)
+	}
+}
+
+func (l *layout) row(cells []string, widths []float64, aligns []align, font pdf.Font) {
+	x := float64(margin)
+	for i, w := range widths {
+		var text string
+		if i < len(cells) {
+			text = pdf.Truncate(font, fontSize, cells[i], w-2*cellPadding)
+		}
+		a := alignLeft
+		if i < len(aligns) {
+			a = aligns[i]
+		}
+		baseline := l.y + rowHeight - 4.5
+		switch a {
+		case alignRight:
+			l.page.TextRight(x+w-cellPadding, baseline, font, fontSize, text)
+		case alignCenter:
+			l.page.Text(x+(w-pdf.TextWidth(font, fontSize, text))/2, baseline, font, fontSize, text)
+		default:
+			l.page.Text(x+cellPadding, baseline, font, fontSize, text)
+		}
+		x += w
+	}
+	l.y += rowHeight
+}
+
+// columnWidths sizes columns in proportion to their widest cell, filling the page width.
+func (l *layout) columnWidths(rows [][]string) []float64 {
+	var natural []float64
+	for _, row := range rows {
+		for i, cell := range row {
+			w := pdf.TextWidth(pdf.HelveticaBold, fontSize, cell) + 2*cellPadding
+			if i >= len(natural) {
+				natural = append(natural, 0)
+			}
+			if w > natural[i] {
+				natural[i] = w
+			}
+		}
+	}
+	total := 0.0
+	for _, w := range natural {
+		total += w
+	}
+	for i := range natural {
+		if total > 0 {
+			natural[i] *= l.width() / total
+		} else {
+			natural[i] = l.width() / float64(len(natural))
+		}
+	}
+	return natural
+}
+
+// splitCells splits "| a | b \| c |" into ["a", "b | c"].
+func splitCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	var cells []string
+	var cell strings.Builder
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			cell.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteRune(r)
+		}
+	}
+	if rest := strings.TrimSpace(cell.String()); rest != "" {
+		cells = append(cells, rest)
+	}
+	return cells
+}
+
+func isSeparator(cells []string) bool {
+	for _, c := range cells {
+		if !separatorCell.MatchString(c) {
+			return false
+		}
+	}
+	return len(cells) > 0
+}
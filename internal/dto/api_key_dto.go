@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+type CreateAPIKeyRequest struct {
+	Name   string `json:"name" binding:"required,min=2,max=100"`
+	RoleID int64  `json:"role_id" binding:"required"`
+	// ExpiresAt is optional; keys without it never expire.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// AllowedIPs restricts use of the key to these addresses or CIDR ranges; empty allows any.
+	AllowedIPs []string `json:"allowed_ips,omitempty" binding:"omitempty,dive,ip|cidr"`
+}
+
+type APIKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	KeyPrefix  string     `json:"key_prefix"`
+	UserID     int64      `json:"user_id"`
+	RoleID     int64      `json:"role_id"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedBy  *int64     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKeyResponse is returned once, on creation; the key cannot be retrieved again.
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
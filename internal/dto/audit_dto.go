@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+// AuditLogQuery filters GET /audit-logs. From and To are dates (YYYY-MM-DD); To is inclusive.
+type AuditLogQuery struct {
+	EntityType string     `form:"entity_type" binding:"omitempty,max=50"`
+	EntityID   *int64     `form:"entity_id" binding:"omitempty,gt=0"`
+	ActorID    *int64     `form:"actor_id" binding:"omitempty,gt=0"`
+	From       *time.Time `form:"from" time_format:"2006-01-02"`
+	To         *time.Time `form:"to" time_format:"2006-01-02"`
+	Page       int        `form:"page" binding:"omitempty,min=1"`
+	Limit      int        `form:"limit" binding:"omitempty,min=1,max=200"`
+}
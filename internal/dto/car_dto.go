@@ -0,0 +1,80 @@
+package dto
+
+import (
+	"github.com/user/car-project/internal/chassis"
+	"github.com/user/car-project/internal/models"
+)
+
+// CarListQuery filters GET /cars and GET /cars/export.
+type CarListQuery struct {
+	MakeID       int64  `form:"make_id" binding:"omitempty,gt=0"`
+	ModelID      int64  `form:"model_id" binding:"omitempty,gt=0"`
+	Status       string `form:"status" binding:"omitempty,oneof=available sold reserved damaged lost stolen"`
+	Location     string `form:"location" binding:"omitempty,max=100"`
+	Fuel         string `form:"fuel" binding:"omitempty,oneof=Petrol Diesel Hybrid Electric CNG LPG"`
+	BodyType     string `form:"body_type" binding:"omitempty,oneof=Sedan Hatchback SUV Crossover Coupe Convertible Wagon Van Minivan Pickup Microbus Roadster Fastback Liftback"`
+	Transmission string `form:"transmission" binding:"omitempty,oneof=Manual Automatic CVT DCT"`
+	YearFrom     int    `form:"year_from" binding:"omitempty,min=1900,max=2100"`
+	YearTo       int    `form:"year_to" binding:"omitempty,min=1900,max=2100"`
+	// Q matches the start of the reference or chassis number.
+	Q string `form:"q" binding:"omitempty,max=100"`
+}
+
+// CarExportQuery is a CarListQuery plus the file format of GET /cars/export.
+type CarExportQuery struct {
+	CarListQuery
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx pdf"`
+}
+
+// ChassisDecodeResponse is a decoded VIN or frame number. MakeID is the car make matching
+// the manufacturer, if there is one.
+type ChassisDecodeResponse struct {
+	chassis.Info
+	MakeID *int64 `json:"make_id"`
+}
+
+// CarDuplicate is a stored car that may be the same physical car as another. MatchedOn
+// lists the fields that are equal once case, spaces and punctuation are ignored;
+// Confidence is between 0 and 1. Car is left out when the stored car is outside the
+// caller's data scope.
+type CarDuplicate struct {
+	CarID      int64       `json:"car_id"`
+	Car        *models.Car `json:"car,omitempty"`
+	MatchedOn  []string    `json:"matched_on"`
+	Confidence float64     `json:"confidence"`
+}
+
+// CarMergeRequest names the car to merge into the car in the path.
+type CarMergeRequest struct {
+	DuplicateID int64 `json:"duplicate_id" binding:"required,gt=0"`
+}
+
+// CarMergeResult is the surviving car of a merge and what was moved onto it.
+type CarMergeResult struct {
+	Car         models.Car `json:"car"`
+	MergedCarID int64      `json:"merged_car_id"`
+	Photos      int64      `json:"photos_moved"`
+	Documents   int64      `json:"documents_moved"`
+	LCLinks     int64      `json:"lc_links_moved"`
+}
+
+// CarStatusChangeRequest moves a car to another status. Reason is required for damaged,
+// lost and stolen.
+type CarStatusChangeRequest struct {
+	Status string `json:"status" binding:"required,oneof=available reserved sold damaged lost stolen"`
+	Reason string `json:"reason" binding:"max=1000"`
+}
+
+// CarPriceRequest sets a car's list price and, optionally, the minimum it may be sold for
+// without the price-override permission. Amounts are in BDT.
+type CarPriceRequest struct {
+	ListPrice float64  `json:"list_price" binding:"required,gt=0"`
+	MinPrice  *float64 `json:"min_price" binding:"omitempty,gte=0"`
+	Reason    string   `json:"reason" binding:"max=1000"`
+}
+
+// CarView is a car with its pricing and margin.
+type CarView struct {
+	models.Car
+	Pricing models.CarPricing `json:"pricing"`
+}
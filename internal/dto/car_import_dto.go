@@ -0,0 +1,32 @@
+package dto
+
+// CarImportRowError is one problem found in an imported spreadsheet. Row is the line number
+// in the file, counting the header as row 1.
+type CarImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// CarImportDuplicate is a stored car that may be the same car as an imported row. Rows with
+// a duplicate above the confidence threshold are also reported as errors unless duplicates
+// are allowed.
+type CarImportDuplicate struct {
+	Row        int      `json:"row"`
+	CarID      int64    `json:"car_id"`
+	RefNo      *string  `json:"ref_no"`
+	MatchedOn  []string `json:"matched_on"`
+	Confidence float64  `json:"confidence"`
+}
+
+// CarImportReport summarises a bulk car import. Nothing is written unless Committed is true.
+type CarImportReport struct {
+	Committed      bool                 `json:"committed"`
+	TotalRows      int                  `json:"total_rows"`
+	ValidRows      int                  `json:"valid_rows"`
+	InvalidRows    int                  `json:"invalid_rows"`
+	IgnoredColumns []string             `json:"ignored_columns,omitempty"`
+	Errors         []CarImportRowError  `json:"errors"`
+	Duplicates     []CarImportDuplicate `json:"duplicates,omitempty"`
+	CarIDs         []int64              `json:"car_ids,omitempty"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "github.com/user/car-project/internal/models"
+
+// OrderItemRequest sells one car. Price defaults to the car's list price.
+type OrderItemRequest struct {
+	CarID int64    `json:"car_id" binding:"required,gt=0"`
+	Price *float64 `json:"price" binding:"omitempty,gte=0"`
+	Notes *string  `json:"notes" binding:"omitempty,max=255"`
+}
+
+type OrderCreateRequest struct {
+	ShippingAddress *string            `json:"shipping_address" binding:"omitempty,max=512"`
+	Items           []OrderItemRequest `json:"items" binding:"required,min=1,max=100,dive"`
+}
+
+// OrderResponse is an order with its items.
+type OrderResponse struct {
+	models.Order
+	Items []models.OrderItem `json:"items"`
+}
@@ -6,16 +6,27 @@ type RAGAskRequest struct {
 }
 
 // RAGAskResponse is the response for POST /rag/ask.
+// QueryID identifies the logged query for POST /rag/ask/:id/feedback.
 type RAGAskResponse struct {
+	QueryID int64          `json:"query_id,omitempty"`
 	Answer  string         `json:"answer"`
 	Sources []RAGSourceRef `json:"sources,omitempty"`
 }
 
-// RAGSourceRef references a retrieved chunk.
+// RAGSourceRef references a retrieved chunk. Marker matches the inline "[n]" citations in the answer.
 type RAGSourceRef struct {
+	Marker     int    `json:"marker"`
+	ChunkID    int64  `json:"chunk_id"`
 	SourceType string `json:"source_type"`
 	SourceID   string `json:"source_id"`
 	Content    string `json:"content"`
+	Cited      bool   `json:"cited"`
+}
+
+// RAGFeedbackRequest is the request body for POST /rag/ask/:id/feedback.
+type RAGFeedbackRequest struct {
+	Rating  string  `json:"rating" binding:"required,oneof=up down"`
+	Comment *string `json:"comment,omitempty" binding:"omitempty,max=2000"`
 }
 
 // RAGIndexResponse is the response for POST /rag/index/cars.
@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/user/car-project/internal/models"
+)
+
+// ReportQuery limits a report to a period. From and To are dates (YYYY-MM-DD); To is inclusive.
+type ReportQuery struct {
+	From *time.Time `form:"from" time_format:"2006-01-02"`
+	To   *time.Time `form:"to" time_format:"2006-01-02"`
+}
+
+type SellThroughQuery struct {
+	ReportQuery
+	GroupBy string `form:"group_by" binding:"omitempty,oneof=make model body_type"`
+}
+
+// ReportPeriod is the period a report covers and when it was computed; reports are cached,
+// so GeneratedAt may be a few minutes old. DataAsOf is the oldest last refresh of the report
+// views the sales, receivables and cost figures come from.
+type ReportPeriod struct {
+	From        *time.Time `json:"from"`
+	To          *time.Time `json:"to"`
+	GeneratedAt time.Time  `json:"generated_at"`
+	DataAsOf    *time.Time `json:"data_as_of"`
+}
+
+type InventoryAgingReport struct {
+	ReportPeriod
+	Cars        int64                     `json:"cars"`
+	AverageDays float64                   `json:"average_days"`
+	Buckets     []models.StockAgingBucket `json:"buckets"`
+}
+
+type SellThroughReport struct {
+	ReportPeriod
+	GroupBy string                  `json:"group_by"`
+	Groups  []models.SellThroughRow `json:"groups"`
+}
+
+type RevenueReport struct {
+	ReportPeriod
+	Months []models.MonthlyRevenue `json:"months"`
+}
+
+type ReceivablesReport struct {
+	ReportPeriod
+	TotalOutstanding float64             `json:"total_outstanding"`
+	Receivables      []models.Receivable `json:"receivables"`
+}
+
+type StockValueReport struct {
+	ReportPeriod
+	Locations []models.StockValueRow `json:"locations"`
+}
@@ -26,3 +26,22 @@ type AssignRoleRequest struct {
 	UserID int64 `json:"user_id" binding:"required"`
 	RoleID int64 `json:"role_id" binding:"required"`
 }
+
+// RolePermissionsRequest grants or revokes several permissions on a role at once.
+type RolePermissionsRequest struct {
+	PermissionIDs []int64 `json:"permission_ids" binding:"required,min=1,dive,gt=0"`
+}
+
+type RolePermissionsResponse struct {
+	RoleID      int64                `json:"role_id"`
+	Changed     int64                `json:"changed"` // permissions actually granted or revoked by the request
+	Permissions []PermissionResponse `json:"permissions"`
+}
+
+// UserAccessResponse is a user's roles and the effective permission slugs they grant.
+type UserAccessResponse struct {
+	UserID             int64          `json:"user_id"`
+	Roles              []RoleResponse `json:"roles"`
+	Permissions        []string       `json:"permissions"`
+	PermissionsVersion int64          `json:"permissions_version"`
+}
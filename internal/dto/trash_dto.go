@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/user/car-project/internal/models"
+)
+
+// TrashedCar is a deleted car and the time it becomes eligible for purging.
+type TrashedCar struct {
+	models.Car
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// TrashedUser is a deleted user and the time it becomes eligible for purging.
+type TrashedUser struct {
+	UserResponse
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+type TrashResponse struct {
+	RetentionDays int           `json:"retention_days"`
+	Cars          []TrashedCar  `json:"cars"`
+	Users         []TrashedUser `json:"users"`
+}
+
+type PurgeResponse struct {
+	CarsPurged  int64 `json:"cars_purged"`
+	UsersPurged int64 `json:"users_purged"`
+}
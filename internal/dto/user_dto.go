@@ -24,6 +24,8 @@ type UserResponse struct {
 	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+	// Version is the value served as the ETag; send it back in If-Match to update or delete.
+	Version int64 `json:"version,omitempty"`
 }
 
 type LoginRequest struct {
@@ -32,6 +34,37 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	TokenResponse
+	User UserResponse `json:"user"`
+}
+
+type LoginEventResponse struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	IPAddress *string   `json:"ip_address,omitempty"`
+	UserAgent *string   `json:"user_agent,omitempty"`
+	Outcome   string    `json:"outcome"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MeResponse is the authenticated user's profile with their effective roles and permissions.
+type MeResponse struct {
+	User               UserResponse   `json:"user"`
+	Roles              []RoleResponse `json:"roles"`
+	Permissions        []string       `json:"permissions"`
+	PermissionsVersion int64          `json:"permissions_version"`
+}
+
+// UserScopeRequest assigns or removes one data scope value for a user.
+type UserScopeRequest struct {
+	ScopeType string `json:"scope_type" binding:"required,oneof=location showroom"`
+	Value     string `json:"value" binding:"required,max=255"`
+}
+
+// UserScopesResponse lists the locations and showrooms a user is limited to.
+type UserScopesResponse struct {
+	UserID    int64    `json:"user_id"`
+	Bypass    bool     `json:"bypass"` // true when the user holds data-scope-bypass and sees every row
+	Locations []string `json:"locations"`
+	Showrooms []string `json:"showrooms"`
 }
@@ -0,0 +1,49 @@
+package events
+
+import "sync"
+
+// Topic names a kind of event published on a Bus.
+type Topic string
+
+// AccessChanged is published with an AccessChange payload whenever role or permission
+// assignments change, so permission caches can be invalidated.
+const AccessChanged Topic = "access.changed"
+
+// AccessChange identifies whose permissions changed. UserID 0 means any user may be affected.
+type AccessChange struct {
+	UserID int64
+}
+
+// Handler receives the payload of a published event.
+type Handler func(payload interface{})
+
+// Bus is an in-process publish/subscribe bus. Handlers run synchronously in Publish,
+// so they must be fast and must not publish on the same bus.
+type Bus interface {
+	Publish(topic Topic, payload interface{})
+	Subscribe(topic Topic, h Handler)
+}
+
+type bus struct {
+	mu       sync.RWMutex
+	handlers map[Topic][]Handler
+}
+
+func NewBus() Bus {
+	return &bus{handlers: make(map[Topic][]Handler)}
+}
+
+func (b *bus) Publish(topic Topic, payload interface{}) {
+	b.mu.RLock()
+	handlers := b.handlers[topic]
+	b.mu.RUnlock()
+	for _, h := range handlers {
+		h(payload)
+	}
+}
+
+func (b *bus) Subscribe(topic Topic, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[topic] = append(b.handlers[topic], h)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
+)
+
+type APIKeyHandler struct {
+	Service service.APIKeyService
+}
+
+func NewAPIKeyHandler(svc service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{Service: svc}
+}
+
+// CreateAPIKey godoc
+// @Summary      Create an API key
+// @Description  Create a key for a service integration, tied to a role and optionally limited by expiry and an IP allowlist. The key is only returned in this response; store it securely. Send it in the X-API-Key header.
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.CreateAPIKeyRequest  true  "Key name, role and restrictions"
+// @Success      201   {object}  dto.CreatedAPIKeyResponse
+// @Failure      400   {object}  utils.Response
+// @Failure      403   {object}  utils.Response
+// @Failure      404   {object}  utils.Response
+// @Failure      500   {object}  utils.Response
+// @Router       /api-keys [post]
+// @Security     BearerAuth
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	adminID, ok := utils.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	var req dto.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	resp, err := h.Service.CreateAPIKey(c.Request.Context(), req, adminID)
+	if err != nil {
+		switch err {
+		case utils.ErrBadRequest:
+			utils.ErrorResponse(c, http.StatusBadRequest, "expires_at must be in the future", err.Error())
+		case utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "Role not found", err.Error())
+		case utils.ErrForbidden:
+			utils.ErrorResponseWithHints(c, http.StatusForbidden, "Cannot create a key with permissions you do not hold", err.Error(),
+				[]string{"Choose a role whose permissions are a subset of your own"})
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create API key", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "API key created; it will not be shown again", resp)
+}
+
+// GetAPIKeys godoc
+// @Summary      List API keys
+// @Description  List every API key, including revoked and expired ones. Key values are never returned.
+// @Tags         api-keys
+// @Produce      json
+// @Success      200  {array}   dto.APIKeyResponse
+// @Failure      500  {object}  utils.Response
+// @Router       /api-keys [get]
+// @Security     BearerAuth
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	keys, err := h.Service.GetAPIKeys(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch API keys", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API keys fetched successfully", keys)
+}
+
+// GetAPIKeyByID godoc
+// @Summary      Get an API key
+// @Description  Get an API key's metadata by ID
+// @Tags         api-keys
+// @Produce      json
+// @Param        id   path      int  true  "API key ID"
+// @Success      200  {object}  dto.APIKeyResponse
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /api-keys/{id} [get]
+// @Security     BearerAuth
+func (h *APIKeyHandler) GetAPIKeyByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid API key ID", err.Error())
+		return
+	}
+
+	key, err := h.Service.GetAPIKeyByID(c.Request.Context(), id)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "API key not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch API key", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API key fetched successfully", key)
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key
+// @Description  Revoke an API key immediately. Revoked keys stay listed for auditing.
+// @Tags         api-keys
+// @Produce      json
+// @Param        id   path      int  true  "API key ID"
+// @Success      200  {object}  utils.Response
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /api-keys/{id} [delete]
+// @Security     BearerAuth
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid API key ID", err.Error())
+		return
+	}
+
+	if err := h.Service.RevokeAPIKey(c.Request.Context(), id); err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "API key not found or already revoked", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke API key", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API key revoked successfully", nil)
+}
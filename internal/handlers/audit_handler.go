@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
+)
+
+type AuditHandler struct {
+	Service service.AuditService
+}
+
+func NewAuditHandler(svc service.AuditService) *AuditHandler {
+	return &AuditHandler{Service: svc}
+}
+
+// ListAuditLogs godoc
+// @Summary      Query the audit trail
+// @Description  List audit entries for creates, updates, deletes, restores, purges, grants and revokes, newest first. Before and after hold only changed fields for updates; secrets are redacted.
+// @Tags         audit
+// @Produce      json
+// @Param        entity_type  query     string  false  "user, car, role, permission or api_key"
+// @Param        entity_id    query     int     false  "Entity ID"
+// @Param        actor_id     query     int     false  "ID of the user who made the change"
+// @Param        from         query     string  false  "First day, YYYY-MM-DD"
+// @Param        to           query     string  false  "Last day (inclusive), YYYY-MM-DD"
+// @Param        page         query     int     false  "Page (default 1)"
+// @Param        limit        query     int     false  "Page size (default 10, max 200)"
+// @Success      200  {array}   models.AuditLog
+// @Failure      400  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /audit-logs [get]
+// @Security     BearerAuth
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	var q dto.AuditLogQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	entries, total, err := h.Service.ListAuditLogs(c.Request.Context(), q)
+	if err != nil {
+		if err == utils.ErrBadRequest {
+			utils.ErrorResponse(c, http.StatusBadRequest, "from must not be after to", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch audit log", err.Error())
+		}
+		return
+	}
+
+	page, limit := q.Page, q.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = utils.DefaultPageSize
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	utils.PaginatedSuccessResponse(c, http.StatusOK, "Audit log fetched successfully", entries, utils.Pagination{
+		CurrentPage: page,
+		TotalPages:  totalPages,
+		Limit:       limit,
+		TotalItems:  total,
+		Links:       utils.PaginationLinks{Self: c.Request.URL.String()},
+	})
+}
@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/user/car-project/internal/dto"
@@ -11,25 +15,36 @@ import (
 
 type AuthHandler interface {
 	Login(c *gin.Context)
+	Refresh(c *gin.Context)
+	Logout(c *gin.Context)
 }
 
 type authHandler struct {
-	svc service.UserService
+	svc    service.UserService
+	tokens service.TokenService
 }
 
-func NewAuthHandler(svc service.UserService) AuthHandler {
-	return &authHandler{svc: svc}
+func NewAuthHandler(svc service.UserService, tokens service.TokenService) AuthHandler {
+	return &authHandler{svc: svc, tokens: tokens}
+}
+
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
 }
 
 // Login godoc
 // @Summary User Login
-// @Description Authenticate user and return JWT token
+// @Description Authenticate user and return a short-lived access token plus a rotating refresh token
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param login body dto.LoginRequest true "Login Credentials"
 // @Success 200 {object} dto.LoginResponse
 // @Failure 401 {object} utils.Response
+// @Failure 429 {object} utils.Response
 // @Failure 500 {object} utils.Response
 // @Router /api/v1/login [post]
 func (h *authHandler) Login(c *gin.Context) {
@@ -39,8 +54,16 @@ func (h *authHandler) Login(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.svc.Login(c.Request.Context(), req)
+	resp, err := h.svc.Login(c.Request.Context(), req, clientInfo(c))
 	if err != nil {
+		var lockout *utils.LockoutError
+		if errors.As(err, &lockout) {
+			seconds := int(math.Ceil(lockout.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			utils.ErrorResponseWithHints(c, http.StatusTooManyRequests, "too many failed login attempts", err.Error(),
+				[]string{fmt.Sprintf("Try again in %d seconds", seconds)})
+			return
+		}
 		if err == utils.ErrUnauthorized {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "invalid credentials", "")
 			return
@@ -51,3 +74,68 @@ func (h *authHandler) Login(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "login successful", resp)
 }
+
+// Refresh godoc
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and refresh token. The presented refresh token is revoked; reusing it revokes every token from the same login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} dto.TokenResponse
+// @Failure 400 {object} utils.Response
+// @Failure 401 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/auth/refresh [post]
+func (h *authHandler) Refresh(c *gin.Context) {
+	var req dto.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	resp, err := h.tokens.Refresh(c.Request.Context(), req.RefreshToken, clientInfo(c))
+	if err != nil {
+		switch err {
+		case utils.ErrTokenReused:
+			utils.ErrorResponseWithHints(c, http.StatusUnauthorized, "refresh token reuse detected", err.Error(),
+				[]string{"All sessions from this login have been revoked; log in again"})
+		case utils.ErrUnauthorized:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "invalid or expired refresh token", "")
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "token refresh failed", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "token refreshed", resp)
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the current access token immediately and, if a refresh token is given, every token from the same login
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.LogoutRequest false "Refresh token to revoke"
+// @Success 200 {object} utils.Response
+// @Failure 500 {object} utils.Response
+// @Router /api/v1/auth/logout [post]
+// @Security BearerAuth
+func (h *authHandler) Logout(c *gin.Context) {
+	var req dto.LogoutRequest
+	// The body is optional; an empty or missing body only revokes the access token.
+	_ = c.ShouldBindJSON(&req)
+
+	userID, ok := utils.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+	if err := h.tokens.Logout(c.Request.Context(), userID, utils.GetClaims(c), req.RefreshToken); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "logout failed", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "logged out", nil)
+}
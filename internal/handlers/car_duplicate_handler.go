@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
+)
+
+type CarDuplicateHandler struct {
+	Service service.CarDuplicateService
+}
+
+func NewCarDuplicateHandler(svc service.CarDuplicateService) *CarDuplicateHandler {
+	return &CarDuplicateHandler{Service: svc}
+}
+
+// GetDuplicates godoc
+// @Summary      List likely duplicates of a car
+// @Description  List the cars within the caller's data scope that may be the same physical car: same ref_no, chassis number or engine number once case, spaces and punctuation are ignored. Each has the matched fields and a confidence between 0 and 1, most likely first.
+// @Tags         cars
+// @Produce      json
+// @Param        id   path      int  true  "Car ID"
+// @Success      200  {array}   dto.CarDuplicate
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /cars/{id}/duplicates [get]
+// @Security     BearerAuth
+func (h *CarDuplicateHandler) GetDuplicates(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid car ID", err.Error())
+		return
+	}
+
+	duplicates, err := h.Service.DuplicatesOf(c.Request.Context(), id)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Car not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to find duplicates", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Duplicates fetched successfully", duplicates)
+}
+
+// MergeCars godoc
+// @Summary      Merge a duplicate car into another
+// @Description  Move the photos, documents and LC links of duplicate_id onto the car in the path and move duplicate_id to the trash. Moved photos and documents are placed after the car's own and lose their primary flag if the car already has a primary one. Orders and payments stay with the merged car. Both cars must be within the caller's data scope.
+// @Tags         cars
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                  true  "ID of the car to keep"
+// @Param        request  body      dto.CarMergeRequest  true  "Car to merge into it"
+// @Success      200  {object}  dto.CarMergeResult
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /cars/{id}/merge [post]
+// @Security     BearerAuth
+func (h *CarDuplicateHandler) MergeCars(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid car ID", err.Error())
+		return
+	}
+
+	var req dto.CarMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	result, err := h.Service.MergeCars(c.Request.Context(), id, req.DuplicateID)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrBadRequest):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Cannot merge a car into itself", err.Error())
+		case err == utils.ErrNotFound:
+			utils.ErrorResponseWithHints(c, http.StatusNotFound, "Car not found", err.Error(),
+				[]string{"Both cars must exist, be outside the trash and be within your data scope"})
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to merge cars", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Cars merged successfully", result)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
+)
+
+// exportContentTypes maps export formats to their media types.
+var exportContentTypes = map[string]string{
+	service.ExportCSV:  "text/csv; charset=utf-8",
+	service.ExportXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	service.ExportPDF:  "application/pdf",
+}
+
+type CarExportHandler struct {
+	Service service.CarExportService
+}
+
+func NewCarExportHandler(svc service.CarExportService) *CarExportHandler {
+	return &CarExportHandler{Service: svc}
+}
+
+// ExportCars godoc
+// @Summary      Export cars to CSV, XLSX or a PDF stock sheet
+// @Description  Download the cars within the caller's data scope, filtered like GET /cars. csv and xlsx have one row per car with the same columns the bulk import accepts; pdf is a printable stock sheet grouped by location. The file is streamed as it is generated.
+// @Tags         cars
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce      application/pdf
+// @Param        format        query     string  false  "csv (default), xlsx or pdf"
+// @Param        make_id       query     int     false  "Make ID"
+// @Param        model_id      query     int     false  "Model ID"
+// @Param        status        query     string  false  "available, sold, reserved, damaged, lost or stolen"
+// @Param        location      query     string  false  "Location"
+// @Param        fuel          query     string  false  "Fuel"
+// @Param        body_type     query     string  false  "Body type"
+// @Param        transmission  query     string  false  "Transmission"
+// @Param        year_from     query     int     false  "Earliest model year"
+// @Param        year_to       query     int     false  "Latest model year"
+// @Param        q             query     string  false  "Start of the reference or chassis number"
+// @Success      200  {file}    file
+// @Failure      400  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /cars/export [get]
+// @Security     BearerAuth
+func (h *CarExportHandler) ExportCars(c *gin.Context) {
+	var q dto.CarExportQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	if q.Format == "" {
+		q.Format = service.ExportCSV
+	}
+
+	utils.SkipETag(c)
+	header := c.Writer.Header()
+	header.Set("Content-Type", exportContentTypes[q.Format])
+	header.Set("Content-Disposition", `attachment; filename="cars-`+time.Now().Format("2006-01-02")+"."+q.Format+`"`)
+
+	err := h.Service.ExportCars(c.Request.Context(), q.CarListQuery, q.Format, c.Writer)
+	if err == nil {
+		return
+	}
+	if c.Writer.Written() {
+		// The status and part of the file are already sent; all we can do is cut it short.
+		utils.GetLogger().Printf("car export aborted: %v", err)
+		c.Abort()
+		return
+	}
+	header.Del("Content-Type")
+	header.Del("Content-Disposition")
+	if err == utils.ErrBadRequest {
+		utils.ErrorResponse(c, http.StatusBadRequest, "year_from must not be after year_to", err.Error())
+	} else {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to export cars", err.Error())
+	}
+}
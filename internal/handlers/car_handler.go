@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/user/car-project/internal/chassis"
+	"github.com/user/car-project/internal/dto"
 	"github.com/user/car-project/internal/models"
 	"github.com/user/car-project/internal/service"
 	"github.com/user/car-project/internal/utils"
@@ -20,13 +26,16 @@ func NewCarHandler(svc service.CarService) *CarHandler {
 
 // CreateCar godoc
 // @Summary      Create a new car
-// @Description  Create a new car with the input payload
+// @Description  Create a new car with the input payload. The status must be available (the default) or reserved. chassis_no_full must be a valid VIN or frame number; it is stored normalized, and an empty year or country_origin is filled in from it. The hints say what was decoded, and warn when the VIN's manufacturer does not match the model's make. A car that is likely a duplicate of a stored car (same ref_no, chassis or engine number ignoring case and punctuation) is refused with 409 and the candidates in data, unless allow_duplicate=true; less likely duplicates are listed in the hints. The hints also warn when no user has the car's location in their data scope.
 // @Tags         cars
 // @Accept       json
 // @Produce      json
-// @Param        car  body      models.Car  true  "Car JSON"
+// @Param        car              body      models.Car  true   "Car JSON"
+// @Param        allow_duplicate  query     bool        false  "Create the car even if it is likely a duplicate"
 // @Success      201  {object}  models.Car
 // @Failure      400  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
+// @Failure      409  {array}   dto.CarDuplicate
 // @Failure      500  {object}  utils.Response
 // @Router       /cars [post]
 // @Security     BearerAuth
@@ -37,28 +46,97 @@ func (h *CarHandler) CreateCar(c *gin.Context) {
 		return
 	}
 
-	if err := h.Service.CreateCar(&car); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create car", err.Error())
+	allowDuplicate, err := boolQuery(c, "allow_duplicate")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid allow_duplicate parameter", err.Error())
+		return
+	}
+
+	hints, err := h.Service.CreateCar(c.Request.Context(), &car, allowDuplicate)
+	if err != nil {
+		var invalid *chassis.Error
+		var duplicate *service.DuplicateCarError
+		switch {
+		case errors.As(err, &invalid):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid chassis number", invalid.Reason)
+		case errors.As(err, &duplicate):
+			utils.SuccessResponseWithHints(c, http.StatusConflict, "Car is likely a duplicate of a stored car", duplicate.Duplicates,
+				[]string{"Check the cars in data; merge them if they are the same car, or send allow_duplicate=true to create it anyway"})
+		case err == utils.ErrForbidden:
+			utils.ErrorResponseWithHints(c, http.StatusForbidden, "Car location is outside your data scope", err.Error(),
+				[]string{"Set location to one of the locations assigned to you"})
+		case err == utils.ErrAlreadyExists:
+			utils.ErrorResponse(c, http.StatusConflict, "Another car has the same reference number", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create car", err.Error())
+		}
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusCreated, "Car created successfully", car)
+	utils.SuccessResponseWithHints(c, http.StatusCreated, "Car created successfully", car, hints)
+}
+
+// DecodeVIN godoc
+// @Summary      Decode a VIN or frame number
+// @Description  Validate a 17-character VIN or a Japanese frame number (e.g. ZVW30-1234567) and decode it offline: manufacturer, country, model year and check digit for VINs, model code and serial for frame numbers. make_id is the car make whose name matches the decoded manufacturer, if any.
+// @Tags         cars
+// @Produce      json
+// @Param        vin  path      string  true  "VIN or frame number"
+// @Success      200  {object}  dto.ChassisDecodeResponse
+// @Failure      400  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /vin/{vin}/decode [get]
+// @Security     BearerAuth
+func (h *CarHandler) DecodeVIN(c *gin.Context) {
+	decoded, err := h.Service.DecodeChassis(c.Request.Context(), c.Param("vin"))
+	if err != nil {
+		var invalid *chassis.Error
+		if errors.As(err, &invalid) {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid chassis number", invalid.Reason)
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to decode chassis number", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Chassis number decoded successfully", decoded)
 }
 
 // GetCars godoc
 // @Summary      List all cars
-// @Description  Get a list of all cars
+// @Description  Get a list of all cars within the caller's data scope, optionally filtered
 // @Tags         cars
 // @Accept       json
 // @Produce      json
+// @Param        make_id       query     int     false  "Make ID"
+// @Param        model_id      query     int     false  "Model ID"
+// @Param        status        query     string  false  "available, sold, reserved, damaged, lost or stolen"
+// @Param        location      query     string  false  "Location"
+// @Param        fuel          query     string  false  "Fuel"
+// @Param        body_type     query     string  false  "Body type"
+// @Param        transmission  query     string  false  "Transmission"
+// @Param        year_from     query     int     false  "Earliest model year"
+// @Param        year_to       query     int     false  "Latest model year"
+// @Param        q             query     string  false  "Start of the reference or chassis number"
 // @Success      200  {array}   models.Car
+// @Failure      400  {object}  utils.Response
 // @Failure      500  {object}  utils.Response
 // @Router       /cars [get]
 // @Security     BearerAuth
 func (h *CarHandler) GetCars(c *gin.Context) {
-	cars, err := h.Service.GetCars()
+	var q dto.CarListQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	cars, err := h.Service.GetCars(c.Request.Context(), q)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch cars", err.Error())
+		if err == utils.ErrBadRequest {
+			utils.ErrorResponse(c, http.StatusBadRequest, "year_from must not be after year_to", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch cars", err.Error())
+		}
 		return
 	}
 
@@ -67,12 +145,12 @@ func (h *CarHandler) GetCars(c *gin.Context) {
 
 // GetCarByID godoc
 // @Summary      Get a car by ID
-// @Description  Get details of a specific car by its ID
+// @Description  Get details of a specific car by its ID, with its list and minimum price, landed cost (cost basis) and margin in BDT
 // @Tags         cars
 // @Accept       json
 // @Produce      json
 // @Param        id   path      int  true  "Car ID"
-// @Success      200  {object}  models.Car
+// @Success      200  {object}  dto.CarView
 // @Failure      400  {object}  utils.Response
 // @Failure      404  {object}  utils.Response
 // @Failure      500  {object}  utils.Response
@@ -85,7 +163,7 @@ func (h *CarHandler) GetCarByID(c *gin.Context) {
 		return
 	}
 
-	car, err := h.Service.GetCarByID(id)
+	car, err := h.Service.GetCarByID(c.Request.Context(), id)
 	if err != nil {
 		if err == utils.ErrNotFound {
 			utils.ErrorResponse(c, http.StatusNotFound, "Car not found", err.Error())
@@ -95,19 +173,35 @@ func (h *CarHandler) GetCarByID(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Car fetched successfully", car)
+	pricing, err := h.Service.Pricing(c.Request.Context(), id)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Car not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch car pricing", err.Error())
+		}
+		return
+	}
+
+	utils.SetVersionETag(c, car.Version)
+	utils.SuccessResponse(c, http.StatusOK, "Car fetched successfully", dto.CarView{Car: *car, Pricing: *pricing})
 }
 
 // UpdateCar godoc
 // @Summary      Update a car
-// @Description  Update an existing car by its ID
+// @Description  Replace an existing car. Requires If-Match with the car's current ETag; returns 412 if the car has changed since. The status cannot be changed here; send the current one or leave it out.
 // @Tags         cars
 // @Accept       json
 // @Produce      json
 // @Param        id   path      int         true  "Car ID"
 // @Param        car  body      models.Car  true  "Car JSON"
+// @Param        If-Match  header  string  true  "ETag from GET /cars/{id}"
 // @Success      200  {object}  models.Car
 // @Failure      400  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      412  {object}  utils.Response
+// @Failure      428  {object}  utils.Response
 // @Failure      500  {object}  utils.Response
 // @Router       /cars/{id} [put]
 // @Security     BearerAuth
@@ -124,24 +218,214 @@ func (h *CarHandler) UpdateCar(c *gin.Context) {
 		return
 	}
 	car.ID = id
+	car.Version, _ = utils.GetIfMatchVersion(c)
 
-	if err := h.Service.UpdateCar(&car); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update car", err.Error())
+	if err := h.Service.UpdateCar(c.Request.Context(), &car); err != nil {
+		var invalid *chassis.Error
+		switch {
+		case errors.As(err, &invalid):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid chassis number", invalid.Reason)
+		case errors.Is(err, utils.ErrBadRequest):
+			utils.ErrorResponseWithHints(c, http.StatusBadRequest, "Status cannot be changed by an update", err.Error(),
+				[]string{"Send the car's current status, or leave it out, and use POST /cars/{id}/status to change it"})
+		case err == utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "Car not found", err.Error())
+		case err == utils.ErrPreconditionFailed:
+			preconditionFailedResponse(c, "Car", err)
+		case err == utils.ErrForbidden:
+			utils.ErrorResponseWithHints(c, http.StatusForbidden, "Car location is outside your data scope", err.Error(),
+				[]string{"Set location to one of the locations assigned to you"})
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update car", err.Error())
+		}
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Car updated successfully", nil)
+	// Return the stored car, with the columns a PUT does not write and the new version.
+	updated, err := h.Service.GetCarByID(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch updated car", err.Error())
+		return
+	}
+	utils.SetVersionETag(c, updated.Version)
+	utils.SuccessResponse(c, http.StatusOK, "Car updated successfully", updated)
+}
+
+// PatchCar godoc
+// @Summary      Partially update a car
+// @Description  Change only some fields of a car. Send an RFC 7396 merge patch (application/merge-patch+json or application/json, e.g. {"color":"Red"}; null clears a field) or an RFC 6902 JSON Patch (application/json-patch+json). Only the changed fields are validated and written. Requires If-Match with the car's current ETag.
+// @Tags         cars
+// @Accept       json
+// @Produce      json
+// @Param        id        path    int     true  "Car ID"
+// @Param        patch     body    object  true  "Merge patch or JSON Patch"
+// @Param        If-Match  header  string  true  "ETag from GET /cars/{id}"
+// @Success      200  {object}  models.Car
+// @Failure      400  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      412  {object}  utils.Response
+// @Failure      415  {object}  utils.Response
+// @Failure      428  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /cars/{id} [patch]
+// @Security     BearerAuth
+func (h *CarHandler) PatchCar(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid car ID", err.Error())
+		return
+	}
+
+	contentType := c.ContentType()
+	switch contentType {
+	case utils.MergePatchContentType, utils.JSONPatchContentType, "application/json":
+	default:
+		utils.ErrorResponseWithHints(c, http.StatusUnsupportedMediaType, "Unsupported patch format", contentType,
+			[]string{"Use Content-Type " + utils.MergePatchContentType + " or " + utils.JSONPatchContentType})
+		return
+	}
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Could not read request body", err.Error())
+		return
+	}
+	version, _ := utils.GetIfMatchVersion(c)
+
+	car, err := h.Service.PatchCar(c.Request.Context(), id, version, patch, contentType)
+	if err != nil {
+		var invalid *utils.PatchError
+		var badChassis *chassis.Error
+		var fields validator.ValidationErrors
+		switch {
+		case errors.As(err, &invalid):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Patch could not be applied", invalid.Reason)
+		case errors.As(err, &badChassis):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid chassis number", badChassis.Reason)
+		case errors.As(err, &fields):
+			utils.ValidationErrorResponse(c, fields)
+		case err == utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "Car not found", err.Error())
+		case err == utils.ErrPreconditionFailed:
+			preconditionFailedResponse(c, "Car", err)
+		case err == utils.ErrForbidden:
+			utils.ErrorResponseWithHints(c, http.StatusForbidden, "Car location is outside your data scope", err.Error(),
+				[]string{"Set location to one of the locations assigned to you"})
+		case err == utils.ErrAlreadyExists:
+			utils.ErrorResponse(c, http.StatusConflict, "Another car has the same reference number", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update car", err.Error())
+		}
+		return
+	}
+
+	utils.SetVersionETag(c, car.Version)
+	utils.SuccessResponse(c, http.StatusOK, "Car updated successfully", car)
+}
+
+// ChangeCarStatus godoc
+// @Summary      Change a car's status
+// @Description  Move a car to another status and record the change in its status history. Only some transitions are allowed (e.g. a sold car can only go back to available); a refused transition returns 409 with the allowed statuses in the hints. A reason is required for damaged, lost and stolen. A car on an open order (pending, approved or shipped) cannot be marked sold. Requires If-Match with the car's current ETag.
+// @Tags         cars
+// @Accept       json
+// @Produce      json
+// @Param        id        path    int                         true  "Car ID"
+// @Param        request   body    dto.CarStatusChangeRequest  true  "New status and reason"
+// @Param        If-Match  header  string                      true  "ETag from GET /cars/{id}"
+// @Success      200  {object}  models.Car
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      409  {object}  utils.Response
+// @Failure      412  {object}  utils.Response
+// @Failure      428  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /cars/{id}/status [post]
+// @Security     BearerAuth
+func (h *CarHandler) ChangeCarStatus(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid car ID", err.Error())
+		return
+	}
+
+	var req dto.CarStatusChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	version, _ := utils.GetIfMatchVersion(c)
+
+	car, err := h.Service.ChangeStatus(c.Request.Context(), id, version, req)
+	if err != nil {
+		var refused *service.StatusTransitionError
+		switch {
+		case errors.As(err, &refused):
+			hint := "A " + refused.From + " car cannot change status"
+			if len(refused.Allowed) > 0 {
+				hint = "A " + refused.From + " car can become " + strings.Join(refused.Allowed, ", ")
+			}
+			utils.ErrorResponseWithHints(c, http.StatusConflict, "Status change not allowed", err.Error(), []string{hint})
+		case errors.Is(err, utils.ErrBadRequest):
+			utils.ErrorResponse(c, http.StatusBadRequest, "A reason is required for this status", err.Error())
+		case err == utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "Car not found", err.Error())
+		case err == utils.ErrPreconditionFailed:
+			preconditionFailedResponse(c, "Car", err)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to change car status", err.Error())
+		}
+		return
+	}
+
+	utils.SetVersionETag(c, car.Version)
+	utils.SuccessResponse(c, http.StatusOK, "Car status changed successfully", car)
+}
+
+// GetCarStatusHistory godoc
+// @Summary      Get a car's status history
+// @Description  List every status the car has had, oldest first, with the reason and the user who set it. The first entry is the status the car was created with.
+// @Tags         cars
+// @Produce      json
+// @Param        id   path      int  true  "Car ID"
+// @Success      200  {array}   models.CarStatusChange
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /cars/{id}/status-history [get]
+// @Security     BearerAuth
+func (h *CarHandler) GetCarStatusHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid car ID", err.Error())
+		return
+	}
+
+	changes, err := h.Service.StatusHistory(c.Request.Context(), id)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Car not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch status history", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Status history fetched successfully", changes)
 }
 
 // DeleteCar godoc
 // @Summary      Delete a car
-// @Description  Delete a car by its ID
+// @Description  Move a car to the trash. Requires If-Match with the car's current ETag; returns 412 if the car has changed since.
 // @Tags         cars
 // @Accept       json
 // @Produce      json
 // @Param        id   path      int  true  "Car ID"
+// @Param        If-Match  header  string  true  "ETag from GET /cars/{id}"
 // @Success      200  {object}  models.Car
 // @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      412  {object}  utils.Response
+// @Failure      428  {object}  utils.Response
 // @Failure      500  {object}  utils.Response
 // @Router       /cars/{id} [delete]
 // @Security     BearerAuth
@@ -152,10 +436,142 @@ func (h *CarHandler) DeleteCar(c *gin.Context) {
 		return
 	}
 
-	if err := h.Service.DeleteCar(id); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete car", err.Error())
+	version, _ := utils.GetIfMatchVersion(c)
+
+	if err := h.Service.DeleteCar(c.Request.Context(), id, version); err != nil {
+		switch err {
+		case utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "Car not found", err.Error())
+		case utils.ErrPreconditionFailed:
+			preconditionFailedResponse(c, "Car", err)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete car", err.Error())
+		}
 		return
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Car deleted successfully", nil)
 }
+
+// RestoreCar godoc
+// @Summary      Restore a deleted car
+// @Description  Take a car out of the trash. Fails with 409 if another car has since taken its reference number.
+// @Tags         cars
+// @Produce      json
+// @Param        id   path      int  true  "Car ID"
+// @Success      200  {object}  utils.Response
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      409  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /cars/{id}/restore [post]
+// @Security     BearerAuth
+func (h *CarHandler) RestoreCar(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid car ID", err.Error())
+		return
+	}
+
+	if err := h.Service.RestoreCar(c.Request.Context(), id); err != nil {
+		switch err {
+		case utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "Car not found in trash", err.Error())
+		case utils.ErrAlreadyExists:
+			utils.ErrorResponseWithHints(c, http.StatusConflict, "Another car has the same reference number", err.Error(),
+				[]string{"Change or delete the other car's ref_no first"})
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to restore car", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Car restored successfully", nil)
+}
+
+// SetCarPrice godoc
+// @Summary      Set a car's price
+// @Description  Set the list price of a car and, optionally, its minimum sale price, in BDT, and record them in its price history. Orders below the minimum price need the price-override permission. Requires If-Match with the car's current ETag; the car gets a new version.
+// @Tags         cars
+// @Accept       json
+// @Produce      json
+// @Param        id        path    int                  true  "Car ID"
+// @Param        request   body    dto.CarPriceRequest  true  "Prices and reason"
+// @Param        If-Match  header  string               true  "ETag from GET /cars/{id}"
+// @Success      200  {object}  dto.CarView
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      412  {object}  utils.Response
+// @Failure      428  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /cars/{id}/price [put]
+// @Security     BearerAuth
+func (h *CarHandler) SetCarPrice(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid car ID", err.Error())
+		return
+	}
+
+	var req dto.CarPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	version, _ := utils.GetIfMatchVersion(c)
+
+	car, err := h.Service.SetPrice(c.Request.Context(), id, version, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrBadRequest):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid price", err.Error())
+		case err == utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "Car not found", err.Error())
+		case err == utils.ErrPreconditionFailed:
+			preconditionFailedResponse(c, "Car", err)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to set car price", err.Error())
+		}
+		return
+	}
+	pricing, err := h.Service.Pricing(c.Request.Context(), id)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch car pricing", err.Error())
+		return
+	}
+
+	utils.SetVersionETag(c, car.Version)
+	utils.SuccessResponse(c, http.StatusOK, "Car price set successfully", dto.CarView{Car: *car, Pricing: *pricing})
+}
+
+// GetCarPriceHistory godoc
+// @Summary      Get a car's price history
+// @Description  List every list and minimum price the car has had, oldest first, with the reason and the user who set it.
+// @Tags         cars
+// @Produce      json
+// @Param        id   path      int  true  "Car ID"
+// @Success      200  {array}   models.CarPriceChange
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /cars/{id}/price-history [get]
+// @Security     BearerAuth
+func (h *CarHandler) GetCarPriceHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid car ID", err.Error())
+		return
+	}
+
+	changes, err := h.Service.PriceHistory(c.Request.Context(), id)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Car not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch price history", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Price history fetched successfully", changes)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/spreadsheet"
+	"github.com/user/car-project/internal/utils"
+)
+
+// maxImportFileSize bounds the size of an uploaded import spreadsheet.
+const maxImportFileSize = 10 << 20
+
+type CarImportHandler struct {
+	Service service.CarImportService
+}
+
+func NewCarImportHandler(svc service.CarImportService) *CarImportHandler {
+	return &CarImportHandler{Service: svc}
+}
+
+// ImportCars godoc
+// @Summary      Bulk import cars from CSV or XLSX
+// @Description  Upload a .csv or .xlsx file whose first row names the columns (car fields, plus make and model names instead of model_id). Every row is validated and a per-row report is returned. By default this is a dry run; with commit=true the cars are created in one transaction, and only if no row has errors. Stored cars that may be the same car as a row are listed in duplicates; rows that likely are count as errors unless allow_duplicates=true.
+// @Tags         cars
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file              formData  file  true   "Spreadsheet (.csv or .xlsx)"
+// @Param        commit            query     bool  false  "Create the cars (default false: validate only)"
+// @Param        allow_duplicates  query     bool  false  "Import rows that are likely duplicates of stored cars"
+// @Success      200  {object}  dto.CarImportReport
+// @Success      201  {object}  dto.CarImportReport
+// @Failure      400  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
+// @Failure      409  {object}  utils.Response
+// @Failure      415  {object}  utils.Response
+// @Failure      422  {object}  dto.CarImportReport
+// @Failure      500  {object}  utils.Response
+// @Router       /cars/import [post]
+// @Security     BearerAuth
+func (h *CarImportHandler) ImportCars(c *gin.Context) {
+	commit, err := boolQuery(c, "commit")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid commit parameter", err.Error())
+		return
+	}
+	allowDuplicates, err := boolQuery(c, "allow_duplicates")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid allow_duplicates parameter", err.Error())
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		utils.ErrorResponseWithHints(c, http.StatusBadRequest, "Missing import file", err.Error(),
+			[]string{"Send the spreadsheet as multipart/form-data in the \"file\" field, at most 10 MB"})
+		return
+	}
+	format, err := spreadsheet.FormatOf(fh.Filename)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnsupportedMediaType, "Unsupported file type", err.Error())
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Could not read import file", err.Error())
+		return
+	}
+	defer f.Close()
+	rows, err := spreadsheet.Read(format, f)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Could not parse import file", err.Error())
+		return
+	}
+
+	report, err := h.Service.ImportCars(c.Request.Context(), rows, commit, allowDuplicates)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrBadRequest):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid import file", err.Error())
+		case err == utils.ErrForbidden:
+			utils.ErrorResponse(c, http.StatusForbidden, "Car location is outside your data scope", err.Error())
+		case err == utils.ErrAlreadyExists:
+			utils.ErrorResponseWithHints(c, http.StatusConflict, "A car with the same reference or chassis number was created meanwhile", err.Error(),
+				[]string{"Run the import again to see which rows conflict"})
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to import cars", err.Error())
+		}
+		return
+	}
+
+	switch {
+	case report.Committed:
+		utils.SuccessResponse(c, http.StatusCreated, "Cars imported successfully", report)
+	case report.InvalidRows > 0 && commit:
+		utils.SuccessResponseWithHints(c, http.StatusUnprocessableEntity, "Import rejected; no cars were created", report,
+			[]string{"Fix the rows listed in errors and upload the file again"})
+	case report.InvalidRows > 0:
+		utils.SuccessResponseWithHints(c, http.StatusOK, "Validation found errors", report,
+			[]string{"Fix the rows listed in errors before importing with commit=true"})
+	default:
+		utils.SuccessResponseWithHints(c, http.StatusOK, "Validation passed", report,
+			[]string{"Upload again with commit=true to create the cars"})
+	}
+}
+
+// boolQuery parses an optional boolean query parameter; it is false when absent.
+func boolQuery(c *gin.Context, name string) (bool, error) {
+	v := c.Query(name)
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
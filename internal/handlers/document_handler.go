@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
+)
+
+type DocumentHandler struct {
+	Service service.DocumentService
+}
+
+func NewDocumentHandler(svc service.DocumentService) *DocumentHandler {
+	return &DocumentHandler{Service: svc}
+}
+
+// GetSpecSheet godoc
+// @Summary      Download a car spec sheet
+// @Description  Render a printable PDF with the car's primary photo, specifications, grades and details. Cars outside the caller's data scope are reported as not found.
+// @Tags         cars
+// @Produce      application/pdf
+// @Param        id   path      int  true  "Car ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /cars/{id}/spec-sheet [get]
+// @Security     BearerAuth
+func (h *DocumentHandler) GetSpecSheet(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid car ID", err.Error())
+		return
+	}
+
+	pdf, err := h.Service.SpecSheet(c.Request.Context(), id)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Car not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to render spec sheet", err.Error())
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="car-%d-spec-sheet.pdf"`, id))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// GenerateInvoice godoc
+// @Summary      Generate an invoice for an order
+// @Description  Render a sales invoice for the order's cars and store it as a document of type invoice. Each call stores a new copy, so an invoice can be regenerated after the order changes. Orders with a car outside the caller's data scope are reported as not found.
+// @Tags         invoices
+// @Produce      json
+// @Param        id   path      int  true  "Order ID"
+// @Success      201  {object}  models.Document
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /orders/{id}/invoices [post]
+// @Security     BearerAuth
+func (h *DocumentHandler) GenerateInvoice(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID", err.Error())
+		return
+	}
+	var generatedBy *int64
+	if userID, ok := utils.GetUserID(c); ok {
+		generatedBy = &userID
+	}
+
+	doc, err := h.Service.GenerateInvoice(c.Request.Context(), orderID, generatedBy)
+	if err != nil {
+		switch err {
+		case utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "Order not found", err.Error())
+		case utils.ErrBadRequest:
+			utils.ErrorResponse(c, http.StatusBadRequest, "Order has no items to invoice", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate invoice", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Invoice generated successfully", doc)
+}
+
+// GetInvoices godoc
+// @Summary      List an order's invoices
+// @Description  List the invoices generated for the order, newest first
+// @Tags         invoices
+// @Produce      json
+// @Param        id   path      int  true  "Order ID"
+// @Success      200  {array}   models.Document
+// @Failure      400  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /orders/{id}/invoices [get]
+// @Security     BearerAuth
+func (h *DocumentHandler) GetInvoices(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID", err.Error())
+		return
+	}
+
+	docs, err := h.Service.ListInvoices(c.Request.Context(), orderID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch invoices", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Invoices fetched successfully", docs)
+}
+
+// DownloadInvoice godoc
+// @Summary      Download an invoice
+// @Description  Download a stored invoice PDF
+// @Tags         invoices
+// @Produce      application/pdf
+// @Param        id           path      int  true  "Order ID"
+// @Param        document_id  path      int  true  "Document ID"
+// @Success      200  {file}    file
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /orders/{id}/invoices/{document_id} [get]
+// @Security     BearerAuth
+func (h *DocumentHandler) DownloadInvoice(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order ID", err.Error())
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("document_id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid document ID", err.Error())
+		return
+	}
+
+	doc, path, err := h.Service.OpenInvoice(c.Request.Context(), orderID, id)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Invoice not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch invoice", err.Error())
+		}
+		return
+	}
+
+	utils.SkipETag(c)
+	c.FileAttachment(path, doc.FileName)
+}
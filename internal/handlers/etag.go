@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/utils"
+)
+
+// preconditionFailedResponse writes a 412 for a write whose If-Match no longer matches the resource.
+func preconditionFailedResponse(c *gin.Context, resource string, err error) {
+	utils.ErrorResponseWithHints(c, http.StatusPreconditionFailed, resource+" was modified by someone else", err.Error(),
+		[]string{"GET the resource again, reapply your changes and retry with the new ETag in If-Match"})
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/utils"
+)
+
+type JWKSHandler struct {
+	Keys *utils.KeySet
+}
+
+func NewJWKSHandler(keys *utils.KeySet) *JWKSHandler {
+	return &JWKSHandler{Keys: keys}
+}
+
+// GetJWKS godoc
+// @Summary      JSON Web Key Set
+// @Description  Public keys that verify access tokens, selected by the token's kid header. Served as a bare JWKS document (RFC 7517) rather than the usual response envelope. Empty when tokens are signed with a shared HMAC secret.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  utils.JWKS
+// @Router       /.well-known/jwks.json [get]
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	// Short cache so verifiers pick up a rotated key within minutes.
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.Keys.JWKS())
+}
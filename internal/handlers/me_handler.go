@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
+)
+
+type MeHandler struct {
+	Users service.UserService
+	Roles service.RoleService
+}
+
+func NewMeHandler(users service.UserService, roles service.RoleService) *MeHandler {
+	return &MeHandler{Users: users, Roles: roles}
+}
+
+// GetMe godoc
+// @Summary      Current user
+// @Description  The authenticated user's profile with their effective roles, permission slugs and permissions_version
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  dto.MeResponse
+// @Failure      401  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /api/v1/me [get]
+// @Security     BearerAuth
+func (h *MeHandler) GetMe(c *gin.Context) {
+	userID, ok := utils.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	user, err := h.Users.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch user", err.Error())
+		}
+		return
+	}
+	access, err := h.Roles.GetUserAccess(c.Request.Context(), userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch user permissions", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "User fetched successfully", dto.MeResponse{
+		User:               *user,
+		Roles:              access.Roles,
+		Permissions:        access.Permissions,
+		PermissionsVersion: access.PermissionsVersion,
+	})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
+)
+
+type OrderHandler struct {
+	Service service.OrderService
+}
+
+func NewOrderHandler(svc service.OrderService) *OrderHandler {
+	return &OrderHandler{Service: svc}
+}
+
+// CreateOrder godoc
+// @Summary      Create an order
+// @Description  Order one or more cars for the caller. Each item is priced at the car's list price unless a price is given. Cars must be available or reserved, not on another open order and within the caller's data scope. Selling below a car's minimum price returns 403 unless the caller holds price-override; allowed ones are listed in the response hints.
+// @Tags         orders
+// @Accept       json
+// @Produce      json
+// @Param        request  body      dto.OrderCreateRequest  true  "Order"
+// @Success      201  {object}  dto.OrderResponse
+// @Failure      400  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      409  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /orders [post]
+// @Security     BearerAuth
+func (h *OrderHandler) CreateOrder(c *gin.Context) {
+	userID, ok := utils.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	var req dto.OrderCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	order, notes, err := h.Service.CreateOrder(c.Request.Context(), userID, req)
+	if err != nil {
+		var below *service.BelowMinimumPriceError
+		switch {
+		case errors.As(err, &below):
+			hints := make([]string, len(below.Items))
+			for i, b := range below.Items {
+				hints[i] = fmt.Sprintf("Car %d may not be sold for less than %.2f", b.CarID, b.MinPrice)
+			}
+			hints = append(hints, "Selling below the minimum price requires the "+service.PermissionPriceOverride+" permission")
+			utils.ErrorResponseWithHints(c, http.StatusForbidden, "Price below the minimum", err.Error(), hints)
+		case errors.Is(err, utils.ErrConflict):
+			utils.ErrorResponse(c, http.StatusConflict, "Car cannot be ordered", err.Error())
+		case errors.Is(err, utils.ErrBadRequest):
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid order", err.Error())
+		case err == utils.ErrNotFound:
+			utils.ErrorResponseWithHints(c, http.StatusNotFound, "Car not found", err.Error(),
+				[]string{"Every car must exist, be outside the trash and be within your data scope"})
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create order", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponseWithHints(c, http.StatusCreated, "Order created successfully", order, notes)
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
+)
+
+type PasswordHandler struct {
+	Service service.PasswordService
+}
+
+func NewPasswordHandler(svc service.PasswordService) *PasswordHandler {
+	return &PasswordHandler{Service: svc}
+}
+
+// weakPasswordResponse writes a 400 listing the broken policy rules when err is a *utils.PasswordPolicyError.
+func weakPasswordResponse(c *gin.Context, err error) bool {
+	var weak *utils.PasswordPolicyError
+	if !errors.As(err, &weak) {
+		return false
+	}
+	utils.ErrorResponseWithHints(c, http.StatusBadRequest, "Password does not meet the strength policy", err.Error(), weak.Problems)
+	return true
+}
+
+// ChangePassword godoc
+// @Summary      Change own password
+// @Description  Change the caller's password. The current password is required. Every existing session is revoked and a fresh token pair is returned.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.ChangePasswordRequest  true  "Current and new password"
+// @Success      200   {object}  dto.TokenResponse
+// @Failure      400   {object}  utils.Response
+// @Failure      401   {object}  utils.Response
+// @Failure      403   {object}  utils.Response
+// @Failure      500   {object}  utils.Response
+// @Router       /api/v1/me/password [put]
+// @Security     BearerAuth
+func (h *PasswordHandler) ChangePassword(c *gin.Context) {
+	userID, ok := utils.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	tokens, err := h.Service.ChangePassword(c.Request.Context(), userID, req, clientInfo(c))
+	if err != nil {
+		if weakPasswordResponse(c, err) {
+			return
+		}
+		switch err {
+		case utils.ErrUnauthorized:
+			utils.ErrorResponse(c, http.StatusForbidden, "Current password is incorrect", "")
+		case utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to change password", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Password changed; other sessions have been signed out", tokens)
+}
+
+// RequestReset godoc
+// @Summary      Start a password reset for a user
+// @Description  Issue a single-use, time-limited reset token and deliver it to the user's email through the configured notifier. The token is never returned in the response.
+// @Tags         users
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  dto.PasswordResetIssuedResponse
+// @Failure      400  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /users/{id}/password-reset [post]
+// @Security     BearerAuth
+func (h *PasswordHandler) RequestReset(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+	adminID, ok := utils.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	resp, err := h.Service.RequestReset(c.Request.Context(), id, adminID)
+	if err != nil {
+		switch err {
+		case utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+		case utils.ErrForbidden:
+			utils.ErrorResponse(c, http.StatusForbidden, "Only an administrator can reset an administrator's password", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start password reset", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Password reset token sent", resp)
+}
+
+// ResetPassword godoc
+// @Summary      Complete a password reset
+// @Description  Set a new password using a reset token. The token can be used once; every existing session of the user is revoked.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.ResetPasswordRequest  true  "Reset token and new password"
+// @Success      200   {object}  utils.Response
+// @Failure      400   {object}  utils.Response
+// @Failure      401   {object}  utils.Response
+// @Failure      500   {object}  utils.Response
+// @Router       /api/v1/auth/password-reset [post]
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	if err := h.Service.ResetPassword(c.Request.Context(), req); err != nil {
+		if weakPasswordResponse(c, err) {
+			return
+		}
+		if err == utils.ErrUnauthorized {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired reset token", "")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reset password", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Password has been reset; log in with the new password", nil)
+}
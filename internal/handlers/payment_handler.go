@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
+)
+
+type PaymentHandler struct {
+	Service service.PaymentService
+}
+
+func NewPaymentHandler(svc service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{Service: svc}
+}
+
+// GetPayments godoc
+// @Summary      List payments
+// @Description  Get the payment history within the caller's showroom scope
+// @Tags         payments
+// @Produce      json
+// @Success      200  {array}   models.PaymentHistory
+// @Failure      500  {object}  utils.Response
+// @Router       /payments [get]
+// @Security     BearerAuth
+func (h *PaymentHandler) GetPayments(c *gin.Context) {
+	payments, err := h.Service.GetPayments(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch payments", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payments fetched successfully", payments)
+}
+
+// GetPaymentByID godoc
+// @Summary      Get a payment by ID
+// @Description  Get a payment record by its ID. Records outside the caller's showroom scope are reported as not found.
+// @Tags         payments
+// @Produce      json
+// @Param        id   path      int  true  "Payment ID"
+// @Success      200  {object}  models.PaymentHistory
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /payments/{id} [get]
+// @Security     BearerAuth
+func (h *PaymentHandler) GetPaymentByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment ID", err.Error())
+		return
+	}
+
+	payment, err := h.Service.GetPaymentByID(c.Request.Context(), id)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Payment not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch payment", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment fetched successfully", payment)
+}
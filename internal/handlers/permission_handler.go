@@ -146,6 +146,7 @@ func (h *PermissionHandler) UpdatePermission(c *gin.Context) {
 // @Param        id   path      int  true  "Permission ID"
 // @Success      200  {object}  utils.Response
 // @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
 // @Failure      500  {object}  utils.Response
 // @Router       /permissions/{id} [delete]
 // @Security     BearerAuth
@@ -157,7 +158,11 @@ func (h *PermissionHandler) DeletePermission(c *gin.Context) {
 	}
 
 	if err := h.Service.DeletePermission(c.Request.Context(), id); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete permission", err.Error())
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Permission not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete permission", err.Error())
+		}
 		return
 	}
 
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/user/car-project/internal/dto"
@@ -36,7 +37,8 @@ func (h *RAGHandler) Ask(c *gin.Context) {
 		return
 	}
 
-	result, err := h.Service.Ask(c.Request.Context(), req.Query)
+	userID, _ := utils.GetUserID(c)
+	result, err := h.Service.Ask(c.Request.Context(), userID, req.Query)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "RAG request failed", err.Error())
 		return
@@ -45,18 +47,62 @@ func (h *RAGHandler) Ask(c *gin.Context) {
 	sources := make([]dto.RAGSourceRef, len(result.Sources))
 	for i, s := range result.Sources {
 		sources[i] = dto.RAGSourceRef{
+			Marker:     s.Marker,
+			ChunkID:    s.ChunkID,
 			SourceType: s.SourceType,
 			SourceID:   s.SourceID,
 			Content:    s.Content,
+			Cited:      s.Cited,
 		}
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Answer generated", dto.RAGAskResponse{
+		QueryID: result.QueryID,
 		Answer:  result.Answer,
 		Sources: sources,
 	})
 }
 
+// Feedback godoc
+// @Summary      Rate a RAG answer
+// @Description  Give a thumbs up or down on an answer returned by /rag/ask. Only the user who asked the question can rate it.
+// @Tags         rag
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                     true  "Query ID (query_id from /rag/ask)"
+// @Param        body  body      dto.RAGFeedbackRequest  true  "Feedback"
+// @Success      200  {object}  utils.Response
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /rag/ask/{id}/feedback [post]
+// @Security     BearerAuth
+func (h *RAGHandler) Feedback(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid query ID", err.Error())
+		return
+	}
+
+	var req dto.RAGFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	userID, _ := utils.GetUserID(c)
+	if err := h.Service.Feedback(c.Request.Context(), id, userID, req.Rating == "up", req.Comment); err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Query not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to record feedback", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Feedback recorded", nil)
+}
+
 // IndexCars godoc
 // @Summary      Index cars for RAG
 // @Description  Re-index all cars (make, model, details, descriptions) into the RAG vector store. Call this after bulk updates or to refresh the knowledge base.
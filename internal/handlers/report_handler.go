@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
+)
+
+type ReportHandler struct {
+	Service service.ReportService
+}
+
+func NewReportHandler(svc service.ReportService) *ReportHandler {
+	return &ReportHandler{Service: svc}
+}
+
+// GetInventoryAging godoc
+// @Summary      Inventory aging
+// @Description  Bucket the cars in stock (available, reserved or damaged) by days since they came into stock: 0-30, 31-60, 61-90, 91-180 and 181+. The period limits the stock-in date, which is the date of the car's first LC or the day it was created.
+// @Tags         reports
+// @Produce      json
+// @Param        from  query     string  false  "First day, YYYY-MM-DD"
+// @Param        to    query     string  false  "Last day (inclusive), YYYY-MM-DD"
+// @Success      200  {object}  dto.InventoryAgingReport
+// @Failure      400  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /reports/inventory-aging [get]
+// @Security     BearerAuth
+func (h *ReportHandler) GetInventoryAging(c *gin.Context) {
+	var q dto.ReportQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	report, err := h.Service.InventoryAging(c.Request.Context(), q)
+	if err != nil {
+		reportErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Inventory aging report generated successfully", report)
+}
+
+// GetSellThrough godoc
+// @Summary      Sell-through
+// @Description  Compare the cars sold in the period (on approved, shipped or delivered orders, by order date) with the cars still in stock, per make, model or body type. Rate is sold / (sold + in stock).
+// @Tags         reports
+// @Produce      json
+// @Param        from      query     string  false  "First day, YYYY-MM-DD"
+// @Param        to        query     string  false  "Last day (inclusive), YYYY-MM-DD"
+// @Param        group_by  query     string  false  "make (default), model or body_type"
+// @Success      200  {object}  dto.SellThroughReport
+// @Failure      400  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /reports/sell-through [get]
+// @Security     BearerAuth
+func (h *ReportHandler) GetSellThrough(c *gin.Context) {
+	var q dto.SellThroughQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	report, err := h.Service.SellThrough(c.Request.Context(), q)
+	if err != nil {
+		reportErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Sell-through report generated successfully", report)
+}
+
+// GetRevenue godoc
+// @Summary      Revenue and margin per month
+// @Description  Total the sales of each month in the period and their margin over landed cost. Margin covers only the cars whose cost is known; cars_without_cost counts the others.
+// @Tags         reports
+// @Produce      json
+// @Param        from  query     string  false  "First day, YYYY-MM-DD"
+// @Param        to    query     string  false  "Last day (inclusive), YYYY-MM-DD"
+// @Success      200  {object}  dto.RevenueReport
+// @Failure      400  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /reports/revenue [get]
+// @Security     BearerAuth
+func (h *ReportHandler) GetRevenue(c *gin.Context) {
+	var q dto.ReportQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	report, err := h.Service.Revenue(c.Request.Context(), q)
+	if err != nil {
+		reportErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Revenue report generated successfully", report)
+}
+
+// GetReceivables godoc
+// @Summary      Outstanding receivables
+// @Description  List the customer purchases made in the period that their installments have not paid off, largest outstanding amount first.
+// @Tags         reports
+// @Produce      json
+// @Param        from  query     string  false  "First day, YYYY-MM-DD"
+// @Param        to    query     string  false  "Last day (inclusive), YYYY-MM-DD"
+// @Success      200  {object}  dto.ReceivablesReport
+// @Failure      400  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /reports/receivables [get]
+// @Security     BearerAuth
+func (h *ReportHandler) GetReceivables(c *gin.Context) {
+	var q dto.ReportQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	report, err := h.Service.Receivables(c.Request.Context(), q)
+	if err != nil {
+		reportErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Receivables report generated successfully", report)
+}
+
+// GetStockValue godoc
+// @Summary      Stock value by location
+// @Description  Value the cars in stock at each location at list price and landed cost. The period limits the stock-in date.
+// @Tags         reports
+// @Produce      json
+// @Param        from  query     string  false  "First day, YYYY-MM-DD"
+// @Param        to    query     string  false  "Last day (inclusive), YYYY-MM-DD"
+// @Success      200  {object}  dto.StockValueReport
+// @Failure      400  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /reports/stock-value [get]
+// @Security     BearerAuth
+func (h *ReportHandler) GetStockValue(c *gin.Context) {
+	var q dto.ReportQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	report, err := h.Service.StockValue(c.Request.Context(), q)
+	if err != nil {
+		reportErrorResponse(c, err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Stock value report generated successfully", report)
+}
+
+// GetReportViews godoc
+// @Summary      Report view refresh status
+// @Description  The materialized views the reports read sales, receivables and landed costs from, with when each was last refreshed, how long it took and its most recent failure.
+// @Tags         reports
+// @Produce      json
+// @Success      200  {array}   models.ReportViewRefresh
+// @Failure      500  {object}  utils.Response
+// @Router       /reports/views [get]
+// @Security     BearerAuth
+func (h *ReportHandler) GetReportViews(c *gin.Context) {
+	views, err := h.Service.Views(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch report views", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Report views fetched successfully", views)
+}
+
+// RefreshReportViews godoc
+// @Summary      Refresh report views now
+// @Description  Refresh the materialized views behind the reports without blocking reports that read them. Cached reports are recomputed afterwards. Returns 409 while another refresh is running.
+// @Tags         reports
+// @Produce      json
+// @Success      200  {array}   models.ReportViewRefresh
+// @Failure      409  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /reports/views/refresh [post]
+// @Security     BearerAuth
+func (h *ReportHandler) RefreshReportViews(c *gin.Context) {
+	views, err := h.Service.RefreshViews(c.Request.Context())
+	if err != nil {
+		if err == utils.ErrConflict {
+			utils.ErrorResponse(c, http.StatusConflict, "Report views are already being refreshed", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to refresh report views", err.Error())
+		}
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Report views refreshed", views)
+}
+
+func reportErrorResponse(c *gin.Context, err error) {
+	if errors.Is(err, utils.ErrBadRequest) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid report period", err.Error())
+	} else {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to generate report", err.Error())
+	}
+}
@@ -139,13 +139,15 @@ func (h *RoleHandler) UpdateRole(c *gin.Context) {
 
 // DeleteRole godoc
 // @Summary      Delete role
-// @Description  Delete a role by its ID
+// @Description  Delete a role by its ID. The admin role cannot be deleted.
 // @Tags         roles
 // @Accept       json
 // @Produce      json
 // @Param        id   path      int  true  "Role ID"
 // @Success      200  {object}  utils.Response
 // @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      409  {object}  utils.Response
 // @Failure      500  {object}  utils.Response
 // @Router       /roles/{id} [delete]
 // @Security     BearerAuth
@@ -157,7 +159,14 @@ func (h *RoleHandler) DeleteRole(c *gin.Context) {
 	}
 
 	if err := h.Service.DeleteRole(c.Request.Context(), id); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete role", err.Error())
+		switch err {
+		case utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "Role not found", err.Error())
+		case utils.ErrLastAdmin:
+			utils.ErrorResponse(c, http.StatusConflict, "The admin role cannot be deleted", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete role", err.Error())
+		}
 		return
 	}
 
@@ -166,13 +175,15 @@ func (h *RoleHandler) DeleteRole(c *gin.Context) {
 
 // AssignRole godoc
 // @Summary      Assign role to user
-// @Description  Assign a role to a user
+// @Description  Assign a role to a user. The caller must hold every permission the role grants and is recorded as assigned_by.
 // @Tags         roles
 // @Accept       json
 // @Produce      json
 // @Param        assignment body dto.AssignRoleRequest true "Assignment Payload"
 // @Success      200  {object}  utils.Response
 // @Failure      400  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
 // @Failure      500  {object}  utils.Response
 // @Router       /roles/assign [post]
 // @Security     BearerAuth
@@ -182,11 +193,214 @@ func (h *RoleHandler) AssignRole(c *gin.Context) {
 		utils.ValidationErrorResponse(c, err)
 		return
 	}
+	assignedBy, ok := utils.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
 
-	if err := h.Service.AssignRole(c.Request.Context(), req); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to assign role", err.Error())
+	if err := h.Service.AssignRole(c.Request.Context(), req, assignedBy); err != nil {
+		switch err {
+		case utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "User or role not found", err.Error())
+		case utils.ErrForbidden:
+			utils.ErrorResponse(c, http.StatusForbidden, "Cannot assign a role with permissions you do not have", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to assign role", err.Error())
+		}
 		return
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Role assigned successfully", nil)
 }
+
+// RevokeRole godoc
+// @Summary      Revoke role from user
+// @Description  Remove a role from a user. The last active administrator cannot lose the admin role.
+// @Tags         roles
+// @Accept       json
+// @Produce      json
+// @Param        assignment body dto.AssignRoleRequest true "Assignment Payload"
+// @Success      200  {object}  utils.Response
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      409  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /roles/revoke [post]
+// @Security     BearerAuth
+func (h *RoleHandler) RevokeRole(c *gin.Context) {
+	var req dto.AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	if err := h.Service.RevokeRole(c.Request.Context(), req); err != nil {
+		switch err {
+		case utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "User does not have this role", err.Error())
+		case utils.ErrLastAdmin:
+			utils.ErrorResponseWithHints(c, http.StatusConflict, "Cannot remove the last administrator", err.Error(),
+				[]string{"Assign the admin role to another active user first"})
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke role", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Role revoked successfully", nil)
+}
+
+// GetRolePermissions godoc
+// @Summary      List role permissions
+// @Description  List the permissions granted to a role
+// @Tags         roles
+// @Produce      json
+// @Param        id   path      int  true  "Role ID"
+// @Success      200  {object}  dto.RolePermissionsResponse
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /roles/{id}/permissions [get]
+// @Security     BearerAuth
+func (h *RoleHandler) GetRolePermissions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid role ID", err.Error())
+		return
+	}
+
+	resp, err := h.Service.GetRolePermissions(c.Request.Context(), id)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Role not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch role permissions", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Role permissions fetched successfully", resp)
+}
+
+// GrantPermissions godoc
+// @Summary      Grant permissions to role
+// @Description  Grant several permissions to a role at once. The caller must hold every permission they grant and is recorded as assigned_by. Permissions the role already has are ignored.
+// @Tags         roles
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                         true  "Role ID"
+// @Param        body  body      dto.RolePermissionsRequest  true  "Permission IDs"
+// @Success      200   {object}  dto.RolePermissionsResponse
+// @Failure      400   {object}  utils.Response
+// @Failure      403   {object}  utils.Response
+// @Failure      404   {object}  utils.Response
+// @Failure      500   {object}  utils.Response
+// @Router       /roles/{id}/permissions [post]
+// @Security     BearerAuth
+func (h *RoleHandler) GrantPermissions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid role ID", err.Error())
+		return
+	}
+	var req dto.RolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	assignedBy, ok := utils.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	resp, err := h.Service.GrantPermissions(c.Request.Context(), id, req, assignedBy)
+	if err != nil {
+		switch err {
+		case utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "Role not found", err.Error())
+		case utils.ErrBadRequest:
+			utils.ErrorResponseWithHints(c, http.StatusBadRequest, "Unknown permission ID", err.Error(),
+				[]string{"Use GET /api/v1/permissions to list valid permission IDs"})
+		case utils.ErrForbidden:
+			utils.ErrorResponse(c, http.StatusForbidden, "Cannot grant permissions you do not have", err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to grant permissions", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Permissions granted successfully", resp)
+}
+
+// RevokePermissions godoc
+// @Summary      Revoke permissions from role
+// @Description  Revoke several permissions from a role at once. Permissions the role does not have are ignored.
+// @Tags         roles
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                         true  "Role ID"
+// @Param        body  body      dto.RolePermissionsRequest  true  "Permission IDs"
+// @Success      200   {object}  dto.RolePermissionsResponse
+// @Failure      400   {object}  utils.Response
+// @Failure      404   {object}  utils.Response
+// @Failure      500   {object}  utils.Response
+// @Router       /roles/{id}/permissions [delete]
+// @Security     BearerAuth
+func (h *RoleHandler) RevokePermissions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid role ID", err.Error())
+		return
+	}
+	var req dto.RolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	resp, err := h.Service.RevokePermissions(c.Request.Context(), id, req)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Role not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke permissions", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Permissions revoked successfully", resp)
+}
+
+// GetUserAccess godoc
+// @Summary      Get a user's roles and effective permissions
+// @Description  List the user's roles and the union of the permission slugs they grant
+// @Tags         users
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  dto.UserAccessResponse
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /users/{id}/permissions [get]
+// @Security     BearerAuth
+func (h *RoleHandler) GetUserAccess(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	resp, err := h.Service.GetUserAccess(c.Request.Context(), id)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch user permissions", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "User permissions fetched successfully", resp)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
+)
+
+type ScopeHandler struct {
+	Service service.ScopeService
+}
+
+func NewScopeHandler(svc service.ScopeService) *ScopeHandler {
+	return &ScopeHandler{Service: svc}
+}
+
+// GetUserScopes godoc
+// @Summary      Get a user's data scopes
+// @Description  List the locations and showrooms a user is limited to. Users without any assignment see no scoped rows unless they hold data-scope-bypass.
+// @Tags         users
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  dto.UserScopesResponse
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /users/{id}/scopes [get]
+// @Security     BearerAuth
+func (h *ScopeHandler) GetUserScopes(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	resp, err := h.Service.GetUserScopes(c.Request.Context(), id)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch user scopes", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "User scopes fetched successfully", resp)
+}
+
+// AddUserScope godoc
+// @Summary      Assign a data scope to a user
+// @Description  Allow a user to see rows of one location or showroom. Assigning an existing value is a no-op. Requires data-scope-bypass.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                   true  "User ID"
+// @Param        body  body      dto.UserScopeRequest  true  "Scope type and value"
+// @Success      200   {object}  dto.UserScopesResponse
+// @Failure      400   {object}  utils.Response
+// @Failure      403   {object}  utils.Response
+// @Failure      404   {object}  utils.Response
+// @Failure      500   {object}  utils.Response
+// @Router       /users/{id}/scopes [post]
+// @Security     BearerAuth
+func (h *ScopeHandler) AddUserScope(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+	adminID, ok := utils.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	var req dto.UserScopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	resp, err := h.Service.AddUserScope(c.Request.Context(), id, req, adminID)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to assign user scope", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "User scope assigned successfully", resp)
+}
+
+// RemoveUserScope godoc
+// @Summary      Remove a data scope from a user
+// @Description  Stop a user from seeing rows of one location or showroom. Requires data-scope-bypass.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                   true  "User ID"
+// @Param        body  body      dto.UserScopeRequest  true  "Scope type and value"
+// @Success      200   {object}  dto.UserScopesResponse
+// @Failure      400   {object}  utils.Response
+// @Failure      403   {object}  utils.Response
+// @Failure      404   {object}  utils.Response
+// @Failure      500   {object}  utils.Response
+// @Router       /users/{id}/scopes [delete]
+// @Security     BearerAuth
+func (h *ScopeHandler) RemoveUserScope(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	var req dto.UserScopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	resp, err := h.Service.RemoveUserScope(c.Request.Context(), id, req)
+	if err != nil {
+		if err == utils.ErrNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "User scope not found", err.Error())
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to remove user scope", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "User scope removed successfully", resp)
+}
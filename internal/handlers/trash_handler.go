@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
+)
+
+type TrashHandler struct {
+	Service service.TrashService
+}
+
+func NewTrashHandler(svc service.TrashService) *TrashHandler {
+	return &TrashHandler{Service: svc}
+}
+
+// GetTrash godoc
+// @Summary      List deleted cars and users
+// @Description  Soft-deleted cars (within the caller's locations) and users, newest first, with the time each will be purged.
+// @Tags         trash
+// @Produce      json
+// @Success      200  {object}  dto.TrashResponse
+// @Failure      500  {object}  utils.Response
+// @Router       /trash [get]
+// @Security     BearerAuth
+func (h *TrashHandler) GetTrash(c *gin.Context) {
+	trash, err := h.Service.GetTrash(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to list trash", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Trash retrieved successfully", trash)
+}
+
+// PurgeTrash godoc
+// @Summary      Purge expired trash now
+// @Description  Permanently delete cars and users that have been in the trash longer than the retention period. Applies to all locations regardless of data scope. Cars on an order and users with orders are kept.
+// @Tags         trash
+// @Produce      json
+// @Success      200  {object}  dto.PurgeResponse
+// @Failure      500  {object}  utils.Response
+// @Router       /trash/purge [post]
+// @Security     BearerAuth
+func (h *TrashHandler) PurgeTrash(c *gin.Context) {
+	res, err := h.Service.Purge(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to purge trash", err.Error())
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Trash purged", res)
+}
@@ -27,17 +27,27 @@ func NewUserHandler(svc service.UserService) *UserHandler {
 // @Param        user  body      dto.CreateUserRequest  true  "User JSON"
 // @Success      201  {object}  dto.UserResponse
 // @Failure      400  {object}  utils.Response
+// @Failure      409  {object}  utils.Response
 // @Failure      500  {object}  utils.Response
 // @Router       /users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
+	adminID, ok := utils.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
 	var req dto.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, err)
 		return
 	}
 
-	user, err := h.Service.CreateUser(c.Request.Context(), req)
+	user, err := h.Service.CreateUser(c.Request.Context(), req, adminID)
 	if err != nil {
+		if weakPasswordResponse(c, err) {
+			return
+		}
 		if err == utils.ErrAlreadyExists {
 			utils.ErrorResponse(c, http.StatusConflict, "User already exists", err.Error())
 		} else {
@@ -88,6 +98,7 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 		return
 	}
 
+	utils.SetVersionETag(c, user.Version)
 	utils.SuccessResponse(c, http.StatusOK, "User fetched successfully", user)
 }
 
@@ -96,7 +107,12 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 // @Tags         users
 // @Param        id   path      int  true  "User ID"
 // @Param        user body      dto.UpdateUserRequest true "Update Payload"
-// @Success      200  {object}  utils.Response
+// @Param        If-Match  header  string  true  "ETag from GET /users/{id}"
+// @Success      200  {object}  dto.UserResponse
+// @Failure      403  {object}  utils.Response
+// @Failure      409  {object}  utils.Response
+// @Failure      412  {object}  utils.Response
+// @Failure      428  {object}  utils.Response
 // @Router       /users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -105,29 +121,51 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	adminID, ok := utils.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
 	var req dto.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, err)
 		return
 	}
 
-	if err := h.Service.UpdateUser(c.Request.Context(), id, req); err != nil {
-		if err == utils.ErrNotFound {
+	version, _ := utils.GetIfMatchVersion(c)
+	user, err := h.Service.UpdateUser(c.Request.Context(), id, version, req, adminID)
+	if err != nil {
+		switch err {
+		case utils.ErrNotFound:
 			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
-		} else {
+		case utils.ErrPreconditionFailed:
+			preconditionFailedResponse(c, "User", err)
+		case utils.ErrLastAdmin:
+			utils.ErrorResponseWithHints(c, http.StatusConflict, "Cannot deactivate the last administrator", err.Error(),
+				[]string{"Assign the admin role to another active user first"})
+		case utils.ErrForbidden:
+			utils.ErrorResponse(c, http.StatusForbidden, "Only an administrator can change an administrator", err.Error())
+		default:
 			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update user", err.Error())
 		}
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "User updated successfully", nil)
+	utils.SetVersionETag(c, user.Version)
+	utils.SuccessResponse(c, http.StatusOK, "User updated successfully", user)
 }
 
 // DeleteUser godoc
 // @Summary      Delete user
 // @Tags         users
 // @Param        id   path      int  true  "User ID"
+// @Param        If-Match  header  string  true  "ETag from GET /users/{id}"
 // @Success      200  {object}  utils.Response
+// @Failure      403  {object}  utils.Response
+// @Failure      409  {object}  utils.Response
+// @Failure      412  {object}  utils.Response
+// @Failure      428  {object}  utils.Response
 // @Router       /users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -136,10 +174,101 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.Service.DeleteUser(c.Request.Context(), id); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete user", err.Error())
+	adminID, ok := utils.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	version, _ := utils.GetIfMatchVersion(c)
+
+	if err := h.Service.DeleteUser(c.Request.Context(), id, version, adminID); err != nil {
+		switch err {
+		case utils.ErrLastAdmin:
+			utils.ErrorResponseWithHints(c, http.StatusConflict, "Cannot delete the last administrator", err.Error(),
+				[]string{"Assign the admin role to another active user first"})
+		case utils.ErrForbidden:
+			utils.ErrorResponse(c, http.StatusForbidden, "Only an administrator can delete an administrator", err.Error())
+		case utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found", err.Error())
+		case utils.ErrPreconditionFailed:
+			preconditionFailedResponse(c, "User", err)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete user", err.Error())
+		}
 		return
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "User deleted successfully", nil)
 }
+
+// RestoreUser godoc
+// @Summary      Restore a deleted user
+// @Description  Take a user out of the trash. Fails with 409 if another user has since taken their username or email.
+// @Tags         users
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  utils.Response
+// @Failure      400  {object}  utils.Response
+// @Failure      404  {object}  utils.Response
+// @Failure      409  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /users/{id}/restore [post]
+// @Security     BearerAuth
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	adminID, ok := utils.GetUserID(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	if err := h.Service.RestoreUser(c.Request.Context(), id, adminID); err != nil {
+		switch err {
+		case utils.ErrNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found in trash", err.Error())
+		case utils.ErrAlreadyExists:
+			utils.ErrorResponseWithHints(c, http.StatusConflict, "Username or email is now used by another user", err.Error(),
+				[]string{"Change the other user's email, or delete that user first"})
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to restore user", err.Error())
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "User restored successfully", nil)
+}
+
+// GetLoginEvents godoc
+// @Summary      List a user's recent login activity
+// @Description  Most recent login attempts for the user, including IP, user agent and outcome
+// @Tags         users
+// @Produce      json
+// @Param        id     path      int  true   "User ID"
+// @Param        limit  query     int  false  "Max events (default 50, max 200)"
+// @Success      200  {array}   dto.LoginEventResponse
+// @Failure      400  {object}  utils.Response
+// @Failure      500  {object}  utils.Response
+// @Router       /users/{id}/login-events [get]
+// @Security     BearerAuth
+func (h *UserHandler) GetLoginEvents(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	events, err := h.Service.GetLoginEvents(c.Request.Context(), id, limit)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch login events", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Login events fetched successfully", events)
+}
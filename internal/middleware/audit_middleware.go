@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// AuditContext stores the request's track_id and client IP in the request context so
+// repositories can attribute the changes they audit. AuthMiddleware adds the caller.
+// Must run after TrackIDMiddleware.
+func AuditContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := repository.AuditActor{TrackID: utils.GetTrackID(c), IP: c.ClientIP()}
+		c.Request = c.Request.WithContext(repository.WithAuditActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
+
+// setAuditCaller records the authenticated user (and API key, if one was used) as the audit actor.
+func setAuditCaller(c *gin.Context, userID int64, apiKeyID *int64) {
+	ctx := c.Request.Context()
+	actor, _ := repository.AuditActorFromContext(ctx)
+	actor.UserID = &userID
+	actor.APIKeyID = apiKeyID
+	c.Request = c.Request.WithContext(repository.WithAuditActor(ctx, actor))
+}
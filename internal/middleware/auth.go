@@ -5,14 +5,48 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/service"
 	"github.com/user/car-project/internal/utils"
 )
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// AuthMiddleware authenticates either an X-API-Key header or a bearer token. For tokens it
+// rejects revoked ones and those whose permissions_version no longer matches the user's, and
+// stores the claims in the context. Both credentials store the acting user ID, so permission
+// checks and data scoping treat an API key like its service user.
+func AuthMiddleware(keys *utils.KeySet, tokens service.TokenService, perms service.PermissionService, apiKeys service.APIKeyService) gin.HandlerFunc {
+	logger := utils.GetLogger()
 	return func(c *gin.Context) {
+		if rawKey := c.GetHeader("X-API-Key"); rawKey != "" {
+			// ClientIP only honours X-Forwarded-For from TRUSTED_PROXIES, so the allowlist
+			// cannot be bypassed by sending the header directly.
+			key, err := apiKeys.Authenticate(c.Request.Context(), rawKey, c.ClientIP())
+			switch err {
+			case nil:
+			case utils.ErrUnauthorized:
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid, expired or revoked API key"})
+				c.Abort()
+				return
+			case utils.ErrForbidden:
+				c.JSON(http.StatusForbidden, gin.H{"error": "API key is not allowed from this IP address"})
+				c.Abort()
+				return
+			default:
+				logger.Printf("Failed to authenticate API key: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate API key"})
+				c.Abort()
+				return
+			}
+
+			c.Set(utils.UserIDContextKey, key.UserID)
+			c.Set(utils.APIKeyContextKey, key.ID)
+			setAuditCaller(c, key.UserID, &key.ID)
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header is required"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header or X-API-Key is required"})
 			c.Abort()
 			return
 		}
@@ -24,14 +58,45 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		claims, err := utils.ValidateToken(parts[1], jwtSecret)
+		claims, err := utils.ValidateToken(parts[1], keys)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		c.Set("userID", claims.UserID)
+		// Reject tokens revoked by logout, password change or refresh-token reuse detection.
+		revoked, err := tokens.IsRevoked(c.Request.Context(), claims.ID)
+		if err != nil {
+			logger.Printf("Failed to check token revocation for user %d: %v", claims.UserID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate token"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		// Roles or permissions changed since the token was issued, so embedded claims may be wrong,
+		// or the user was deactivated or deleted (version 0).
+		version, err := perms.GetPermissionsVersion(c.Request.Context(), claims.UserID)
+		if err != nil {
+			logger.Printf("Failed to check permissions version for user %d: %v", claims.UserID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to validate token"})
+			c.Abort()
+			return
+		}
+		if version != claims.PermissionsVersion {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token permissions are out of date; refresh the token"})
+			c.Abort()
+			return
+		}
+
+		c.Set(utils.UserIDContextKey, claims.UserID)
+		c.Set(utils.ClaimsContextKey, claims)
+		setAuditCaller(c, claims.UserID, nil)
 		c.Next()
 	}
 }
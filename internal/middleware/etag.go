@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/utils"
 )
 
 type responseBodyWriter struct {
@@ -14,17 +15,37 @@ type responseBodyWriter struct {
 	body        *bytes.Buffer
 	wroteHeader bool
 	status      int
+	ctx         *gin.Context
+	passthrough bool
+}
+
+// streaming reports whether the handler called utils.SkipETag, in which case the response is
+// written straight through from then on.
+func (w *responseBodyWriter) streaming() bool {
+	if !w.passthrough && w.ctx.GetBool(utils.SkipETagContextKey) {
+		w.passthrough = true
+		if w.status != 0 {
+			w.ResponseWriter.WriteHeader(w.status)
+		}
+	}
+	return w.passthrough
 }
 
 func (w *responseBodyWriter) WriteHeader(status int) {
 	w.status = status
-	// We don't call w.ResponseWriter.WriteHeader(status) yet
+	if w.streaming() {
+		w.ResponseWriter.WriteHeader(status)
+	}
+	// Otherwise we don't call w.ResponseWriter.WriteHeader(status) yet
 }
 
 func (w *responseBodyWriter) Write(b []byte) (int, error) {
 	if w.status == 0 {
 		w.status = http.StatusOK
 	}
+	if w.streaming() {
+		return w.ResponseWriter.Write(b)
+	}
 	return w.body.Write(b)
 }
 
@@ -32,6 +53,9 @@ func (w *responseBodyWriter) WriteString(s string) (int, error) {
 	if w.status == 0 {
 		w.status = http.StatusOK
 	}
+	if w.streaming() {
+		return w.ResponseWriter.WriteString(s)
+	}
 	return w.body.WriteString(s)
 }
 
@@ -52,11 +76,15 @@ func ETagMiddleware() gin.HandlerFunc {
 
 		// Save the original writer
 		originalWriter := c.Writer
-		w := &responseBodyWriter{body: &bytes.Buffer{}, ResponseWriter: originalWriter}
+		w := &responseBodyWriter{body: &bytes.Buffer{}, ResponseWriter: originalWriter, ctx: c}
 		c.Writer = w
 
 		c.Next()
 
+		if w.passthrough {
+			return
+		}
+
 		// If the response was not 200 OK, don't generate ETag
 		if w.Status() != http.StatusOK {
 			// Write whatever we captured to the original writer
@@ -75,12 +103,14 @@ func ETagMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Calculate SHA-1 hash
-		hash := sha1.Sum(data)
-		etag := "\"" + hex.EncodeToString(hash[:]) + "\""
-
-		// Set ETag header
-		c.Header("ETag", etag)
+		// Versioned resources set their own ETag (see utils.SetVersionETag) so that it can be
+		// sent back in If-Match; everything else gets a hash of the body.
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			hash := sha1.Sum(data)
+			etag = "\"" + hex.EncodeToString(hash[:]) + "\""
+			c.Header("ETag", etag)
+		}
 
 		// Check If-None-Match header
 		if c.Request.Header.Get("If-None-Match") == etag {
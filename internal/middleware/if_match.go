@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/utils"
+)
+
+// RequireIfMatch makes a write conditional on the version the client last read. The request
+// must carry an If-Match header holding the resource's ETag; it is rejected with 428 when the
+// header is missing and 412 when it is not a version ETag. Handlers read the version with
+// utils.GetIfMatchVersion and pass it to the service, which fails with
+// utils.ErrPreconditionFailed if the resource has changed since.
+func RequireIfMatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("If-Match")
+		if header == "" {
+			utils.ErrorResponseWithHints(c, http.StatusPreconditionRequired, "If-Match header is required", "",
+				[]string{"GET the resource and send its ETag header value in If-Match"})
+			c.Abort()
+			return
+		}
+		version, ok := utils.ParseVersionETag(header)
+		if !ok {
+			utils.ErrorResponseWithHints(c, http.StatusPreconditionFailed, "If-Match does not match the current version", "",
+				[]string{"Send the ETag exactly as returned, including the quotes"})
+			c.Abort()
+			return
+		}
+		c.Set(utils.IfMatchContextKey, version)
+		c.Next()
+	}
+}
@@ -6,40 +6,42 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
 )
 
+// RequirePermission allows the request only if the user holds requiredPerm, directly or via a wildcard such as "car-*".
 func RequirePermission(permService service.PermissionService, requiredPerm string) gin.HandlerFunc {
+	return requirePermissions(permService, func(set service.PermissionSet) bool {
+		return set.Has(requiredPerm)
+	})
+}
+
+// RequireAnyPermission allows the request if the user holds at least one of perms.
+func RequireAnyPermission(permService service.PermissionService, perms ...string) gin.HandlerFunc {
+	return requirePermissions(permService, func(set service.PermissionSet) bool {
+		return set.HasAny(perms...)
+	})
+}
+
+// RequireAllPermissions allows the request only if the user holds every one of perms.
+func RequireAllPermissions(permService service.PermissionService, perms ...string) gin.HandlerFunc {
+	return requirePermissions(permService, func(set service.PermissionSet) bool {
+		return set.HasAll(perms...)
+	})
+}
+
+func requirePermissions(permService service.PermissionService, allowed func(service.PermissionSet) bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get userID from context (set by AuthMiddleware)
-		userID, exists := c.Get("userID")
-		if !exists {
+		uid, ok := utils.GetUserID(c)
+		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
 			c.Abort()
 			return
 		}
 
-		// Type assertion
-		uid, ok := userID.(int64)
-		if !ok {
-			// This handles float64 which JWT parsing might return, depending on implementation
-			// Safe conversion attempt if int64 fails directly
-			if val, ok := userID.(float64); ok {
-				uid = int64(val)
-			} else {
-				// Also handle int if platform dependent
-				if val, ok := userID.(int); ok {
-					uid = int64(val)
-				} else {
-					log.Printf("UserID type assertion failed: %T %v", userID, userID)
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
-					c.Abort()
-					return
-				}
-			}
-		}
-
-		// Fetch permissions
-		perms, err := permService.GetUserPermissions(c.Request.Context(), uid)
+		// Resolved through the permission cache
+		set, err := permService.GetUserPermissionSet(c.Request.Context(), uid)
 		if err != nil {
 			log.Printf("Failed to fetch permissions for user %d: %v", uid, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
@@ -47,16 +49,7 @@ func RequirePermission(permService service.PermissionService, requiredPerm strin
 			return
 		}
 
-		// Check if user has the required permission
-		hasPerm := false
-		for _, p := range perms {
-			if p == requiredPerm {
-				hasPerm = true
-				break
-			}
-		}
-
-		if !hasPerm {
+		if !allowed(set) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
 			c.Abort()
 			return
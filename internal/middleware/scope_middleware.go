@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
+)
+
+// DataScope resolves the caller's location and showroom scope and stores it in the request
+// context, where repositories pick it up to filter scoped tables. Must run after AuthMiddleware.
+func DataScope(scopes service.ScopeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := utils.GetUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			c.Abort()
+			return
+		}
+
+		scope, err := scopes.ResolveScope(c.Request.Context(), uid)
+		if err != nil {
+			log.Printf("Failed to resolve data scope for user %d: %v", uid, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resolve data scope"})
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(repository.WithScope(c.Request.Context(), scope))
+		c.Next()
+	}
+}
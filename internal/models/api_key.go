@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// APIKey is a credential for service-to-service integrations. Requests made with it act as
+// the key's service user (UserID), which holds RoleID.
+type APIKey struct {
+	ID         int64          `db:"id" json:"id"`
+	Name       string         `db:"name" json:"name"`
+	KeyPrefix  string         `db:"key_prefix" json:"key_prefix"`
+	KeyHash    string         `db:"key_hash" json:"-"`
+	UserID     int64          `db:"user_id" json:"user_id"`
+	RoleID     int64          `db:"role_id" json:"role_id"`
+	AllowedIPs pq.StringArray `db:"allowed_ips" json:"allowed_ips"`
+	ExpiresAt  *time.Time     `db:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time     `db:"last_used_at" json:"last_used_at"`
+	RevokedAt  *time.Time     `db:"revoked_at" json:"revoked_at"`
+	CreatedBy  *int64         `db:"created_by" json:"created_by"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+}
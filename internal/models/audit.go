@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit actions recorded in audit_log.action.
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+	AuditGrant  = "grant"
+	AuditRevoke = "revoke"
+	// AuditRestore takes a row out of the trash; AuditPurge removes it permanently.
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+	// AuditMerge is recorded on the car a duplicate was merged into.
+	AuditMerge = "merge"
+)
+
+// Audited entity types recorded in audit_log.entity_type.
+const (
+	AuditEntityUser       = "user"
+	AuditEntityCar        = "car"
+	AuditEntityRole       = "role"
+	AuditEntityPermission = "permission"
+	AuditEntityAPIKey     = "api_key"
+	AuditEntityDocument   = "document"
+	AuditEntityOrder      = "order"
+)
+
+// AuditLog records one mutation. Before and After hold only the fields that changed
+// (the whole row for creates and deletes); secrets are redacted.
+type AuditLog struct {
+	ID            int64           `db:"id" json:"id"`
+	ActorUserID   *int64          `db:"actor_user_id" json:"actor_user_id"`
+	ActorAPIKeyID *int64          `db:"actor_api_key_id" json:"actor_api_key_id"`
+	Action        string          `db:"action" json:"action"`
+	EntityType    string          `db:"entity_type" json:"entity_type"`
+	EntityID      int64           `db:"entity_id" json:"entity_id"`
+	Before        json.RawMessage `db:"before" json:"before" swaggertype:"object"`
+	After         json.RawMessage `db:"after" json:"after" swaggertype:"object"`
+	TrackID       *string         `db:"track_id" json:"track_id"`
+	IPAddress     *string         `db:"ip_address" json:"ip_address"`
+	CreatedAt     time.Time       `db:"created_at" json:"created_at"`
+}
@@ -3,28 +3,47 @@ package models
 import "time"
 
 type Car struct {
-	ID            int64     `db:"id" json:"id"`
-	ModelID       int64     `db:"model_id" json:"model_id" binding:"required"`
-	RefNo         *string   `db:"ref_no" json:"ref_no" binding:"required"`
-	Package       *string   `db:"package" json:"package"`
-	BodyType      *string   `db:"body_type" json:"body_type"`
-	Year          *int16    `db:"year" json:"year"`
-	Color         *string   `db:"color" json:"color"`
-	RegYearMonth  *string   `db:"reg_year_month" json:"reg_year_month"`
-	MileageKM     *int32    `db:"mileage_km" json:"mileage_km"`
-	ChassisNoFull *string   `db:"chassis_no_full" json:"chassis_no_full"`
-	EngineCC      *int32    `db:"engine_cc" json:"engine_cc"`
-	Fuel          *string   `db:"fuel" json:"fuel"`
-	Transmission  *string   `db:"transmission" json:"transmission"`
-	Drive         *string   `db:"drive" json:"drive"`
-	EngineNumber  *string   `db:"engine_number" json:"engine_number"`
-	Seats         *int16    `db:"seats" json:"seats"`
-	NumberOfKeys  *int32    `db:"number_of_keys" json:"number_of_keys"`
-	KeysFeature   *string   `db:"keys_feature" json:"keys_feature"`
-	Steering      *string   `db:"steering" json:"steering"`
-	Location      *string   `db:"location" json:"location"`
-	CountryOrigin *string   `db:"country_origin" json:"country_origin"`
-	Status        *string   `db:"status" json:"status" binding:"omitempty,oneof=available sold pending"`
-	CreatedAt     time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+	ID            int64      `db:"id" json:"id"`
+	ModelID       int64      `db:"model_id" json:"model_id" binding:"required"`
+	RefNo         *string    `db:"ref_no" json:"ref_no" binding:"required"`
+	Package       *string    `db:"package" json:"package"`
+	BodyType      *string    `db:"body_type" json:"body_type"`
+	Year          *int16     `db:"year" json:"year"`
+	Color         *string    `db:"color" json:"color"`
+	RegYearMonth  *string    `db:"reg_year_month" json:"reg_year_month"`
+	MileageKM     *int32     `db:"mileage_km" json:"mileage_km"`
+	ChassisNoFull *string    `db:"chassis_no_full" json:"chassis_no_full"`
+	EngineCC      *int32     `db:"engine_cc" json:"engine_cc"`
+	Fuel          *string    `db:"fuel" json:"fuel"`
+	Transmission  *string    `db:"transmission" json:"transmission"`
+	Drive         *string    `db:"drive" json:"drive"`
+	EngineNumber  *string    `db:"engine_number" json:"engine_number"`
+	Seats         *int16     `db:"seats" json:"seats"`
+	NumberOfKeys  *int32     `db:"number_of_keys" json:"number_of_keys"`
+	KeysFeature   *string    `db:"keys_feature" json:"keys_feature"`
+	Steering      *string    `db:"steering" json:"steering"`
+	Location      *string    `db:"location" json:"location"`
+	CountryOrigin *string    `db:"country_origin" json:"country_origin"`
+	Status        *string    `db:"status" json:"status" binding:"omitempty,oneof=available reserved sold damaged lost stolen"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt     *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	// Version is bumped whenever an editable field changes and served as the ETag.
+	Version int64 `db:"version" json:"version"`
 }
+
+// CarNonPatchableFields are the JSON names of the car fields a patch cannot change: those
+// the server maintains, and the status, which has its own endpoint.
+var CarNonPatchableFields = map[string]bool{
+	"id": true, "created_at": true, "updated_at": true, "deleted_at": true, "version": true, "status": true,
+}
+
+// Allowed values of the car enum columns, as defined in the schema.
+var (
+	CarBodyTypes = []string{"Sedan", "Hatchback", "SUV", "Crossover", "Coupe", "Convertible",
+		"Wagon", "Van", "Minivan", "Pickup", "Microbus", "Roadster", "Fastback", "Liftback"}
+	CarFuels         = []string{"Petrol", "Diesel", "Hybrid", "Electric", "CNG", "LPG"}
+	CarTransmissions = []string{"Manual", "Automatic", "CVT", "DCT"}
+	CarDrives        = []string{"FWD", "RWD", "AWD", "4WD"}
+	CarSteerings     = []string{"Left", "Right"}
+)
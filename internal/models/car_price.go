@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// CarPricing is what a car is offered for and what it cost, in BDT. CostBasis is the landed
+// cost summed over the car's purchase records (total_bdt + govt_duty + cnf_amount +
+// miscellaneous); it and the prices are nil when unknown. Margin and MarginPercent compare
+// the list price with the cost basis.
+type CarPricing struct {
+	ListPrice      *float64   `db:"list_price" json:"list_price"`
+	MinPrice       *float64   `db:"min_price" json:"min_price"`
+	CostBasis      *float64   `db:"cost_basis" json:"cost_basis"`
+	Margin         *float64   `db:"-" json:"margin"`
+	MarginPercent  *float64   `db:"-" json:"margin_percent"`
+	PriceUpdatedAt *time.Time `db:"price_updated_at" json:"price_updated_at"`
+}
+
+// CarPriceChange is one row of a car's price history.
+type CarPriceChange struct {
+	ID        int64     `db:"id" json:"id"`
+	CarID     int64     `db:"car_id" json:"car_id"`
+	ListPrice float64   `db:"list_price" json:"list_price"`
+	MinPrice  *float64  `db:"min_price" json:"min_price"`
+	Reason    *string   `db:"reason" json:"reason"`
+	ChangedBy *int64    `db:"changed_by" json:"changed_by"`
+	ChangedAt time.Time `db:"changed_at" json:"changed_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Car statuses, as defined by car_status_enum.
+const (
+	CarStatusAvailable = "available"
+	CarStatusReserved  = "reserved"
+	CarStatusSold      = "sold"
+	CarStatusDamaged   = "damaged"
+	CarStatusLost      = "lost"
+	CarStatusStolen    = "stolen"
+)
+
+var CarStatuses = []string{CarStatusAvailable, CarStatusReserved, CarStatusSold, CarStatusDamaged, CarStatusLost, CarStatusStolen}
+
+// CarStatusChange is one row of a car's status history. FromStatus is nil for the status a
+// car was created with.
+type CarStatusChange struct {
+	ID         int64     `db:"id" json:"id"`
+	CarID      int64     `db:"car_id" json:"car_id"`
+	FromStatus *string   `db:"from_status" json:"from_status"`
+	ToStatus   string    `db:"to_status" json:"to_status"`
+	Reason     *string   `db:"reason" json:"reason"`
+	ChangedBy  *int64    `db:"changed_by" json:"changed_by"`
+	ChangedAt  time.Time `db:"changed_at" json:"changed_at"`
+}
@@ -2,9 +2,11 @@ package models
 
 import "time"
 
+// Document is a file attached to a car, or to an order for generated invoices.
 type Document struct {
 	ID           int64     `db:"id" json:"id"`
-	CarID        int64     `db:"car_id" json:"car_id"`
+	CarID        *int64    `db:"car_id" json:"car_id"`
+	OrderID      *int64    `db:"order_id" json:"order_id"`
 	DocumentType string    `db:"document_type" json:"document_type"`
 	FileName     string    `db:"file_name" json:"file_name"`
 	FilePath     string    `db:"file_path" json:"file_path"`
@@ -17,3 +19,8 @@ type Document struct {
 	CreatedAt    time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
 }
+
+// Document types.
+const (
+	DocumentTypeInvoice = "invoice"
+)
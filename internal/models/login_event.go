@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Login event outcomes stored in login_events.outcome.
+const (
+	// LoginOutcomePending marks an attempt whose credentials are still being checked; it
+	// counts as a failure for the lockout until it is finished.
+	LoginOutcomePending         = "pending"
+	LoginOutcomeSuccess         = "success"
+	LoginOutcomeInvalidPassword = "invalid_password"
+	LoginOutcomeUnknownUser     = "unknown_user"
+	LoginOutcomeInactive        = "inactive"
+	LoginOutcomeLockedOut       = "locked_out"
+)
+
+type LoginEvent struct {
+	ID        int64     `db:"id" json:"id"`
+	UserID    *int64    `db:"user_id" json:"user_id"`
+	Username  string    `db:"username" json:"username"`
+	IPAddress *string   `db:"ip_address" json:"ip_address"`
+	UserAgent *string   `db:"user_agent" json:"user_agent"`
+	Outcome   string    `db:"outcome" json:"outcome"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
 
 // RAGChunk is a single indexed document chunk with its embedding.
 type RAGChunk struct {
@@ -11,3 +15,17 @@ type RAGChunk struct {
 	Metadata   string    `db:"metadata" json:"metadata"` // JSON string
 	CreatedAt  time.Time `db:"created_at" json:"created_at"`
 }
+
+// RAGQuery is a logged question with the chunks retrieved for it and the user's rating of the answer.
+type RAGQuery struct {
+	ID              int64         `db:"id" json:"id"`
+	UserID          *int64        `db:"user_id" json:"user_id"`
+	Query           string        `db:"query" json:"query"`
+	ChunkIDs        pq.Int64Array `db:"chunk_ids" json:"chunk_ids"`
+	Answer          *string       `db:"answer" json:"answer"`
+	LatencyMS       int           `db:"latency_ms" json:"latency_ms"`
+	Feedback        *int16        `db:"feedback" json:"feedback"` // 1 = thumbs up, -1 = thumbs down
+	FeedbackComment *string       `db:"feedback_comment" json:"feedback_comment"`
+	FeedbackAt      *time.Time    `db:"feedback_at" json:"feedback_at"`
+	CreatedAt       time.Time     `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Amounts in reports are in BDT. A car is in stock while it is available, reserved or
+// damaged; it is sold by an approved, shipped or delivered order, on the day the order was
+// placed. Its stock-in date is the date of the first LC it is on, or the day it was created.
+// Sales, receivables, costs and LC dates come from materialized views, so they are as of the
+// views' last refresh; car statuses, locations and prices are live.
+
+// StockAgingBucket counts the cars in stock for between MinDays and MaxDays days (no upper
+// bound when MaxDays is nil) and what they are listed at and cost.
+type StockAgingBucket struct {
+	Label     string  `json:"label"`
+	MinDays   int     `json:"min_days"`
+	MaxDays   *int    `json:"max_days"`
+	Cars      int64   `json:"cars"`
+	ListValue float64 `json:"list_value"`
+	CostValue float64 `json:"cost_value"`
+}
+
+// SellThroughRow compares the cars of a group sold in a period with those still in stock.
+// Rate is Sold / (Sold + InStock).
+type SellThroughRow struct {
+	Group   string  `db:"grp" json:"group"`
+	Sold    int64   `db:"sold" json:"sold"`
+	InStock int64   `db:"in_stock" json:"in_stock"`
+	Rate    float64 `db:"-" json:"rate"`
+}
+
+// MonthlyRevenue is what the cars sold in a month were sold for and cost. Margin and
+// MarginPercent only cover the cars whose cost is known; CarsWithoutCost are left out of them.
+type MonthlyRevenue struct {
+	Month           time.Time `db:"month" json:"month"`
+	Orders          int64     `db:"orders" json:"orders"`
+	CarsSold        int64     `db:"cars_sold" json:"cars_sold"`
+	Revenue         float64   `db:"revenue" json:"revenue"`
+	Cost            float64   `db:"cost" json:"cost"`
+	CostedRevenue   float64   `db:"costed_revenue" json:"-"`
+	Margin          float64   `db:"-" json:"margin"`
+	MarginPercent   *float64  `db:"-" json:"margin_percent"`
+	CarsWithoutCost int64     `db:"cars_without_cost" json:"cars_without_cost"`
+}
+
+// Receivable is a customer purchase not yet paid in full by its installments.
+type Receivable struct {
+	PaymentID       int64      `db:"payment_id" json:"payment_id"`
+	CarID           *int64     `db:"car_id" json:"car_id"`
+	CustomerName    *string    `db:"customer_name" json:"customer_name"`
+	ShowroomName    *string    `db:"showroom_name" json:"showroom_name"`
+	PurchaseDate    *time.Time `db:"purchase_date" json:"purchase_date"`
+	Amount          float64    `db:"amount" json:"amount"`
+	Paid            float64    `db:"paid" json:"paid"`
+	Outstanding     float64    `db:"outstanding" json:"outstanding"`
+	LastPaymentDate *time.Time `db:"last_payment_date" json:"last_payment_date"`
+}
+
+// StockValueRow is the value of the cars in stock at a location.
+type StockValueRow struct {
+	Location         *string `db:"location" json:"location"`
+	Cars             int64   `db:"cars" json:"cars"`
+	ListValue        float64 `db:"list_value" json:"list_value"`
+	CostValue        float64 `db:"cost_value" json:"cost_value"`
+	CarsWithoutPrice int64   `db:"cars_without_price" json:"cars_without_price"`
+	CarsWithoutCost  int64   `db:"cars_without_cost" json:"cars_without_cost"`
+}
+
+// ReportViewRefresh is the last refresh of one of the materialized views the reports read.
+// FailedAt and LastError describe the most recent failed refresh, if any.
+type ReportViewRefresh struct {
+	View        string     `db:"view_name" json:"view"`
+	RefreshedAt *time.Time `db:"refreshed_at" json:"refreshed_at"`
+	DurationMS  *int64     `db:"duration_ms" json:"duration_ms"`
+	FailedAt    *time.Time `db:"failed_at" json:"failed_at"`
+	LastError   *string    `db:"last_error" json:"last_error"`
+}
@@ -2,6 +2,9 @@ package models
 
 import "time"
 
+// RoleSlugAdmin is the role that must always keep at least one active member.
+const RoleSlugAdmin = "admin"
+
 type Role struct {
 	ID          int64     `db:"id" json:"id"`
 	Name        string    `db:"name" json:"name"`
@@ -25,4 +28,5 @@ type PermissionRole struct {
 	PermissionID int64     `db:"permission_id" json:"permission_id"`
 	RoleID       int64     `db:"role_id" json:"role_id"`
 	AssignedAt   time.Time `db:"assigned_at" json:"assigned_at"`
+	AssignedBy   *int64    `db:"assigned_by" json:"assigned_by"`
 }
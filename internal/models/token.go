@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type RefreshToken struct {
+	ID              int64      `db:"id" json:"id"`
+	UserID          int64      `db:"user_id" json:"user_id"`
+	TokenHash       string     `db:"token_hash" json:"-"`
+	FamilyID        string     `db:"family_id" json:"family_id"`
+	AccessJTI       string     `db:"access_jti" json:"-"`
+	AccessExpiresAt time.Time  `db:"access_expires_at" json:"-"`
+	ExpiresAt       time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt       *time.Time `db:"revoked_at" json:"revoked_at"`
+	ReplacedBy      *int64     `db:"replaced_by" json:"replaced_by"`
+	UserAgent       *string    `db:"user_agent" json:"user_agent"`
+	IPAddress       *string    `db:"ip_address" json:"ip_address"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID        int64      `db:"id" json:"id"`
+	UserID    int64      `db:"user_id" json:"user_id"`
+	TokenHash string     `db:"token_hash" json:"-"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `db:"used_at" json:"used_at"`
+	CreatedBy *int64     `db:"created_by" json:"created_by"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
@@ -10,11 +10,15 @@ type User struct {
 	PasswordHash string     `db:"password_hash" json:"-"`
 	IsActive     bool       `db:"is_active" json:"is_active"`
 	LastLoginAt  *time.Time `db:"last_login_at" json:"last_login_at"`
-	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
-	CreatedBy    *int64     `db:"created_by" json:"created_by"`
-	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
-	UpdatedBy    *int64     `db:"updated_by" json:"updated_by"`
-	DeletedAt    *time.Time `db:"deleted_at" json:"deleted_at"`
+	// PermissionsVersion is bumped by database triggers whenever the user's roles or their roles' permissions change.
+	PermissionsVersion int64      `db:"permissions_version" json:"permissions_version"`
+	CreatedAt          time.Time  `db:"created_at" json:"created_at"`
+	CreatedBy          *int64     `db:"created_by" json:"created_by"`
+	UpdatedAt          time.Time  `db:"updated_at" json:"updated_at"`
+	UpdatedBy          *int64     `db:"updated_by" json:"updated_by"`
+	DeletedAt          *time.Time `db:"deleted_at" json:"deleted_at"`
+	// Version is bumped whenever an editable field changes and served as the ETag.
+	Version int64 `db:"version" json:"version"`
 }
 
 type RoleUser struct {
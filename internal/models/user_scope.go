@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Data scope types. A location scope limits cars by cars.location; a showroom scope limits
+// payment records by payment_history.showroom_name.
+const (
+	ScopeTypeLocation = "location"
+	ScopeTypeShowroom = "showroom"
+)
+
+type UserScope struct {
+	ID         int64     `db:"id" json:"id"`
+	UserID     int64     `db:"user_id" json:"user_id"`
+	ScopeType  string    `db:"scope_type" json:"scope_type"`
+	Value      string    `db:"value" json:"value"`
+	AssignedBy *int64    `db:"assigned_by" json:"assigned_by"`
+	AssignedAt time.Time `db:"assigned_at" json:"assigned_at"`
+}
@@ -0,0 +1,80 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoders for AddImage
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// maxImagePixels bounds the size of images accepted by AddImage.
+const maxImagePixels = 25_000_000
+
+// Image is a picture added to a document, which can be drawn on any of its pages.
+type Image struct {
+	id            int
+	Width, Height int
+}
+
+// AddImage writes a JPEG, PNG or GIF image to the document. JPEGs are embedded as they are;
+// other images are converted to RGB, with transparency drawn over white.
+func (d *Document) AddImage(data []byte) (*Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, errors.New("image is empty or too large")
+	}
+	img := &Image{id: d.next, Width: cfg.Width, Height: cfg.Height}
+	d.next++
+	dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /BitsPerComponent 8", cfg.Width, cfg.Height)
+
+	if format == "jpeg" && (cfg.ColorModel == color.YCbCrModel || cfg.ColorModel == color.GrayModel) {
+		space := "/DeviceRGB"
+		if cfg.ColorModel == color.GrayModel {
+			space = "/DeviceGray"
+		}
+		d.stream(img.id, dict+" /ColorSpace "+space+" /Filter /DCTDecode", data)
+		return img, d.err
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	b := decoded.Bounds()
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	row := make([]byte, 0, 3*b.Dx())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row = row[:0]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := decoded.At(x, y).RGBA()
+			// Composite premultiplied colour over a white background.
+			white := 0xffff - a
+			row = append(row, byte((r+white)>>8), byte((g+white)>>8), byte((bl+white)>>8))
+		}
+		zw.Write(row)
+	}
+	zw.Close()
+	d.stream(img.id, dict+" /ColorSpace /DeviceRGB /Filter /FlateDecode", z.Bytes())
+	return img, d.err
+}
+
+// DrawImage draws img scaled to w by h points with its top-left corner at (x, y).
+func (p *Page) DrawImage(img *Image, x, y, w, h float64) {
+	used := false
+	for _, id := range p.images {
+		used = used || id == img.id
+	}
+	if !used {
+		p.images = append(p.images, img.id)
+	}
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(w), num(h), num(x), num(p.doc.height-y-h), img.id)
+}
@@ -0,0 +1,298 @@
+// Package pdf writes simple PDF documents: pages of text in the standard Helvetica fonts,
+// lines, filled rectangles and images. It covers printable sheets such as stock lists and
+// invoices; there are no embedded fonts or text outside the Windows-1252 character set.
+//
+// Coordinates are in points (1/72 inch) measured from the top-left corner of the page, and
+// y is the baseline of text. Each page is written out when the next one is started, so
+// long documents are streamed rather than built in memory.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Page sizes in points.
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Font is one of the standard fonts every PDF viewer provides.
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+// Object numbers reserved for the catalog, the page tree and the fonts; pages and their
+// contents are numbered after them as they are written.
+const (
+	catalogObj = 1
+	pagesObj   = 2
+	fontObj    = 3 // HelveticaBold is fontObj+1
+	firstFree  = 5
+)
+
+// Document is a PDF being written to an io.Writer.
+type Document struct {
+	w       *countingWriter
+	width   float64
+	height  float64
+	title   string
+	offsets map[int]int64
+	next    int
+	pages   []int
+	page    *Page
+	err     error
+}
+
+// Page is the page currently being drawn. It is only valid until the next AddPage or Close.
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+	images  []int
+}
+
+// New starts a document whose pages are width by height points.
+func New(w io.Writer, width, height float64) *Document {
+	d := &Document{w: &countingWriter{w: w}, width: width, height: height, offsets: map[int]int64{}, next: firstFree}
+	d.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	for i, name := range []string{"Helvetica", "Helvetica-Bold"} {
+		d.object(fontObj+i, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+	return d
+}
+
+// SetTitle sets the title shown by PDF viewers.
+func (d *Document) SetTitle(title string) {
+	d.title = title
+}
+
+// Width and Height return the page size in points.
+func (d *Document) Width() float64  { return d.width }
+func (d *Document) Height() float64 { return d.height }
+
+// AddPage finishes the current page, if any, and starts a new one.
+func (d *Document) AddPage() *Page {
+	d.flushPage()
+	d.page = &Page{doc: d}
+	return d.page
+}
+
+// Close finishes the document and returns the first error encountered while writing it.
+// It does not close the underlying io.Writer.
+func (d *Document) Close() error {
+	if d.page == nil {
+		d.AddPage()
+	}
+	d.flushPage()
+
+	kids := make([]string, len(d.pages))
+	for i, id := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	d.object(pagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		strings.Join(kids, " "), len(d.pages), num(d.width), num(d.height)))
+	d.object(catalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+	info := d.next
+	d.object(info, fmt.Sprintf("<< /Title %s /Producer (car-project) /CreationDate (D:%s) >>",
+		literal(d.title), time.Now().UTC().Format("20060102150405Z")))
+
+	xref := d.w.n
+	size := info + 1
+	d.printf("xref\n0 %d\n0000000000 65535 f \n", size)
+	for i := 1; i < size; i++ {
+		d.printf("%010d 00000 n \n", d.offsets[i])
+	}
+	d.printf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, catalogObj, info, xref)
+	return d.err
+}
+
+// flushPage writes the current page and its compressed content stream.
+func (d *Document) flushPage() {
+	if d.page == nil {
+		return
+	}
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(d.page.content.Bytes())
+	zw.Close()
+
+	contents, page := d.next, d.next+1
+	d.next += 2
+	d.stream(contents, "/Filter /FlateDecode", z.Bytes())
+	xobjects := ""
+	for _, id := range d.page.images {
+		xobjects += fmt.Sprintf(" /Im%d %d 0 R", id, id)
+	}
+	if xobjects != "" {
+		xobjects = " /XObject <<" + xobjects + " >>"
+	}
+	d.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >>%s >> /Contents %d 0 R >>",
+		pagesObj, fontObj, fontObj+1, xobjects, contents))
+	d.pages = append(d.pages, page)
+	d.page = nil
+}
+
+func (d *Document) object(id int, body string) {
+	if id >= d.next {
+		d.next = id + 1
+	}
+	d.offsets[id] = d.w.n
+	d.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (d *Document) stream(id int, dict string, data []byte) {
+	d.object(id, fmt.Sprintf("<< /Length %d %s >>\nstream\n%s\nendstream", len(data), dict, data))
+}
+
+func (d *Document) printf(format string, args ...interface{}) {
+	if d.err == nil {
+		_, d.err = fmt.Fprintf(d.w, format, args...)
+	}
+}
+
+// Text draws s with its baseline starting at (x, y).
+func (p *Page) Text(x, y float64, font Font, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td %s Tj ET\n",
+		font+1, num(size), num(x), num(p.doc.height-y), literal(s))
+}
+
+// TextRight draws s with its baseline ending at (x, y).
+func (p *Page) TextRight(x, y float64, font Font, size float64, s string) {
+	p.Text(x-TextWidth(font, size, s), y, font, size, s)
+}
+
+// Line draws a line of the given width in black.
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n",
+		num(width), num(x1), num(p.doc.height-y1), num(x2), num(p.doc.height-y2))
+}
+
+// FillRect fills a rectangle whose top-left corner is (x, y) with a shade of gray, from 0
+// (black) to 1 (white).
+func (p *Page) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(&p.content, "q %s g %s %s %s %s re f Q\n",
+		num(gray), num(x), num(p.doc.height-y-h), num(w), num(h))
+}
+
+// TextWidth returns the width of s in points when drawn in font at size.
+func TextWidth(font Font, size float64, s string) float64 {
+	widths := &helveticaWidths
+	if font == HelveticaBold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, r := range s {
+		if r >= 32 && r <= 126 {
+			total += widths[r-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Truncate shortens s with an ellipsis so that it fits in width points.
+func Truncate(font Font, size float64, s string, width float64) string {
+	if TextWidth(font, size, s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && TextWidth(font, size, string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// literal encodes s as a PDF string in WinAnsiEncoding; characters it cannot represent
+// become "?".
+func literal(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		c, ok := winAnsi(r)
+		if !ok {
+			c = '?'
+		}
+		switch c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			if c < 32 || c > 126 {
+				fmt.Fprintf(&b, "\\%03o", c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// winAnsiSpecials are the characters Windows-1252 places in 0x80-0x9F.
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+func winAnsi(r rune) (byte, bool) {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return ' ', true
+	case r >= 32 && r <= 126, r >= 0xA0 && r <= 0xFF:
+		return byte(r), true
+	}
+	c, ok := winAnsiSpecials[r]
+	return c, ok
+}
+
+// num formats a coordinate with at most two decimals.
+func num(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Advance widths of the printable ASCII characters (32-126), in 1/1000 of the font size,
+// from the Adobe font metrics of the standard fonts.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,123 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	var buf bytes.Buffer
+	doc := New(&buf, A4Width, A4Height)
+	doc.SetTitle("Stock (daily)")
+	doc.AddPage().Text(36, 50, HelveticaBold, 16, "Page one")
+	p := doc.AddPage()
+	p.Text(36, 50, Helvetica, 10, `Café (a\b) €5`)
+	p.Line(36, 60, 200, 60, 1)
+	if err := doc.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	out := buf.Bytes()
+
+	t.Run("XrefOffsetsPointAtObjects", func(t *testing.T) {
+		start := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+		if start == nil {
+			t.Fatalf("Expected a trailer, got %q", out[len(out)-60:])
+		}
+		xref, _ := strconv.Atoi(string(start[1]))
+		if !bytes.HasPrefix(out[xref:], []byte("xref\n")) {
+			t.Fatalf("Expected xref at offset %d", xref)
+		}
+		lines := strings.Split(string(out[xref:]), "\n")
+		for i, line := range lines[3:] {
+			if !strings.HasSuffix(line, " n ") {
+				break
+			}
+			offset, _ := strconv.Atoi(line[:10])
+			if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(out[offset:], []byte(want)) {
+				t.Errorf("Expected %q at offset %d", want, offset)
+			}
+		}
+	})
+
+	t.Run("WritesPagesAndText", func(t *testing.T) {
+		if !bytes.Contains(out, []byte("/Count 2")) {
+			t.Errorf("Expected 2 pages")
+		}
+		if !bytes.Contains(out, []byte(`/Title (Stock \(daily\))`)) {
+			t.Errorf("Expected an escaped title")
+		}
+		var text strings.Builder
+		for _, m := range regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(out, -1) {
+			zr, err := zlib.NewReader(bytes.NewReader(m[1]))
+			if err != nil {
+				t.Fatalf("Expected a Flate stream, got %v", err)
+			}
+			b, _ := io.ReadAll(zr)
+			text.Write(b)
+		}
+		if !strings.Contains(text.String(), `(Caf\351 \(a\\b\) \2005) Tj`) {
+			t.Errorf("Expected WinAnsi-encoded text, got %q", text.String())
+		}
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	if s := Truncate(Helvetica, 10, "Short", 100); s != "Short" {
+		t.Errorf("Expected Short, got %q", s)
+	}
+	s := Truncate(Helvetica, 10, "Toyota Land Cruiser Prado TX-L", 60)
+	if !strings.HasSuffix(s, "…") || TextWidth(Helvetica, 10, s) > 60 {
+		t.Errorf("Expected a truncated string within 60pt, got %q", s)
+	}
+}
+
+func TestImages(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	var pngData, jpegData bytes.Buffer
+	png.Encode(&pngData, img)
+	jpeg.Encode(&jpegData, img, nil)
+
+	var buf bytes.Buffer
+	doc := New(&buf, A4Width, A4Height)
+	p := doc.AddPage()
+	pngImg, err := doc.AddImage(pngData.Bytes())
+	if err != nil {
+		t.Fatalf("Expected no error for a PNG, got %v", err)
+	}
+	jpegImg, err := doc.AddImage(jpegData.Bytes())
+	if err != nil {
+		t.Fatalf("Expected no error for a JPEG, got %v", err)
+	}
+	if pngImg.Width != 4 || pngImg.Height != 2 {
+		t.Errorf("Expected a 4x2 image, got %dx%d", pngImg.Width, pngImg.Height)
+	}
+	p.DrawImage(pngImg, 36, 36, 40, 20)
+	p.DrawImage(pngImg, 36, 80, 40, 20)
+	p.DrawImage(jpegImg, 36, 120, 40, 20)
+	if _, err := doc.AddImage([]byte("not an image")); err == nil {
+		t.Errorf("Expected an error for invalid data")
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "/Filter /DCTDecode") {
+		t.Errorf("Expected the JPEG to be embedded as is")
+	}
+	want := fmt.Sprintf("/XObject << /Im%d %d 0 R /Im%d %d 0 R >>", pngImg.id, pngImg.id, jpegImg.id, jpegImg.id)
+	if !strings.Contains(out, want) {
+		t.Errorf("Expected each image once in the page resources: %s", want)
+	}
+}
@@ -0,0 +1,88 @@
+package rag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// cachingEmbedder is an Embedder decorator that stores embeddings in Postgres keyed by
+// model and content hash, so re-indexing unchanged text does not call the provider again.
+type cachingEmbedder struct {
+	next  Embedder
+	repo  repository.RAGRepository
+	model string
+}
+
+// NewCachingEmbedder wraps next with a Postgres-backed embedding cache.
+// model must identify the embedding model used by next; it is part of the cache key.
+func NewCachingEmbedder(next Embedder, repo repository.RAGRepository, model string) Embedder {
+	return &cachingEmbedder{next: next, repo: repo, model: model}
+}
+
+func (e *cachingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vecs, err := e.EmbedBatch(ctx, []string{text})
+	if err != nil || len(vecs) == 0 {
+		return nil, err
+	}
+	return vecs[0], nil
+}
+
+func (e *cachingEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	hashes := make([]string, len(texts))
+	for i, t := range texts {
+		hashes[i] = contentHash(t)
+	}
+
+	// A cache read failure degrades to calling the provider for everything.
+	cached, err := e.repo.GetCachedEmbeddings(ctx, e.model, hashes)
+	if err != nil {
+		utils.GetLogger().Printf("rag: embedding cache read failed: %v", err)
+		cached = nil
+	}
+
+	out := make([][]float32, len(texts))
+	var missIdx []int
+	var missTexts []string
+	for i, h := range hashes {
+		if s, ok := cached[h]; ok {
+			if vec, err := ParseVectorFromPG(s); err == nil {
+				out[i] = vec
+				continue
+			}
+		}
+		missIdx = append(missIdx, i)
+		missTexts = append(missTexts, texts[i])
+	}
+	if len(missTexts) == 0 {
+		return out, nil
+	}
+
+	vecs, err := e.next.EmbedBatch(ctx, missTexts)
+	if err != nil {
+		return nil, err
+	}
+	if len(vecs) != len(missTexts) {
+		return nil, fmt.Errorf("embedder returned %d vectors for %d texts", len(vecs), len(missTexts))
+	}
+
+	fresh := make(map[string]string, len(vecs))
+	for j, vec := range vecs {
+		i := missIdx[j]
+		out[i] = vec
+		fresh[hashes[i]] = FormatVectorForPG(vec)
+	}
+	if err := e.repo.PutCachedEmbeddings(ctx, e.model, fresh); err != nil {
+		utils.GetLogger().Printf("rag: embedding cache write failed: %v", err)
+	}
+	return out, nil
+}
+
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
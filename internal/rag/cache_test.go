@@ -0,0 +1,132 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/user/car-project/internal/repository"
+)
+
+// fakeCacheRepository keeps the embedding cache in memory, keyed by model and content hash.
+type fakeCacheRepository struct {
+	repository.RAGRepository
+	entries map[[2]string]string
+	getErr  error
+}
+
+func (r *fakeCacheRepository) GetCachedEmbeddings(ctx context.Context, model string, hashes []string) (map[string]string, error) {
+	if r.getErr != nil {
+		return nil, r.getErr
+	}
+	out := make(map[string]string)
+	for _, h := range hashes {
+		if s, ok := r.entries[[2]string{model, h}]; ok {
+			out[h] = s
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeCacheRepository) PutCachedEmbeddings(ctx context.Context, model string, embeddings map[string]string) error {
+	for h, s := range embeddings {
+		r.entries[[2]string{model, h}] = s
+	}
+	return nil
+}
+
+func TestCachingEmbedder(t *testing.T) {
+	const model = "text-embedding-3-small"
+	key := func(model, text string) [2]string { return [2]string{model, contentHash(text)} }
+
+	tests := []struct {
+		name        string
+		cached      map[[2]string]string
+		getErr      error
+		texts       []string
+		want        [][]float32
+		wantBatches []int
+		wantCached  map[[2]string]string
+	}{
+		{
+			name:        "Miss",
+			texts:       []string{"a", "b"},
+			want:        [][]float32{{0}, {1}},
+			wantBatches: []int{2},
+			wantCached:  map[[2]string]string{key(model, "a"): "[0]", key(model, "b"): "[1]"},
+		},
+		{
+			name:       "Hit",
+			cached:     map[[2]string]string{key(model, "a"): "[7]", key(model, "b"): "[8]"},
+			texts:      []string{"a", "b"},
+			want:       [][]float32{{7}, {8}},
+			wantCached: map[[2]string]string{key(model, "a"): "[7]", key(model, "b"): "[8]"},
+		},
+		{
+			name:        "PartialHitEmbedsOnlyMisses",
+			cached:      map[[2]string]string{key(model, "a"): "[7]"},
+			texts:       []string{"a", "b"},
+			want:        [][]float32{{7}, {0}},
+			wantBatches: []int{1},
+			wantCached:  map[[2]string]string{key(model, "a"): "[7]", key(model, "b"): "[0]"},
+		},
+		{
+			name:        "ModelIsPartOfKey",
+			cached:      map[[2]string]string{key("other-model", "a"): "[7]"},
+			texts:       []string{"a"},
+			want:        [][]float32{{0}},
+			wantBatches: []int{1},
+			wantCached:  map[[2]string]string{key("other-model", "a"): "[7]", key(model, "a"): "[0]"},
+		},
+		{
+			name:        "KeyIsExactContent",
+			cached:      map[[2]string]string{key(model, "a"): "[7]"},
+			texts:       []string{"a "},
+			want:        [][]float32{{0}},
+			wantBatches: []int{1},
+			wantCached:  map[[2]string]string{key(model, "a"): "[7]", key(model, "a "): "[0]"},
+		},
+		{
+			name:        "UnreadableEntryIsReplaced",
+			cached:      map[[2]string]string{key(model, "a"): "not a vector"},
+			texts:       []string{"a"},
+			want:        [][]float32{{0}},
+			wantBatches: []int{1},
+			wantCached:  map[[2]string]string{key(model, "a"): "[0]"},
+		},
+		{
+			name:        "ReadFailureEmbedsEverything",
+			cached:      map[[2]string]string{key(model, "a"): "[7]"},
+			getErr:      errors.New("connection refused"),
+			texts:       []string{"a"},
+			want:        [][]float32{{0}},
+			wantBatches: []int{1},
+			wantCached:  map[[2]string]string{key(model, "a"): "[0]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeCacheRepository{entries: map[[2]string]string{}, getErr: tt.getErr}
+			for k, v := range tt.cached {
+				repo.entries[k] = v
+			}
+			fake := &fakeEmbedder{}
+
+			got, err := NewCachingEmbedder(fake, repo, model).EmbedBatch(context.Background(), tt.texts)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected embeddings %v, got %v", tt.want, got)
+			}
+			if !reflect.DeepEqual(fake.batches, tt.wantBatches) {
+				t.Errorf("Expected provider batches %v, got %v", tt.wantBatches, fake.batches)
+			}
+			if !reflect.DeepEqual(repo.entries, tt.wantCached) {
+				t.Errorf("Expected cache %v, got %v", tt.wantCached, repo.entries)
+			}
+		})
+	}
+}
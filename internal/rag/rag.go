@@ -2,12 +2,18 @@ package rag
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/user/car-project/internal/models"
 	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
 )
 
 const (
@@ -16,15 +22,20 @@ const (
 
 // AskResult is the response from RAG Ask.
 type AskResult struct {
-	Answer   string   `json:"answer"`
-	Sources  []Source `json:"sources,omitempty"`
+	QueryID int64    `json:"query_id,omitempty"`
+	Answer  string   `json:"answer"`
+	Sources []Source `json:"sources,omitempty"`
 }
 
-// Source references a chunk that was used.
+// Source references a chunk that was used. Marker is the number the chunk was
+// given in the prompt, so an inline "[2]" in the answer refers to the source with Marker 2.
 type Source struct {
+	Marker     int    `json:"marker"`
+	ChunkID    int64  `json:"chunk_id"`
 	SourceType string `json:"source_type"`
 	SourceID   string `json:"source_id"`
 	Content    string `json:"content"`
+	Cited      bool   `json:"cited"`
 }
 
 // RAG orchestrates retrieval and generation.
@@ -43,12 +54,17 @@ func NewRAG(embedder Embedder, llm LLM, repo repository.RAGRepository, topK int)
 	return &RAG{embedder: embedder, llm: llm, repo: repo, topK: topK}
 }
 
+const systemPrompt = `You are a helpful assistant for a car dealership/inventory system. Answer the user's question using ONLY the provided context. If the context does not contain enough information, say so. Be concise and factual. Do not make up car details or inventory.
+Each context entry starts with a bracketed number such as [1]. After every claim, cite the entries that support it using those numbers, e.g. "The car is red [2]." or "[1][3]". Never cite a number that is not in the context.`
+
 // Ask runs retrieval-augmented generation: embed query -> search -> generate answer.
-func (r *RAG) Ask(ctx context.Context, query string) (*AskResult, error) {
+// The question, retrieved chunk IDs, answer and latency are logged to rag_queries for userID.
+func (r *RAG) Ask(ctx context.Context, userID int64, query string) (*AskResult, error) {
 	query = strings.TrimSpace(query)
 	if query == "" {
 		return &AskResult{Answer: "Please provide a question."}, nil
 	}
+	started := time.Now()
 
 	emb, err := r.embedder.Embed(ctx, query)
 	if err != nil {
@@ -62,10 +78,15 @@ func (r *RAG) Ask(ctx context.Context, query string) (*AskResult, error) {
 	}
 
 	sources := make([]Source, 0, len(chunks))
+	chunkIDs := make([]int64, 0, len(chunks))
 	var contextParts []string
-	for _, c := range chunks {
-		contextParts = append(contextParts, c.Content)
+	for i, c := range chunks {
+		marker := i + 1
+		contextParts = append(contextParts, fmt.Sprintf("[%d] (%s %s)\n%s", marker, c.SourceType, c.SourceID, c.Content))
+		chunkIDs = append(chunkIDs, c.ID)
 		sources = append(sources, Source{
+			Marker:     marker,
+			ChunkID:    c.ID,
 			SourceType: c.SourceType,
 			SourceID:   c.SourceID,
 			Content:    truncate(c.Content, 200),
@@ -77,7 +98,6 @@ func (r *RAG) Ask(ctx context.Context, query string) (*AskResult, error) {
 		contextBlob = "No relevant documents found in the knowledge base."
 	}
 
-	systemPrompt := `You are a helpful assistant for a car dealership/inventory system. Answer the user's question using ONLY the provided context. If the context does not contain enough information, say so. Be concise and factual. Do not make up car details or inventory.`
 	userMessage := fmt.Sprintf("Context:\n%s\n\nQuestion: %s", contextBlob, query)
 
 	answer, err := r.llm.Complete(ctx, systemPrompt, userMessage)
@@ -85,10 +105,45 @@ func (r *RAG) Ask(ctx context.Context, query string) (*AskResult, error) {
 		return nil, fmt.Errorf("generate: %w", err)
 	}
 
-	return &AskResult{
+	for _, m := range parseCitations(answer, len(sources)) {
+		sources[m-1].Cited = true
+	}
+
+	result := &AskResult{
 		Answer:  answer,
 		Sources: sources,
-	}, nil
+	}
+
+	// Logging is best effort: a failed insert should not cost the user their answer.
+	logged := &models.RAGQuery{
+		Query:     query,
+		ChunkIDs:  chunkIDs,
+		Answer:    &answer,
+		LatencyMS: int(time.Since(started).Milliseconds()),
+	}
+	if userID > 0 {
+		logged.UserID = &userID
+	}
+	if err := r.repo.LogQuery(ctx, logged); err != nil {
+		utils.GetLogger().Printf("rag: failed to log query: %v", err)
+	} else {
+		result.QueryID = logged.ID
+	}
+
+	return result, nil
+}
+
+// Feedback records a thumbs up (true) or down (false) from userID on a logged query.
+func (r *RAG) Feedback(ctx context.Context, queryID, userID int64, helpful bool, comment *string) error {
+	var rating int16 = -1
+	if helpful {
+		rating = 1
+	}
+	err := r.repo.SetQueryFeedback(ctx, queryID, userID, rating, comment)
+	if errors.Is(err, sql.ErrNoRows) {
+		return utils.ErrNotFound
+	}
+	return err
 }
 
 // IndexCars loads all car content from the DB, embeds it, and upserts into rag_chunks.
@@ -98,36 +153,47 @@ func (r *RAG) IndexCars(ctx context.Context) (indexed int, err error) {
 		return 0, fmt.Errorf("get cars content: %w", err)
 	}
 
-	if err := r.repo.DeleteAllBySourceType(ctx, SourceTypeCar); err != nil {
-		return 0, fmt.Errorf("clear existing car chunks: %w", err)
-	}
-
 	const maxChunkSize = 1500
 	var chunks []repository.RAGChunkInput
+	var texts []string
 	for _, row := range rows {
 		if strings.TrimSpace(row.Content) == "" {
 			continue
 		}
 		content := normalizeSpace(row.Content)
 		sourceID := strconv.FormatInt(row.CarID, 10)
-		parts := splitIntoChunks(content, maxChunkSize)
-		for _, part := range parts {
-			emb, err := r.embedder.Embed(ctx, part)
-			if err != nil {
-				return 0, fmt.Errorf("embed car %s: %w", sourceID, err)
-			}
-			meta, _ := json.Marshal(map[string]interface{}{"car_id": row.CarID})
+		meta, _ := json.Marshal(map[string]interface{}{"car_id": row.CarID})
+		for _, part := range splitIntoChunks(content, maxChunkSize) {
 			chunks = append(chunks, repository.RAGChunkInput{
 				SourceType: SourceTypeCar,
 				SourceID:   sourceID,
 				Content:    part,
-				Embedding:  FormatVectorForPG(emb),
 				Metadata:   string(meta),
 			})
+			texts = append(texts, part)
 		}
 		indexed++
 	}
 
+	// Embed everything in one call; the embedder decorators handle caching, batching and rate limits.
+	if len(texts) > 0 {
+		vecs, err := r.embedder.EmbedBatch(ctx, texts)
+		if err != nil {
+			return 0, fmt.Errorf("embed cars: %w", err)
+		}
+		if len(vecs) != len(chunks) {
+			return 0, fmt.Errorf("embed cars: got %d vectors for %d chunks", len(vecs), len(chunks))
+		}
+		for i := range chunks {
+			chunks[i].Embedding = FormatVectorForPG(vecs[i])
+		}
+	}
+
+	// Clear only once embedding succeeded, so a provider failure leaves the old index intact.
+	if err := r.repo.DeleteAllBySourceType(ctx, SourceTypeCar); err != nil {
+		return 0, fmt.Errorf("clear existing car chunks: %w", err)
+	}
+
 	const batchSize = 20
 	for i := 0; i < len(chunks); i += batchSize {
 		end := i + batchSize
@@ -173,3 +239,21 @@ func splitIntoChunks(s string, maxSize int) []string {
 	}
 	return out
 }
+
+var citationPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// parseCitations returns the distinct citation markers found in answer, in order of first
+// appearance. Markers outside 1..numSources are ignored.
+func parseCitations(answer string, numSources int) []int {
+	var out []int
+	seen := make(map[int]bool)
+	for _, m := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n < 1 || n > numSources || seen[n] {
+			continue
+		}
+		seen[n] = true
+		out = append(out, n)
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package rag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCitations(t *testing.T) {
+	tests := []struct {
+		name       string
+		answer     string
+		numSources int
+		want       []int
+	}{
+		{"NoCitations", "The car is red.", 3, nil},
+		{"OrderOfAppearance", "It is red [2] and has 4 seats [1][2].", 3, []int{2, 1}},
+		{"OutOfRange", "See [0], [4] and [3].", 3, []int{3}},
+		{"NoSources", "Nothing found [1].", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseCitations(tt.answer, tt.numSources)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCitations(%q, %d) = %v, want %v", tt.answer, tt.numSources, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ResilienceConfig controls retries, batching and rate limiting of provider calls.
+type ResilienceConfig struct {
+	MaxRetries      int           // retries after the first attempt on 429/5xx responses
+	BaseDelay       time.Duration // first backoff delay; doubles on every retry
+	MaxDelay        time.Duration // upper bound for a single backoff delay
+	MaxBatchSize    int           // max texts per EmbedBatch call to the provider
+	TokensPerMinute int           // approximate token budget per minute; 0 disables limiting
+}
+
+// DefaultResilienceConfig returns conservative defaults for the OpenAI API.
+func DefaultResilienceConfig() ResilienceConfig {
+	return ResilienceConfig{
+		MaxRetries:   5,
+		BaseDelay:    500 * time.Millisecond,
+		MaxDelay:     30 * time.Second,
+		MaxBatchSize: 100,
+	}
+}
+
+type resilience struct {
+	cfg     ResilienceConfig
+	limiter *tokenLimiter
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+func newResilience(cfg ResilienceConfig) *resilience {
+	def := DefaultResilienceConfig()
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = def.BaseDelay
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = def.MaxDelay
+	}
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = def.MaxBatchSize
+	}
+	r := &resilience{cfg: cfg, sleep: sleepContext}
+	if cfg.TokensPerMinute > 0 {
+		r.limiter = newTokenLimiter(cfg.TokensPerMinute)
+	}
+	return r
+}
+
+// do waits for tokens, then runs fn, retrying retryable errors with exponential backoff and full jitter.
+func (r *resilience) do(ctx context.Context, tokens int, fn func() error) error {
+	if r.limiter != nil {
+		if err := r.limiter.wait(ctx, tokens, r.sleep); err != nil {
+			return err
+		}
+	}
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = fn()
+		if err == nil || !isRetryable(err) || attempt >= r.cfg.MaxRetries {
+			return err
+		}
+		if err := r.sleep(ctx, r.backoff(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *resilience) backoff(attempt int) time.Duration {
+	d := r.cfg.BaseDelay << attempt
+	if d <= 0 || d > r.cfg.MaxDelay {
+		d = r.cfg.MaxDelay
+	}
+	return time.Duration(rand.Int64N(int64(d)) + 1)
+}
+
+// isRetryable reports whether err is a rate-limit or server-side error from the provider.
+func isRetryable(err error) bool {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return retryableStatus(reqErr.HTTPStatusCode)
+	}
+	return false
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// estimateTokens approximates OpenAI token usage (~4 characters per token).
+func estimateTokens(texts ...string) int {
+	n := 0
+	for _, t := range texts {
+		n += len(t)/4 + 1
+	}
+	return n
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// tokenLimiter is a token bucket refilled continuously at perMinute tokens per minute.
+type tokenLimiter struct {
+	mu        sync.Mutex
+	perMinute float64
+	available float64
+	last      time.Time
+	now       func() time.Time
+}
+
+func newTokenLimiter(perMinute int) *tokenLimiter {
+	return &tokenLimiter{
+		perMinute: float64(perMinute),
+		available: float64(perMinute),
+		last:      time.Now(),
+		now:       time.Now,
+	}
+}
+
+// reserve takes n tokens and returns how long the caller must wait before using them.
+// Requests larger than the whole budget are clamped so they can still proceed.
+func (l *tokenLimiter) reserve(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.available += now.Sub(l.last).Minutes() * l.perMinute
+	if l.available > l.perMinute {
+		l.available = l.perMinute
+	}
+	l.last = now
+
+	need := float64(n)
+	if need > l.perMinute {
+		need = l.perMinute
+	}
+	l.available -= need
+	if l.available >= 0 {
+		return 0
+	}
+	return time.Duration(-l.available / l.perMinute * float64(time.Minute))
+}
+
+func (l *tokenLimiter) wait(ctx context.Context, n int, sleep func(context.Context, time.Duration) error) error {
+	if d := l.reserve(n); d > 0 {
+		return sleep(ctx, d)
+	}
+	return nil
+}
+
+// resilientEmbedder splits batches, rate limits and retries calls to the wrapped Embedder.
+type resilientEmbedder struct {
+	next Embedder
+	r    *resilience
+}
+
+// NewResilientEmbedder wraps next with backoff on 429/5xx, a max batch size and token-per-minute limiting.
+func NewResilientEmbedder(next Embedder, cfg ResilienceConfig) Embedder {
+	return &resilientEmbedder{next: next, r: newResilience(cfg)}
+}
+
+func (e *resilientEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vecs, err := e.EmbedBatch(ctx, []string{text})
+	if err != nil || len(vecs) == 0 {
+		return nil, err
+	}
+	return vecs[0], nil
+}
+
+func (e *resilientEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, 0, len(texts))
+	for i := 0; i < len(texts); i += e.r.cfg.MaxBatchSize {
+		end := i + e.r.cfg.MaxBatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch := texts[i:end]
+		var vecs [][]float32
+		err := e.r.do(ctx, estimateTokens(batch...), func() error {
+			var err error
+			vecs, err = e.next.EmbedBatch(ctx, batch)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, vecs...)
+	}
+	return out, nil
+}
+
+// resilientLLM rate limits and retries calls to the wrapped LLM.
+type resilientLLM struct {
+	next LLM
+	r    *resilience
+}
+
+// NewResilientLLM wraps next with backoff on 429/5xx and token-per-minute limiting.
+func NewResilientLLM(next LLM, cfg ResilienceConfig) LLM {
+	return &resilientLLM{next: next, r: newResilience(cfg)}
+}
+
+func (l *resilientLLM) Complete(ctx context.Context, systemPrompt, userMessage string) (string, error) {
+	var out string
+	err := l.r.do(ctx, estimateTokens(systemPrompt, userMessage), func() error {
+		var err error
+		out, err = l.next.Complete(ctx, systemPrompt, userMessage)
+		return err
+	})
+	return out, err
+}
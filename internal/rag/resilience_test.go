@@ -0,0 +1,119 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type fakeEmbedder struct {
+	failures int // number of calls that return err before succeeding
+	err      error
+	batches  []int
+}
+
+func (f *fakeEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vecs, err := f.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vecs[0], nil
+}
+
+func (f *fakeEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if f.failures > 0 {
+		f.failures--
+		return nil, f.err
+	}
+	f.batches = append(f.batches, len(texts))
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i] = []float32{float32(i)}
+	}
+	return out, nil
+}
+
+func newTestEmbedder(next Embedder, cfg ResilienceConfig) (*resilientEmbedder, *[]time.Duration) {
+	e := NewResilientEmbedder(next, cfg).(*resilientEmbedder)
+	var slept []time.Duration
+	e.r.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	return e, &slept
+}
+
+func TestResilientEmbedderRetriesRateLimit(t *testing.T) {
+	fake := &fakeEmbedder{failures: 2, err: &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}}
+	e, slept := newTestEmbedder(fake, ResilienceConfig{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second})
+
+	if _, err := e.EmbedBatch(context.Background(), []string{"a"}); err != nil {
+		t.Fatalf("Expected success after retries, got %v", err)
+	}
+	if len(*slept) != 2 {
+		t.Errorf("Expected 2 backoff sleeps, got %d", len(*slept))
+	}
+}
+
+func TestResilientEmbedderGivesUp(t *testing.T) {
+	t.Run("NonRetryable", func(t *testing.T) {
+		fake := &fakeEmbedder{failures: 1, err: errors.New("bad request")}
+		e, slept := newTestEmbedder(fake, ResilienceConfig{MaxRetries: 3})
+		if _, err := e.EmbedBatch(context.Background(), []string{"a"}); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+		if len(*slept) != 0 {
+			t.Errorf("Expected no retries, got %d", len(*slept))
+		}
+	})
+
+	t.Run("RetriesExhausted", func(t *testing.T) {
+		fake := &fakeEmbedder{failures: 5, err: &openai.RequestError{HTTPStatusCode: http.StatusBadGateway}}
+		e, slept := newTestEmbedder(fake, ResilienceConfig{MaxRetries: 2})
+		if _, err := e.EmbedBatch(context.Background(), []string{"a"}); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+		if len(*slept) != 2 {
+			t.Errorf("Expected 2 retries, got %d", len(*slept))
+		}
+	})
+}
+
+func TestResilientEmbedderSplitsBatches(t *testing.T) {
+	fake := &fakeEmbedder{}
+	e, _ := newTestEmbedder(fake, ResilienceConfig{MaxBatchSize: 2})
+
+	vecs, err := e.EmbedBatch(context.Background(), []string{"a", "b", "c", "d", "e"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(vecs) != 5 {
+		t.Errorf("Expected 5 vectors, got %d", len(vecs))
+	}
+	if want := []int{2, 2, 1}; len(fake.batches) != len(want) || fake.batches[0] != 2 || fake.batches[2] != 1 {
+		t.Errorf("Expected batches %v, got %v", want, fake.batches)
+	}
+}
+
+func TestTokenLimiterReserve(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newTokenLimiter(600)
+	l.now = func() time.Time { return now }
+	l.last = now
+
+	if d := l.reserve(600); d != 0 {
+		t.Errorf("Expected full budget to be available, got wait %v", d)
+	}
+	// Budget is empty; 10 more tokens at 600/min need one second.
+	if d := l.reserve(10); d != time.Second {
+		t.Errorf("Expected 1s wait, got %v", d)
+	}
+	now = now.Add(time.Minute)
+	if d := l.reserve(100); d != 0 {
+		t.Errorf("Expected budget refilled after a minute, got wait %v", d)
+	}
+}
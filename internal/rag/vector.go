@@ -2,6 +2,7 @@ package rag
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -18,3 +19,25 @@ func FormatVectorForPG(embedding []float32) string {
 	b.WriteString("]")
 	return b.String()
 }
+
+// ParseVectorFromPG converts a pgvector string "[a,b,c,...]" back to a float32 slice.
+func ParseVectorFromPG(s string) ([]float32, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return nil, fmt.Errorf("invalid vector %q", s)
+	}
+	s = s[1 : len(s)-1]
+	if s == "" {
+		return []float32{}, nil
+	}
+	parts := strings.Split(s, ",")
+	out := make([]float32, len(parts))
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector element %q: %w", p, err)
+		}
+		out[i] = float32(v)
+	}
+	return out, nil
+}
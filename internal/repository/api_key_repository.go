@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/user/car-project/internal/models"
+)
+
+type APIKeyRepository interface {
+	// Create inserts the key's service user, assigns it the key's role and stores the key, atomically.
+	Create(ctx context.Context, key *models.APIKey, user *models.User) error
+	GetAll(ctx context.Context) ([]models.APIKey, error)
+	GetByID(ctx context.Context, id int64) (*models.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	// Revoke marks the key revoked and deactivates its service user; it reports whether a live key was revoked.
+	Revoke(ctx context.Context, id int64) (bool, error)
+	TouchLastUsed(ctx context.Context, id int64) error
+}
+
+type apiKeyRepository struct {
+	DB *sqlx.DB
+}
+
+func NewAPIKeyRepository(db *sqlx.DB) APIKeyRepository {
+	return &apiKeyRepository{DB: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey, user *models.User) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowxContext(ctx,
+		`INSERT INTO users (name, username, email, password_hash, is_active, created_by, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, TRUE, $5, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		 RETURNING id, created_at, updated_at`,
+		user.Name, user.Username, user.Email, user.PasswordHash, key.CreatedBy,
+	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	key.UserID = user.ID
+
+	if err := auditRow(ctx, tx, auditedRow{models.AuditCreate, models.AuditEntityUser, "users", &user.ID}, nil); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO role_user (user_id, role_id, assigned_by) VALUES ($1, $2, $3)",
+		user.ID, key.RoleID, key.CreatedBy,
+	); err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, models.AuditGrant, models.AuditEntityUser, user.ID, nil, map[string]interface{}{"role_id": key.RoleID}); err != nil {
+		return err
+	}
+
+	err = tx.QueryRowxContext(ctx,
+		`INSERT INTO api_keys (name, key_prefix, key_hash, user_id, role_id, allowed_ips, expires_at, created_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id, created_at`,
+		key.Name, key.KeyPrefix, key.KeyHash, key.UserID, key.RoleID, key.AllowedIPs, key.ExpiresAt, key.CreatedBy,
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if err := auditRow(ctx, tx, auditedRow{models.AuditCreate, models.AuditEntityAPIKey, "api_keys", &key.ID}, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *apiKeyRepository) GetAll(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.DB.SelectContext(ctx, &keys, "SELECT * FROM api_keys ORDER BY id")
+	return keys, err
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id int64) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.DB.GetContext(ctx, &key, "SELECT * FROM api_keys WHERE id = $1", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByHash returns nil when no key has the hash or its service user is inactive or deleted,
+// so such keys stop authenticating at once.
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.DB.GetContext(ctx, &key,
+		`SELECT k.* FROM api_keys k JOIN users u ON u.id = k.user_id
+		 WHERE k.key_hash = $1 AND u.is_active AND u.deleted_at IS NULL`, hash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id int64) (bool, error) {
+	var revoked bool
+	err := withAudit(ctx, r.DB, auditedRow{models.AuditUpdate, models.AuditEntityAPIKey, "api_keys", &id}, func(tx *sqlx.Tx) error {
+		var userID int64
+		err := tx.QueryRowxContext(ctx,
+			`UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL RETURNING user_id`,
+			id,
+		).Scan(&userID)
+		if err != nil {
+			return err
+		}
+
+		row := auditedRow{models.AuditUpdate, models.AuditEntityUser, "users", &userID}
+		before, err := snapshotRow(ctx, tx, row.table, userID, true)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE users SET is_active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = $1", userID,
+		); err != nil {
+			return err
+		}
+		revoked = true
+		return auditRow(ctx, tx, row, before)
+	})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return revoked, err
+}
+
+// TouchLastUsed records use of the key, at most once a minute to keep writes off the hot path.
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id int64) error {
+	_, err := r.DB.ExecContext(ctx,
+		`UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+		 WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')`,
+		id,
+	)
+	return err
+}
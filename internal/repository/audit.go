@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/user/car-project/internal/models"
+)
+
+// AuditActor identifies who made a change and from where. Middleware stores it in the
+// request context; repositories copy it into every audit_log row they write. Changes made
+// without one (seeding, background jobs) are still audited, with an empty actor.
+type AuditActor struct {
+	UserID   *int64
+	APIKeyID *int64
+	TrackID  string
+	IP       string
+}
+
+type auditActorContextKey struct{}
+
+// WithAuditActor returns a copy of ctx carrying a.
+func WithAuditActor(ctx context.Context, a AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorContextKey{}, a)
+}
+
+// AuditActorFromContext returns the actor stored by WithAuditActor, if any.
+func AuditActorFromContext(ctx context.Context) (AuditActor, bool) {
+	a, ok := ctx.Value(auditActorContextKey{}).(AuditActor)
+	return a, ok
+}
+
+// auditRedacted replaces the values of these columns in audit_log.
+var auditRedacted = map[string]bool{"password_hash": true, "key_hash": true}
+
+// auditIgnored columns change on every update and would only add noise to diffs.
+var auditIgnored = map[string]bool{"updated_at": true, "version": true}
+
+// auditedRow describes a mutation of one row of table, recorded by withAudit.
+type auditedRow struct {
+	action     string
+	entityType string
+	table      string
+	id         *int64 // for creates, set by fn
+}
+
+// withAudit runs fn in a transaction and records the row's before and after state in
+// audit_log within the same transaction, so the change and its audit entry commit together.
+func withAudit(ctx context.Context, db *sqlx.DB, row auditedRow, fn func(tx *sqlx.Tx) error) error {
+	return inTx(ctx, db, func(tx *sqlx.Tx) error {
+		var before map[string]interface{}
+		if row.action != models.AuditCreate {
+			var err error
+			if before, err = snapshotRow(ctx, tx, row.table, *row.id, true); err != nil {
+				return err
+			}
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return auditRow(ctx, tx, row, before)
+	})
+}
+
+// inTx runs fn in a transaction, committing only if it succeeds. Mutations that are not a
+// single-row change use it and call recordAudit themselves.
+func inTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// auditRow snapshots the row after a change and records it against before: the whole row
+// for creates and deletes, only the changed columns for updates.
+func auditRow(ctx context.Context, tx *sqlx.Tx, row auditedRow, before map[string]interface{}) error {
+	after, err := snapshotRow(ctx, tx, row.table, *row.id, false)
+	if err != nil {
+		return err
+	}
+
+	var b, a map[string]interface{}
+	switch row.action {
+	case models.AuditCreate:
+		a = redact(after)
+	case models.AuditDelete:
+		b = redact(before)
+	default:
+		b, a = diffRows(before, after)
+	}
+	return recordAudit(ctx, tx, row.action, row.entityType, *row.id, b, a)
+}
+
+// snapshotRow returns the row as a JSON object, or nil if it does not exist. table must be a constant.
+func snapshotRow(ctx context.Context, tx *sqlx.Tx, table string, id int64, lock bool) (map[string]interface{}, error) {
+	query := "SELECT row_to_json(t) FROM " + table + " t WHERE id = $1"
+	if lock {
+		query += " FOR UPDATE"
+	}
+	var raw []byte
+	rows, err := tx.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	if err := rows.Scan(&raw); err != nil {
+		return nil, err
+	}
+	var row map[string]interface{}
+	if err := json.Unmarshal(raw, &row); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// recordAudit inserts one audit_log row attributed to the actor in ctx. Nil before/after are stored as NULL.
+func recordAudit(ctx context.Context, tx *sqlx.Tx, action, entityType string, entityID int64, before, after map[string]interface{}) error {
+	actor, _ := AuditActorFromContext(ctx)
+	b, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	a, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO audit_log (actor_user_id, actor_api_key_id, action, entity_type, entity_id, before, after, track_id, ip_address)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''))`,
+		actor.UserID, actor.APIKeyID, action, entityType, entityID, b, a, actor.TrackID, actor.IP,
+	)
+	return err
+}
+
+func auditJSON(m map[string]interface{}) (interface{}, error) {
+	if m == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// diffRows returns only the columns whose values differ between before and after.
+func diffRows(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	b := map[string]interface{}{}
+	a := map[string]interface{}{}
+	for k, v := range after {
+		if auditIgnored[k] {
+			continue
+		}
+		if old, ok := before[k]; !ok || !reflect.DeepEqual(old, v) {
+			b[k] = old
+			a[k] = v
+		}
+	}
+	return redact(b), redact(a)
+}
+
+func redact(row map[string]interface{}) map[string]interface{} {
+	for k := range row {
+		if auditRedacted[k] {
+			row[k] = "[redacted]"
+		}
+	}
+	return row
+}
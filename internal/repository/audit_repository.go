@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/user/car-project/internal/models"
+)
+
+// AuditFilter narrows an audit_log query; zero-valued fields are ignored.
+type AuditFilter struct {
+	EntityType  string
+	EntityID    *int64
+	ActorUserID *int64
+	From        *time.Time
+	To          *time.Time
+	Limit       int
+	Offset      int
+}
+
+type AuditRepository interface {
+	// List returns matching entries, newest first, and the total number of matches.
+	List(ctx context.Context, filter AuditFilter) ([]models.AuditLog, int64, error)
+}
+
+type auditRepository struct {
+	DB *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) AuditRepository {
+	return &auditRepository{DB: db}
+}
+
+func (r *auditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditLog, int64, error) {
+	var conds []string
+	var args []interface{}
+	if filter.EntityType != "" {
+		conds = append(conds, "entity_type = ?")
+		args = append(args, filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		conds = append(conds, "entity_id = ?")
+		args = append(args, *filter.EntityID)
+	}
+	if filter.ActorUserID != nil {
+		conds = append(conds, "actor_user_id = ?")
+		args = append(args, *filter.ActorUserID)
+	}
+	if filter.From != nil {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		conds = append(conds, "created_at < ?")
+		args = append(args, *filter.To)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int64
+	if err := r.DB.GetContext(ctx, &total, r.DB.Rebind("SELECT COUNT(*) FROM audit_log"+where), args...); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT id, actor_user_id, actor_api_key_id, action, entity_type, entity_id,
+			  COALESCE(before, 'null'::jsonb) AS before, COALESCE(after, 'null'::jsonb) AS after,
+			  track_id, ip_address, created_at
+			  FROM audit_log` + where + ` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`
+	var entries []models.AuditLog
+	err := r.DB.SelectContext(ctx, &entries, r.DB.Rebind(query), append(args, filter.Limit, filter.Offset)...)
+	return entries, total, err
+}
@@ -0,0 +1,19 @@
+package repository
+
+import "testing"
+
+func TestDiffRows(t *testing.T) {
+	before := map[string]interface{}{"id": 1.0, "name": "Old", "email": "a@example.com", "password_hash": "x", "updated_at": "t1"}
+	after := map[string]interface{}{"id": 1.0, "name": "New", "email": "a@example.com", "password_hash": "y", "updated_at": "t2"}
+
+	b, a := diffRows(before, after)
+	if len(a) != 2 || a["name"] != "New" || b["name"] != "Old" {
+		t.Errorf("Expected only name and password_hash to differ, got before=%v after=%v", b, a)
+	}
+	if a["password_hash"] != "[redacted]" || b["password_hash"] != "[redacted]" {
+		t.Errorf("Expected password_hash to be redacted, got before=%v after=%v", b["password_hash"], a["password_hash"])
+	}
+	if _, ok := a["updated_at"]; ok {
+		t.Error("Expected updated_at to be ignored")
+	}
+}
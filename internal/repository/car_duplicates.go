@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/user/car-project/internal/models"
+)
+
+// IdentifierKey is how ref_no, chassis_no_full and engine_number are compared when looking
+// for duplicates: upper-cased, with everything but letters and digits removed, so that
+// "ab-123", "AB 123" and "AB123" are the same. It matches identifierKeySQL and the
+// expression indexes created for it.
+func IdentifierKey(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+// identifierKeySQL is IdentifierKey of column, as indexed by migration 000017.
+func identifierKeySQL(column string) string {
+	return "UPPER(REGEXP_REPLACE(" + column + ", '[^[:alnum:]]', '', 'g'))"
+}
+
+// CarMergeCounts is how many rows a merge moved onto the surviving car.
+type CarMergeCounts struct {
+	Photos    int64 `json:"photos"`
+	Documents int64 `json:"documents"`
+	LCLinks   int64 `json:"lc_links"`
+}
+
+func (r *carRepository) DuplicateCandidates(ctx context.Context, refNos, chassisNos, engineNumbers []string) ([]models.Car, error) {
+	query := "SELECT * FROM cars WHERE deleted_at IS NULL AND (" +
+		identifierKeySQL("ref_no") + " = ANY($1) OR " +
+		identifierKeySQL("chassis_no_full") + " = ANY($2) OR " +
+		identifierKeySQL("engine_number") + " = ANY($3)) ORDER BY id"
+	var cars []models.Car
+	err := r.DB.SelectContext(ctx, &cars, query, pq.Array(refNos), pq.Array(chassisNos), pq.Array(engineNumbers))
+	return cars, err
+}
+
+// Merge moves the duplicate's photos, documents and LC links to the survivor and moves the
+// duplicate to the trash, in one transaction. Moved photos and documents are placed after
+// the survivor's own and only stay primary if the survivor has no primary one. Where both
+// cars are on the same LC, the duplicate's purchase records and LC documents are moved to
+// the survivor's link. It returns sql.ErrNoRows unless both cars exist, are not in the trash
+// and are within the caller's scope.
+func (r *carRepository) Merge(ctx context.Context, survivorID, duplicateID int64) (*CarMergeCounts, error) {
+	cond, args := scopeCondition(ctx, "location", scopeLocations)
+	var counts CarMergeCounts
+	err := inTx(ctx, r.DB, func(tx *sqlx.Tx) error {
+		// Lock both cars in id order, so concurrent merges of the same pair cannot deadlock.
+		var locked []int64
+		if err := tx.SelectContext(ctx, &locked,
+			tx.Rebind("SELECT id FROM cars WHERE id IN (?, ?) AND deleted_at IS NULL"+cond+" ORDER BY id FOR UPDATE"),
+			append([]interface{}{survivorID, duplicateID}, args...)...); err != nil {
+			return err
+		}
+		if len(locked) != 2 {
+			return sql.ErrNoRows
+		}
+		before, err := snapshotRow(ctx, tx, "cars", duplicateID, false)
+		if err != nil {
+			return err
+		}
+
+		for _, move := range []struct {
+			table string
+			count *int64
+		}{{"car_photos", &counts.Photos}, {"documents", &counts.Documents}} {
+			res, err := tx.ExecContext(ctx,
+				`UPDATE `+move.table+` SET car_id = $1,
+				   is_primary = is_primary AND NOT EXISTS (SELECT 1 FROM `+move.table+` s WHERE s.car_id = $1 AND s.is_primary),
+				   sort_order = sort_order + (SELECT COALESCE(MAX(s.sort_order) + 1, 0) FROM `+move.table+` s WHERE s.car_id = $1),
+				   updated_at = CURRENT_TIMESTAMP
+				 WHERE car_id = $2`, survivorID, duplicateID)
+			if err != nil {
+				return err
+			}
+			if *move.count, err = res.RowsAffected(); err != nil {
+				return err
+			}
+		}
+
+		// A car is on an LC at most once: fold links to LCs the survivor is already on into its own.
+		for _, table := range []string{"purchase_history", "lc_purchase_documents"} {
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE `+table+` t SET lc_car_id = s.id, updated_at = CURRENT_TIMESTAMP
+				 FROM lc_cars d JOIN lc_cars s ON s.lc_id = d.lc_id AND s.car_id = $1
+				 WHERE t.lc_car_id = d.id AND d.car_id = $2`, survivorID, duplicateID); err != nil {
+				return err
+			}
+		}
+		res, err := tx.ExecContext(ctx,
+			`DELETE FROM lc_cars d WHERE d.car_id = $2
+			 AND EXISTS (SELECT 1 FROM lc_cars s WHERE s.lc_id = d.lc_id AND s.car_id = $1)`, survivorID, duplicateID)
+		if err != nil {
+			return err
+		}
+		folded, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		res, err = tx.ExecContext(ctx,
+			"UPDATE lc_cars SET car_id = $1, updated_at = CURRENT_TIMESTAMP WHERE car_id = $2", survivorID, duplicateID)
+		if err != nil {
+			return err
+		}
+		moved, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		counts.LCLinks = folded + moved
+
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE cars SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1", duplicateID); err != nil {
+			return err
+		}
+		if err := auditRow(ctx, tx, auditedRow{models.AuditDelete, models.AuditEntityCar, "cars", &duplicateID}, before); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditMerge, models.AuditEntityCar, survivorID, nil, map[string]interface{}{
+			"merged_car_id": duplicateID,
+			"photos":        counts.Photos,
+			"documents":     counts.Documents,
+			"lc_links":      counts.LCLinks,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &counts, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/user/car-project/internal/models"
+)
+
+// costBasisSQL is the landed cost of the car in carColumn: the sum of total_bdt, govt_duty,
+// cnf_amount and miscellaneous over its purchase records on every LC it is on, or NULL if it
+// has none.
+func costBasisSQL(carColumn string) string {
+	return `(SELECT SUM(COALESCE(ph.total_bdt, 0) + COALESCE(ph.govt_duty, 0) + COALESCE(ph.cnf_amount, 0) + COALESCE(ph.miscellaneous, 0))
+	 FROM purchase_history ph JOIN lc_cars lc ON lc.id = ph.lc_car_id WHERE lc.car_id = ` + carColumn + `)`
+}
+
+// Pricing returns the car's prices and cost basis; Margin and MarginPercent are left to the
+// caller. It returns sql.ErrNoRows for a car that does not exist, is in the trash or is
+// outside the caller's scope.
+func (r *carRepository) Pricing(ctx context.Context, id int64) (*models.CarPricing, error) {
+	cond, args := scopeCondition(ctx, "c.location", scopeLocations)
+	var pricing models.CarPricing
+	err := r.DB.GetContext(ctx, &pricing, r.DB.Rebind(
+		`SELECT p.list_price, p.min_price, p.updated_at AS price_updated_at, `+costBasisSQL("c.id")+` AS cost_basis
+		 FROM cars c LEFT JOIN car_prices p ON p.car_id = c.id
+		 WHERE c.id = ? AND c.deleted_at IS NULL`+cond),
+		append([]interface{}{id}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &pricing, nil
+}
+
+func (r *carRepository) SetPrice(ctx context.Context, id, version int64, listPrice float64, minPrice *float64, reason *string) (*models.Car, error) {
+	cond, args := scopeCondition(ctx, "location", scopeLocations)
+	actor, _ := AuditActorFromContext(ctx)
+	var car models.Car
+	err := inTx(ctx, r.DB, func(tx *sqlx.Tx) error {
+		var current int64
+		if err := tx.GetContext(ctx, &current,
+			tx.Rebind("SELECT version FROM cars WHERE id = ? AND deleted_at IS NULL"+cond+" FOR UPDATE"),
+			append([]interface{}{id}, args...)...); err != nil {
+			return err
+		}
+		if current != version {
+			return ErrVersionConflict
+		}
+
+		var before map[string]interface{}
+		var old struct {
+			ListPrice float64  `db:"list_price"`
+			MinPrice  *float64 `db:"min_price"`
+		}
+		err := tx.GetContext(ctx, &old, "SELECT list_price, min_price FROM car_prices WHERE car_id = $1", id)
+		switch {
+		case err == nil:
+			before = map[string]interface{}{"list_price": old.ListPrice, "min_price": old.MinPrice}
+		case err != sql.ErrNoRows:
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO car_prices (car_id, list_price, min_price, updated_by) VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (car_id) DO UPDATE SET list_price = EXCLUDED.list_price, min_price = EXCLUDED.min_price,
+			   updated_by = EXCLUDED.updated_by, updated_at = CURRENT_TIMESTAMP`,
+			id, listPrice, minPrice, actor.UserID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO car_price_history (car_id, list_price, min_price, reason, changed_by)
+			 VALUES ($1, $2, $3, $4, $5)`, id, listPrice, minPrice, reason, actor.UserID); err != nil {
+			return err
+		}
+		// The price is part of the car's representation, so it gets a new version and ETag.
+		if err := tx.GetContext(ctx, &car,
+			"UPDATE cars SET version = version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING *", id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditUpdate, models.AuditEntityCar, id, before,
+			map[string]interface{}{"list_price": listPrice, "min_price": minPrice})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &car, nil
+}
+
+// PriceHistory lists the car's price changes, oldest first. It returns an empty list for a
+// car that does not exist or is outside the caller's scope.
+func (r *carRepository) PriceHistory(ctx context.Context, id int64) ([]models.CarPriceChange, error) {
+	cond, args := scopeCondition(ctx, "c.location", scopeLocations)
+	var changes []models.CarPriceChange
+	err := r.DB.SelectContext(ctx, &changes, r.DB.Rebind(
+		`SELECT h.* FROM car_price_history h JOIN cars c ON c.id = h.car_id
+		 WHERE h.car_id = ? AND c.deleted_at IS NULL`+cond+` ORDER BY h.changed_at, h.id`),
+		append([]interface{}{id}, args...)...)
+	return changes, err
+}
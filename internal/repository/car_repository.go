@@ -1,16 +1,70 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/user/car-project/internal/models"
 )
 
+// CarRepository restricts every query to the locations of the Scope in ctx, if any.
+// Deleted cars stay in the table, marked by deleted_at, until they are purged; only
+// GetDeleted, Restore and Purge see them.
 type CarRepository interface {
-	Create(car *models.Car) error
-	GetAll() ([]models.Car, error)
-	GetByID(id int64) (*models.Car, error)
-	Update(car *models.Car) error
-	Delete(id int64) error
+	Create(ctx context.Context, car *models.Car) error
+	CreateMany(ctx context.Context, cars []models.Car) error
+	ModelNames(ctx context.Context) ([]CarModelName, error)
+	// ModelMake returns the make of a car model; MakeByName finds a make by its name,
+	// ignoring case.
+	ModelMake(ctx context.Context, modelID int64) (*models.CarMake, error)
+	MakeByName(ctx context.Context, name string) (*models.CarMake, error)
+	TakenIdentifiers(ctx context.Context, refNos, chassisNos []string) (map[string]bool, map[string]bool, error)
+	// DuplicateCandidates returns the cars whose ref_no, chassis_no_full or engine_number
+	// matches one of the given IdentifierKey values. Unlike other reads it ignores the Scope
+	// in ctx, because identifiers are unique across all locations; callers must not reveal
+	// more than the id of a car outside the scope (see LocationInScope).
+	DuplicateCandidates(ctx context.Context, refNos, chassisNos, engineNumbers []string) ([]models.Car, error)
+	// UnscopedLocations returns the given locations that no user has in their data scope, so
+	// only holders of data-scope-bypass can see cars there.
+	UnscopedLocations(ctx context.Context, locations []string) ([]string, error)
+	Merge(ctx context.Context, survivorID, duplicateID int64) (*CarMergeCounts, error)
+	// ChangeStatus sets the status of the car at version and records the change in its
+	// status history. allowed is called under a row lock with the current status and whether
+	// the car is on an open order; its error aborts the change.
+	ChangeStatus(ctx context.Context, id, version int64, status string, reason *string, allowed func(from string, openOrder bool) error) (*models.Car, error)
+	StatusHistory(ctx context.Context, id int64) ([]models.CarStatusChange, error)
+	Pricing(ctx context.Context, id int64) (*models.CarPricing, error)
+	// SetPrice sets the list and minimum price of the car at version, records them in its
+	// price history and bumps the car's version.
+	SetPrice(ctx context.Context, id, version int64, listPrice float64, minPrice *float64, reason *string) (*models.Car, error)
+	PriceHistory(ctx context.Context, id int64) ([]models.CarPriceChange, error)
+	GetAll(ctx context.Context, filter CarFilter) ([]models.Car, error)
+	Export(ctx context.Context, filter CarFilter, fn func(*CarExportRow) error) error
+	GetByID(ctx context.Context, id int64) (*models.Car, error)
+	Update(ctx context.Context, car *models.Car) error
+	// Patch writes only the named fields (JSON names) of car, with the same version check
+	// and errors as Update, and reloads car from the updated row. It returns ErrDuplicate
+	// when the new ref_no is taken.
+	Patch(ctx context.Context, car *models.Car, fields []string) error
+	Delete(ctx context.Context, id, version int64) error
+	GetDeleted(ctx context.Context) ([]models.Car, error)
+	Restore(ctx context.Context, id int64) error
+	// Purge permanently removes cars deleted before cutoff and returns how many were removed.
+	Purge(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// CarModelName is a car model and the name of its make.
+type CarModelName struct {
+	ID    int64  `db:"id"`
+	Make  string `db:"make"`
+	Model string `db:"model"`
 }
 
 type carRepository struct {
@@ -21,51 +75,298 @@ func NewCarRepository(db *sqlx.DB) CarRepository {
 	return &carRepository{DB: db}
 }
 
-func (r *carRepository) Create(car *models.Car) error {
-	query := `INSERT INTO cars (model_id, ref_no, package, body_type, year, color, reg_year_month, mileage_km, chassis_no_full, engine_cc, fuel, transmission, drive, engine_number, seats, number_of_keys, keys_feature, steering, location, country_origin, status) 
+const insertCarQuery = `INSERT INTO cars (model_id, ref_no, package, body_type, year, color, reg_year_month, mileage_km, chassis_no_full, engine_cc, fuel, transmission, drive, engine_number, seats, number_of_keys, keys_feature, steering, location, country_origin, status) 
 			  VALUES (:model_id, :ref_no, :package, :body_type, :year, :color, :reg_year_month, :mileage_km, :chassis_no_full, :engine_cc, :fuel, :transmission, :drive, :engine_number, :seats, :number_of_keys, :keys_feature, :steering, :location, :country_origin, :status) 
-			  RETURNING id`
+			  RETURNING id, version`
+
+func (r *carRepository) Create(ctx context.Context, car *models.Car) error {
+	if !inScope(ctx, car.Location, scopeLocations) {
+		return ErrOutOfScope
+	}
+
+	return withAudit(ctx, r.DB, auditedRow{models.AuditCreate, models.AuditEntityCar, "cars", &car.ID}, func(tx *sqlx.Tx) error {
+		return insertCar(ctx, tx, car)
+	})
+}
+
+// CreateMany inserts all cars in one transaction: either every car is stored or none is.
+// It returns ErrDuplicate if a ref_no or chassis number is already taken.
+func (r *carRepository) CreateMany(ctx context.Context, cars []models.Car) error {
+	for i := range cars {
+		if !inScope(ctx, cars[i].Location, scopeLocations) {
+			return ErrOutOfScope
+		}
+	}
+	err := inTx(ctx, r.DB, func(tx *sqlx.Tx) error {
+		for i := range cars {
+			if err := insertCar(ctx, tx, &cars[i]); err != nil {
+				return err
+			}
+			if err := auditRow(ctx, tx, auditedRow{models.AuditCreate, models.AuditEntityCar, "cars", &cars[i].ID}, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return uniqueViolation(err)
+}
 
-	rows, err := r.DB.NamedQuery(query, car)
+// insertCar inserts the car and starts its status history.
+func insertCar(ctx context.Context, tx *sqlx.Tx, car *models.Car) error {
+	rows, err := sqlx.NamedQueryContext(ctx, tx, insertCarQuery, car)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-
 	if rows.Next() {
-		rows.Scan(&car.ID)
+		err = rows.Scan(&car.ID, &car.Version)
 	}
-	return nil
+	rows.Close()
+	if err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if car.Status == nil {
+		return nil
+	}
+	return recordStatusChange(ctx, tx, car.ID, nil, *car.Status, nil)
 }
 
-func (r *carRepository) GetAll() ([]models.Car, error) {
+// ModelNames lists every car model with its make, for resolving names to model IDs.
+func (r *carRepository) ModelNames(ctx context.Context) ([]CarModelName, error) {
+	var names []CarModelName
+	err := r.DB.SelectContext(ctx, &names,
+		`SELECT mo.id, m.name AS make, mo.name AS model
+		 FROM car_models mo JOIN car_makes m ON m.id = mo.make_id
+		 ORDER BY mo.id`)
+	return names, err
+}
+
+func (r *carRepository) ModelMake(ctx context.Context, modelID int64) (*models.CarMake, error) {
+	var carMake models.CarMake
+	err := r.DB.GetContext(ctx, &carMake,
+		"SELECT m.* FROM car_makes m JOIN car_models mo ON mo.make_id = m.id WHERE mo.id = $1", modelID)
+	if err != nil {
+		return nil, err
+	}
+	return &carMake, nil
+}
+
+func (r *carRepository) MakeByName(ctx context.Context, name string) (*models.CarMake, error) {
+	var carMake models.CarMake
+	err := r.DB.GetContext(ctx, &carMake, "SELECT * FROM car_makes WHERE LOWER(name) = LOWER($1) ORDER BY id LIMIT 1", name)
+	if err != nil {
+		return nil, err
+	}
+	return &carMake, nil
+}
+
+// TakenIdentifiers reports which of the given ref_no and chassis_no_full values are already
+// used by another car, matching the unique constraints: ref_no among cars not in the trash,
+// chassis_no_full among all cars. It ignores the caller's scope.
+func (r *carRepository) TakenIdentifiers(ctx context.Context, refNos, chassisNos []string) (map[string]bool, map[string]bool, error) {
+	refs := map[string]bool{}
+	chassis := map[string]bool{}
+	if len(refNos) > 0 {
+		var taken []string
+		if err := r.DB.SelectContext(ctx, &taken,
+			"SELECT ref_no FROM cars WHERE ref_no = ANY($1) AND deleted_at IS NULL", pq.Array(refNos)); err != nil {
+			return nil, nil, err
+		}
+		for _, v := range taken {
+			refs[v] = true
+		}
+	}
+	if len(chassisNos) > 0 {
+		var taken []string
+		if err := r.DB.SelectContext(ctx, &taken,
+			"SELECT chassis_no_full FROM cars WHERE chassis_no_full = ANY($1)", pq.Array(chassisNos)); err != nil {
+			return nil, nil, err
+		}
+		for _, v := range taken {
+			chassis[v] = true
+		}
+	}
+	return refs, chassis, nil
+}
+
+func (r *carRepository) UnscopedLocations(ctx context.Context, locations []string) ([]string, error) {
+	unscoped := []string{}
+	if len(locations) == 0 {
+		return unscoped, nil
+	}
+	err := r.DB.SelectContext(ctx, &unscoped, `
+		SELECT l.location FROM unnest($1::text[]) AS l(location)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM user_scopes us
+			JOIN users u ON u.id = us.user_id
+			WHERE us.scope_type = 'location' AND us.value = l.location AND u.deleted_at IS NULL
+		)
+		ORDER BY l.location`, pq.Array(locations))
+	return unscoped, err
+}
+
+func (r *carRepository) GetAll(ctx context.Context, filter CarFilter) ([]models.Car, error) {
+	cond, args := carFilterCondition(ctx, filter)
 	var cars []models.Car
-	err := r.DB.Select(&cars, "SELECT * FROM cars ORDER BY created_at DESC")
+	err := r.DB.SelectContext(ctx, &cars, r.DB.Rebind("SELECT c.* FROM cars c WHERE c.deleted_at IS NULL"+cond+" ORDER BY c.created_at DESC"), args...)
 	return cars, err
 }
 
-func (r *carRepository) GetByID(id int64) (*models.Car, error) {
+func (r *carRepository) GetByID(ctx context.Context, id int64) (*models.Car, error) {
+	cond, args := scopeCondition(ctx, "location", scopeLocations)
 	var car models.Car
-	err := r.DB.Get(&car, "SELECT * FROM cars WHERE id = $1", id)
+	err := r.DB.GetContext(ctx, &car, r.DB.Rebind("SELECT * FROM cars WHERE id = ? AND deleted_at IS NULL"+cond), append([]interface{}{id}, args...)...)
 	if err != nil {
 		return nil, err
 	}
 	return &car, nil
 }
 
-func (r *carRepository) Update(car *models.Car) error {
+// Update applies only if car.Version is still current and sets it to the new version. The
+// status is left alone; see ChangeStatus. It returns sql.ErrNoRows when the car does not
+// exist or is outside the caller's scope, and ErrVersionConflict when the car has changed
+// since car.Version was read.
+func (r *carRepository) Update(ctx context.Context, car *models.Car) error {
+	if !inScope(ctx, car.Location, scopeLocations) {
+		return ErrOutOfScope
+	}
+
 	query := `UPDATE cars SET model_id=:model_id, ref_no=:ref_no, package=:package, body_type=:body_type, year=:year, color=:color, 
 			  reg_year_month=:reg_year_month, mileage_km=:mileage_km, chassis_no_full=:chassis_no_full, engine_cc=:engine_cc, 
 			  fuel=:fuel, transmission=:transmission, drive=:drive, engine_number=:engine_number, seats=:seats, 
 			  number_of_keys=:number_of_keys, keys_feature=:keys_feature, steering=:steering, location=:location, 
-              country_origin=:country_origin, status=:status, updated_at=CURRENT_TIMESTAMP 
-			  WHERE id=:id`
+              country_origin=:country_origin, updated_at=CURRENT_TIMESTAMP 
+			  WHERE id=:id AND version=:version AND deleted_at IS NULL`
+
+	bound, args, err := sqlx.Named(query, car)
+	if err != nil {
+		return err
+	}
+	cond, scopeArgs := scopeCondition(ctx, "location", scopeLocations)
+	return withAudit(ctx, r.DB, auditedRow{models.AuditUpdate, models.AuditEntityCar, "cars", &car.ID}, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx, tx.Rebind(bound+cond+" RETURNING version"), append(args, scopeArgs...)...).Scan(&car.Version)
+		if err == sql.ErrNoRows {
+			return versionMismatch(ctx, tx, "cars", car.ID, cond, scopeArgs)
+		}
+		return err
+	})
+}
+
+type patchColumn struct {
+	column string
+	index  int
+}
+
+// carPatchColumns maps the JSON name of each patchable models.Car field to its column and
+// field index; models.CarNonPatchableFields are excluded.
+var carPatchColumns = func() map[string]patchColumn {
+	cols := map[string]patchColumn{}
+	t := reflect.TypeOf(models.Car{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		column := f.Tag.Get("db")
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if column == "" || models.CarNonPatchableFields[name] {
+			continue
+		}
+		cols[name] = patchColumn{column, i}
+	}
+	return cols
+}()
+
+func (r *carRepository) Patch(ctx context.Context, car *models.Car, fields []string) error {
+	if !inScope(ctx, car.Location, scopeLocations) {
+		return ErrOutOfScope
+	}
+
+	fields = append([]string(nil), fields...)
+	sort.Strings(fields)
+	value := reflect.ValueOf(car).Elem()
+	sets := make([]string, 0, len(fields)+1)
+	args := make([]interface{}, 0, len(fields)+2)
+	for _, f := range fields {
+		col, ok := carPatchColumns[f]
+		if !ok {
+			return fmt.Errorf("car field %q cannot be patched", f)
+		}
+		sets = append(sets, col.column+" = ?")
+		args = append(args, value.Field(col.index).Interface())
+	}
+	sets = append(sets, "updated_at = CURRENT_TIMESTAMP")
+	args = append(args, car.ID, car.Version)
 
-	_, err := r.DB.NamedExec(query, car)
-	return err
+	cond, scopeArgs := scopeCondition(ctx, "location", scopeLocations)
+	query := "UPDATE cars SET " + strings.Join(sets, ", ") +
+		" WHERE id = ? AND version = ? AND deleted_at IS NULL" + cond + " RETURNING *"
+	err := withAudit(ctx, r.DB, auditedRow{models.AuditUpdate, models.AuditEntityCar, "cars", &car.ID}, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx, tx.Rebind(query), append(args, scopeArgs...)...).StructScan(car)
+		if err == sql.ErrNoRows {
+			return versionMismatch(ctx, tx, "cars", car.ID, cond, scopeArgs)
+		}
+		return err
+	})
+	return uniqueViolation(err)
 }
 
-func (r *carRepository) Delete(id int64) error {
-	_, err := r.DB.Exec("DELETE FROM cars WHERE id = $1", id)
-	return err
+// Delete moves the car to the trash if it is still at version. It returns sql.ErrNoRows when
+// the car does not exist, is already deleted or is outside the caller's scope, and
+// ErrVersionConflict when it has changed since version was read.
+func (r *carRepository) Delete(ctx context.Context, id, version int64) error {
+	cond, args := scopeCondition(ctx, "location", scopeLocations)
+	return withAudit(ctx, r.DB, auditedRow{models.AuditDelete, models.AuditEntityCar, "cars", &id}, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			tx.Rebind("UPDATE cars SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND version = ? AND deleted_at IS NULL"+cond),
+			append([]interface{}{id, version}, args...)...)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(res); err != nil {
+			return versionMismatch(ctx, tx, "cars", id, cond, args)
+		}
+		return nil
+	})
+}
+
+func (r *carRepository) GetDeleted(ctx context.Context) ([]models.Car, error) {
+	cond, args := scopeCondition(ctx, "location", scopeLocations)
+	var cars []models.Car
+	err := r.DB.SelectContext(ctx, &cars, r.DB.Rebind("SELECT * FROM cars WHERE deleted_at IS NOT NULL"+cond+" ORDER BY deleted_at DESC"), args...)
+	return cars, err
+}
+
+// Restore takes the car out of the trash. It returns sql.ErrNoRows when the car is not in the
+// trash or is outside the caller's scope, and ErrDuplicate when another car now has its ref_no.
+func (r *carRepository) Restore(ctx context.Context, id int64) error {
+	cond, args := scopeCondition(ctx, "location", scopeLocations)
+	err := withAudit(ctx, r.DB, auditedRow{models.AuditRestore, models.AuditEntityCar, "cars", &id}, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			tx.Rebind("UPDATE cars SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NOT NULL"+cond),
+			append([]interface{}{id}, args...)...)
+		if err != nil {
+			return err
+		}
+		return requireAffected(res)
+	})
+	return uniqueViolation(err)
+}
+
+// Purge skips cars that appear on an order, since order_items must keep pointing at them.
+func (r *carRepository) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	return purgeRows(ctx, r.DB, models.AuditEntityCar,
+		`DELETE FROM cars WHERE deleted_at < $1
+		 AND NOT EXISTS (SELECT 1 FROM order_items oi WHERE oi.car_id = cars.id)
+		 RETURNING id, row_to_json(cars)`, cutoff)
+}
+
+func requireAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/user/car-project/internal/models"
+)
+
+// openOrderStatuses are the order statuses in which the order's cars are still promised to
+// the buyer.
+const openOrderStatuses = "'pending', 'approved', 'shipped'"
+
+func (r *carRepository) ChangeStatus(ctx context.Context, id, version int64, status string, reason *string, allowed func(from string, openOrder bool) error) (*models.Car, error) {
+	cond, args := scopeCondition(ctx, "location", scopeLocations)
+	var car models.Car
+	err := withAudit(ctx, r.DB, auditedRow{models.AuditUpdate, models.AuditEntityCar, "cars", &id}, func(tx *sqlx.Tx) error {
+		var current struct {
+			Status  *string `db:"status"`
+			Version int64   `db:"version"`
+		}
+		if err := tx.GetContext(ctx, &current,
+			tx.Rebind("SELECT status, version FROM cars WHERE id = ? AND deleted_at IS NULL"+cond+" FOR UPDATE"),
+			append([]interface{}{id}, args...)...); err != nil {
+			return err
+		}
+		if current.Version != version {
+			return ErrVersionConflict
+		}
+		var openOrder bool
+		if err := tx.GetContext(ctx, &openOrder,
+			`SELECT EXISTS (SELECT 1 FROM order_items oi JOIN orders o ON o.id = oi.order_id
+			 WHERE oi.car_id = $1 AND o.status IN (`+openOrderStatuses+`))`, id); err != nil {
+			return err
+		}
+		from := models.CarStatusAvailable
+		if current.Status != nil {
+			from = *current.Status
+		}
+		if err := allowed(from, openOrder); err != nil {
+			return err
+		}
+
+		if err := tx.GetContext(ctx, &car,
+			"UPDATE cars SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 RETURNING *", status, id); err != nil {
+			return err
+		}
+		return recordStatusChange(ctx, tx, id, current.Status, status, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &car, nil
+}
+
+// StatusHistory lists the car's status changes, oldest first. It returns an empty list for a
+// car that does not exist or is outside the caller's scope.
+func (r *carRepository) StatusHistory(ctx context.Context, id int64) ([]models.CarStatusChange, error) {
+	cond, args := scopeCondition(ctx, "c.location", scopeLocations)
+	var changes []models.CarStatusChange
+	err := r.DB.SelectContext(ctx, &changes, r.DB.Rebind(
+		`SELECT h.* FROM car_status_history h JOIN cars c ON c.id = h.car_id
+		 WHERE h.car_id = ? AND c.deleted_at IS NULL`+cond+` ORDER BY h.changed_at, h.id`),
+		append([]interface{}{id}, args...)...)
+	return changes, err
+}
+
+// recordStatusChange appends to the car's status history, attributed to the actor in ctx.
+func recordStatusChange(ctx context.Context, tx *sqlx.Tx, carID int64, from *string, to string, reason *string) error {
+	actor, _ := AuditActorFromContext(ctx)
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO car_status_history (car_id, from_status, to_status, reason, changed_by)
+		 VALUES ($1, $2, $3, $4, $5)`, carID, from, to, reason, actor.UserID)
+	return err
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/user/car-project/internal/models"
+)
+
+// DocumentRepository loads the data of printable documents and stores generated ones. Cars
+// outside the caller's locations are treated as missing, and so are orders with any such car.
+type DocumentRepository interface {
+	SpecSheet(ctx context.Context, carID int64) (*SpecSheetData, error)
+	Invoice(ctx context.Context, orderID int64) (*InvoiceData, error)
+	Create(ctx context.Context, doc *models.Document) error
+	ListByOrder(ctx context.Context, orderID int64, docType string) ([]models.Document, error)
+	GetByOrder(ctx context.Context, orderID, id int64) (*models.Document, error)
+}
+
+// SpecSheetData is a car with everything printed on its spec sheet.
+type SpecSheetData struct {
+	Car        models.Car
+	Make       string
+	Model      string
+	Grade      *models.CarGrade
+	Detail     *models.CarDetail
+	SubDetails []models.CarSubDetail
+	// PhotoURL is the primary visible photo, or the first visible one.
+	PhotoURL *string
+}
+
+// InvoiceData is an order with its cars and buyer.
+type InvoiceData struct {
+	Order models.Order
+	Items []InvoiceItem
+	// Customer is the most recent payment_history row of the order's cars that names a
+	// customer, if any.
+	Customer *models.PaymentHistory
+	// Buyer is the user who placed the order.
+	Buyer models.User
+}
+
+// InvoiceItem is an order item with the car it sells.
+type InvoiceItem struct {
+	models.OrderItem
+	Make      string  `db:"make"`
+	Model     string  `db:"model"`
+	Year      *int16  `db:"year"`
+	RefNo     *string `db:"ref_no"`
+	ChassisNo *string `db:"chassis_no_full"`
+	Location  *string `db:"location"`
+}
+
+type documentRepository struct {
+	DB *sqlx.DB
+}
+
+func NewDocumentRepository(db *sqlx.DB) DocumentRepository {
+	return &documentRepository{DB: db}
+}
+
+func (r *documentRepository) SpecSheet(ctx context.Context, carID int64) (*SpecSheetData, error) {
+	cond, args := scopeCondition(ctx, "c.location", scopeLocations)
+	var row struct {
+		models.Car
+		Make  string `db:"make"`
+		Model string `db:"model"`
+	}
+	err := r.DB.GetContext(ctx, &row, r.DB.Rebind(
+		`SELECT c.*, m.name AS make, mo.name AS model
+		 FROM cars c
+		 JOIN car_models mo ON mo.id = c.model_id
+		 JOIN car_makes m ON m.id = mo.make_id
+		 WHERE c.id = ? AND c.deleted_at IS NULL`+cond), append([]interface{}{carID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	data := &SpecSheetData{Car: row.Car, Make: row.Make, Model: row.Model}
+
+	var grade models.CarGrade
+	switch err := r.DB.GetContext(ctx, &grade, "SELECT * FROM car_grades WHERE car_id = $1", carID); err {
+	case nil:
+		data.Grade = &grade
+	case sql.ErrNoRows:
+	default:
+		return nil, err
+	}
+
+	var detail models.CarDetail
+	switch err := r.DB.GetContext(ctx, &detail, "SELECT * FROM car_details WHERE car_id = $1", carID); err {
+	case nil:
+		data.Detail = &detail
+		if err := r.DB.SelectContext(ctx, &data.SubDetails,
+			"SELECT * FROM car_sub_details WHERE car_detail_id = $1 ORDER BY id", detail.ID); err != nil {
+			return nil, err
+		}
+	case sql.ErrNoRows:
+	default:
+		return nil, err
+	}
+
+	var urls []string
+	if err := r.DB.SelectContext(ctx, &urls,
+		`SELECT url FROM car_photos WHERE car_id = $1 AND NOT COALESCE(is_hidden, FALSE)
+		 ORDER BY is_primary DESC NULLS LAST, sort_order, id LIMIT 1`, carID); err != nil {
+		return nil, err
+	}
+	if len(urls) > 0 {
+		data.PhotoURL = &urls[0]
+	}
+	return data, nil
+}
+
+func (r *documentRepository) Invoice(ctx context.Context, orderID int64) (*InvoiceData, error) {
+	var data InvoiceData
+	if err := r.DB.GetContext(ctx, &data.Order, "SELECT * FROM orders WHERE id = $1", orderID); err != nil {
+		return nil, err
+	}
+	err := r.DB.SelectContext(ctx, &data.Items,
+		`SELECT oi.*, m.name AS make, mo.name AS model, c.year, c.ref_no, c.chassis_no_full, c.location
+		 FROM order_items oi
+		 JOIN cars c ON c.id = oi.car_id
+		 JOIN car_models mo ON mo.id = c.model_id
+		 JOIN car_makes m ON m.id = mo.make_id
+		 WHERE oi.order_id = $1
+		 ORDER BY oi.id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	carIDs := make([]int64, len(data.Items))
+	for i, item := range data.Items {
+		if !inScope(ctx, item.Location, scopeLocations) {
+			return nil, sql.ErrNoRows
+		}
+		carIDs[i] = item.CarID
+	}
+
+	var customer models.PaymentHistory
+	err = r.DB.GetContext(ctx, &customer,
+		`SELECT * FROM payment_history
+		 WHERE car_id = ANY($1) AND customer_name IS NOT NULL
+		 ORDER BY purchase_date DESC NULLS LAST, id DESC LIMIT 1`, pq.Array(carIDs))
+	switch err {
+	case nil:
+		data.Customer = &customer
+	case sql.ErrNoRows:
+	default:
+		return nil, err
+	}
+
+	if err := r.DB.GetContext(ctx, &data.Buyer, "SELECT * FROM users WHERE id = $1", data.Order.UserID); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (r *documentRepository) Create(ctx context.Context, doc *models.Document) error {
+	return withAudit(ctx, r.DB, auditedRow{models.AuditCreate, models.AuditEntityDocument, "documents", &doc.ID}, func(tx *sqlx.Tx) error {
+		query := `INSERT INTO documents (car_id, order_id, document_type, file_name, file_path, file_size, mime_type, uploaded_by)
+				  VALUES (:car_id, :order_id, :document_type, :file_name, :file_path, :file_size, :mime_type, :uploaded_by)
+				  RETURNING id, created_at, updated_at`
+		rows, err := sqlx.NamedQueryContext(ctx, tx, query, doc)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		if rows.Next() {
+			return rows.Scan(&doc.ID, &doc.CreatedAt, &doc.UpdatedAt)
+		}
+		return rows.Err()
+	})
+}
+
+// ListByOrder lists the order's documents of docType, newest first.
+func (r *documentRepository) ListByOrder(ctx context.Context, orderID int64, docType string) ([]models.Document, error) {
+	cond, args := orderScopeCondition(ctx, "order_id")
+	var docs []models.Document
+	err := r.DB.SelectContext(ctx, &docs, r.DB.Rebind(
+		"SELECT * FROM documents WHERE order_id = ? AND document_type = ?"+cond+" ORDER BY created_at DESC, id DESC"),
+		append([]interface{}{orderID, docType}, args...)...)
+	return docs, err
+}
+
+func (r *documentRepository) GetByOrder(ctx context.Context, orderID, id int64) (*models.Document, error) {
+	cond, args := orderScopeCondition(ctx, "order_id")
+	var doc models.Document
+	err := r.DB.GetContext(ctx, &doc, r.DB.Rebind("SELECT * FROM documents WHERE id = ? AND order_id = ?"+cond),
+		append([]interface{}{id, orderID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// orderScopeCondition is scopeCondition for orders: it excludes orders with a car outside
+// the caller's locations.
+func orderScopeCondition(ctx context.Context, column string) (string, []interface{}) {
+	s, ok := ScopeFromContext(ctx)
+	if !ok || s.Bypass {
+		return "", nil
+	}
+	return ` AND NOT EXISTS (
+		SELECT 1 FROM order_items oi JOIN cars c ON c.id = oi.car_id
+		WHERE oi.order_id = ` + column + ` AND (c.location IS NULL OR NOT c.location = ANY(?)))`,
+		[]interface{}{pq.Array(s.Locations)}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/user/car-project/internal/models"
+)
+
+// FailureStats summarizes failed login attempts in a time window.
+type FailureStats struct {
+	Count       int        `db:"count"`
+	LastFailure *time.Time `db:"last_failure"`
+}
+
+type LoginEventRepository interface {
+	// BeginAttempt records a login attempt and, in the same statement, counts the recent
+	// failures of its username and IP to decide whether it is locked out. Attempts for the
+	// same username or IP are serialized, so concurrent ones cannot all slip under the
+	// limits. The attempt is stored as locked_out, with the stats of the limit it reached, or
+	// as pending, which counts as a failure until FinishAttempt sets its outcome.
+	BeginAttempt(ctx context.Context, attempt LoginAttempt) (*models.LoginEvent, *FailureStats, error)
+	// FinishAttempt sets the outcome of a pending attempt and, when known, its user.
+	FinishAttempt(ctx context.Context, id int64, userID *int64, outcome string) error
+	GetByUserID(ctx context.Context, userID int64, limit int) ([]models.LoginEvent, error)
+}
+
+// LoginAttempt is a login about to be checked against the lockout limits. Failures after
+// Since count; a limit of 0 is disabled.
+type LoginAttempt struct {
+	Username           string
+	IPAddress          *string
+	UserAgent          *string
+	Since              time.Time
+	MaxFailuresPerUser int
+	MaxFailuresPerIP   int
+}
+
+type loginEventRepository struct {
+	DB *sqlx.DB
+}
+
+func NewLoginEventRepository(db *sqlx.DB) LoginEventRepository {
+	return &loginEventRepository{DB: db}
+}
+
+// failedOutcomes count towards the lockout; pending attempts count until they are finished.
+const failedOutcomes = `('invalid_password','unknown_user','inactive','pending')`
+
+func (r *loginEventRepository) BeginAttempt(ctx context.Context, a LoginAttempt) (*models.LoginEvent, *FailureStats, error) {
+	var row struct {
+		models.LoginEvent
+		LastFailure *time.Time `db:"last_failure"`
+		Failures    int        `db:"failures"`
+	}
+	err := inTx(ctx, r.DB, func(tx *sqlx.Tx) error {
+		// Under READ COMMITTED the counting statement below takes its snapshot after these
+		// locks are granted, so it sees every attempt made before ours.
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('login-user:' || $1))`, a.Username); err != nil {
+			return err
+		}
+		if a.IPAddress != nil {
+			if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('login-ip:' || $1))`, *a.IPAddress); err != nil {
+				return err
+			}
+		}
+		return tx.GetContext(ctx, &row,
+			`WITH u AS (
+			   SELECT COUNT(*) AS count, MAX(created_at) AS last_failure
+			   FROM login_events
+			   WHERE username = $1 AND outcome IN `+failedOutcomes+` AND created_at > $4
+			     AND created_at > COALESCE((SELECT MAX(created_at) FROM login_events WHERE username = $1 AND outcome = 'success'), '-infinity')
+			 ), i AS (
+			   SELECT COUNT(*) AS count, MAX(created_at) AS last_failure
+			   FROM login_events
+			   WHERE ip_address = $2 AND outcome IN `+failedOutcomes+` AND created_at > $4
+			 ), limits AS (
+			   SELECT CASE WHEN $5 > 0 AND u.count >= $5 THEN u.count WHEN $6 > 0 AND i.count >= $6 THEN i.count END AS failures,
+			          CASE WHEN $5 > 0 AND u.count >= $5 THEN u.last_failure WHEN $6 > 0 AND i.count >= $6 THEN i.last_failure END AS last_failure
+			   FROM u, i
+			 ), ins AS (
+			   INSERT INTO login_events (user_id, username, ip_address, user_agent, outcome)
+			   SELECT (SELECT id FROM users WHERE username = $1 AND deleted_at IS NULL), $1, $2, $3,
+			          CASE WHEN limits.failures IS NULL THEN 'pending' ELSE 'locked_out' END
+			   FROM limits
+			   RETURNING *
+			 )
+			 SELECT ins.*, limits.last_failure, COALESCE(limits.failures, 0) AS failures FROM ins, limits`,
+			a.Username, a.IPAddress, a.UserAgent, a.Since, a.MaxFailuresPerUser, a.MaxFailuresPerIP)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	event := row.LoginEvent
+	if event.Outcome != models.LoginOutcomeLockedOut {
+		return &event, nil, nil
+	}
+	return &event, &FailureStats{Count: row.Failures, LastFailure: row.LastFailure}, nil
+}
+
+func (r *loginEventRepository) FinishAttempt(ctx context.Context, id int64, userID *int64, outcome string) error {
+	_, err := r.DB.ExecContext(ctx,
+		`UPDATE login_events SET outcome = $2, user_id = COALESCE($3, user_id) WHERE id = $1 AND outcome = 'pending'`,
+		id, outcome, userID)
+	return err
+}
+
+func (r *loginEventRepository) GetByUserID(ctx context.Context, userID int64, limit int) ([]models.LoginEvent, error) {
+	var events []models.LoginEvent
+	err := r.DB.SelectContext(ctx, &events,
+		"SELECT * FROM login_events WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2",
+		userID, limit,
+	)
+	return events, err
+}
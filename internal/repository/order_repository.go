@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/user/car-project/internal/models"
+)
+
+// OrderRepository stores orders. Cars outside the caller's locations are treated as missing.
+type OrderRepository interface {
+	// Create stores the order and its items in one transaction and sets their IDs. The cars
+	// of the items are locked first and passed to check, which may still set the items'
+	// prices and the order total; its error aborts the order. It returns sql.ErrNoRows if a
+	// car does not exist, is in the trash or is outside the caller's scope, and
+	// ErrRepeatedCar if two items are for the same car.
+	Create(ctx context.Context, order *models.Order, items []models.OrderItem, check func(cars []SaleCar) error) error
+}
+
+// ErrRepeatedCar is returned by OrderRepository.Create when a car is on the order more than once.
+var ErrRepeatedCar = errors.New("car is on the order more than once")
+
+// SaleCar is a car about to be ordered, with what decides whether and for how much it may
+// be sold.
+type SaleCar struct {
+	ID        int64    `db:"id"`
+	Status    *string  `db:"status"`
+	ListPrice *float64 `db:"list_price"`
+	MinPrice  *float64 `db:"min_price"`
+	// OnOpenOrder is set when the car is already on a pending, approved or shipped order.
+	OnOpenOrder bool `db:"-"`
+}
+
+type orderRepository struct {
+	DB *sqlx.DB
+}
+
+func NewOrderRepository(db *sqlx.DB) OrderRepository {
+	return &orderRepository{DB: db}
+}
+
+func (r *orderRepository) Create(ctx context.Context, order *models.Order, items []models.OrderItem, check func(cars []SaleCar) error) error {
+	ids := make([]int64, len(items))
+	seen := make(map[int64]bool, len(items))
+	for i, item := range items {
+		if seen[item.CarID] {
+			return ErrRepeatedCar
+		}
+		seen[item.CarID] = true
+		ids[i] = item.CarID
+	}
+	cond, args := scopeCondition(ctx, "c.location", scopeLocations)
+
+	return withAudit(ctx, r.DB, auditedRow{models.AuditCreate, models.AuditEntityOrder, "orders", &order.ID}, func(tx *sqlx.Tx) error {
+		var cars []SaleCar
+		if err := tx.SelectContext(ctx, &cars, tx.Rebind(
+			`SELECT c.id, c.status, p.list_price, p.min_price
+			 FROM cars c LEFT JOIN car_prices p ON p.car_id = c.id
+			 WHERE c.id = ANY(?) AND c.deleted_at IS NULL`+cond+` ORDER BY c.id FOR UPDATE OF c`),
+			append([]interface{}{pq.Array(ids)}, args...)...); err != nil {
+			return err
+		}
+		if len(cars) != len(ids) {
+			return sql.ErrNoRows
+		}
+		// Look for open orders only once the cars are locked: this statement's snapshot then
+		// includes the items of any order that held the locks before us.
+		var onOrder []int64
+		if err := tx.SelectContext(ctx, &onOrder,
+			`SELECT DISTINCT oi.car_id FROM order_items oi JOIN orders o ON o.id = oi.order_id
+			 WHERE oi.car_id = ANY($1) AND o.status IN (`+openOrderStatuses+`)`, pq.Array(ids)); err != nil {
+			return err
+		}
+		for i := range cars {
+			for _, id := range onOrder {
+				if cars[i].ID == id {
+					cars[i].OnOpenOrder = true
+				}
+			}
+		}
+		if err := check(cars); err != nil {
+			return err
+		}
+
+		if err := tx.QueryRowxContext(ctx,
+			`INSERT INTO orders (user_id, total_amount, shipping_address) VALUES ($1, $2, $3)
+			 RETURNING id, status, created_at, updated_at`,
+			order.UserID, order.TotalAmount, order.ShippingAddress,
+		).Scan(&order.ID, &order.Status, &order.CreatedAt, &order.UpdatedAt); err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].OrderID = order.ID
+			if err := tx.QueryRowxContext(ctx,
+				`INSERT INTO order_items (order_id, car_id, quantity, price, notes) VALUES ($1, $2, $3, $4, $5)
+				 RETURNING id, created_at, updated_at`,
+				order.ID, items[i].CarID, items[i].Quantity, items[i].Price, items[i].Notes,
+			).Scan(&items[i].ID, &items[i].CreatedAt, &items[i].UpdatedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
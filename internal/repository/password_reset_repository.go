@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/user/car-project/internal/models"
+)
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	GetByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error)
+	// ResetPassword consumes the token, sets its user's password and invalidates the user's
+	// other tokens in one transaction. It returns ErrResetTokenUsed if the token was already
+	// consumed and sql.ErrNoRows if the user no longer exists.
+	ResetPassword(ctx context.Context, token *models.PasswordResetToken, passwordHash string) error
+	InvalidateForUser(ctx context.Context, userID int64) error
+}
+
+// ErrResetTokenUsed is returned by ResetPassword when the token was consumed concurrently.
+var ErrResetTokenUsed = errors.New("password reset token already used")
+
+type passwordResetRepository struct {
+	DB *sqlx.DB
+}
+
+func NewPasswordResetRepository(db *sqlx.DB) PasswordResetRepository {
+	return &passwordResetRepository{DB: db}
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	query := `INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_by)
+			  VALUES (:user_id, :token_hash, :expires_at, :created_by)
+			  RETURNING id, created_at`
+
+	stmt, err := r.DB.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	return stmt.QueryRowxContext(ctx, token).Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *passwordResetRepository) GetByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.DB.GetContext(ctx, &token, "SELECT * FROM password_reset_tokens WHERE token_hash = $1", hash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ResetPassword is audited as an update of the user. Consuming the token first means
+// concurrent requests cannot both reset the password with the same token.
+func (r *passwordResetRepository) ResetPassword(ctx context.Context, token *models.PasswordResetToken, passwordHash string) error {
+	userID := token.UserID
+	return withAudit(ctx, r.DB, auditedRow{models.AuditUpdate, models.AuditEntityUser, "users", &userID}, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			"UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP WHERE id = $1 AND used_at IS NULL", token.ID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrResetTokenUsed
+		}
+		res, err = tx.ExecContext(ctx,
+			"UPDATE users SET password_hash = $1, updated_by = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND deleted_at IS NULL",
+			passwordHash, userID)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(res); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			"UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND used_at IS NULL", userID)
+		return err
+	})
+}
+
+// InvalidateForUser marks every outstanding reset token of the user as used.
+func (r *passwordResetRepository) InvalidateForUser(ctx context.Context, userID int64) error {
+	_, err := r.DB.ExecContext(ctx,
+		"UPDATE password_reset_tokens SET used_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND used_at IS NULL", userID)
+	return err
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/user/car-project/internal/models"
+)
+
+// PaymentRepository restricts every query to the showrooms of the Scope in ctx, if any.
+type PaymentRepository interface {
+	GetAll(ctx context.Context) ([]models.PaymentHistory, error)
+	GetByID(ctx context.Context, id int64) (*models.PaymentHistory, error)
+}
+
+type paymentRepository struct {
+	DB *sqlx.DB
+}
+
+func NewPaymentRepository(db *sqlx.DB) PaymentRepository {
+	return &paymentRepository{DB: db}
+}
+
+func (r *paymentRepository) GetAll(ctx context.Context) ([]models.PaymentHistory, error) {
+	cond, args := scopeCondition(ctx, "showroom_name", scopeShowrooms)
+	var payments []models.PaymentHistory
+	err := r.DB.SelectContext(ctx, &payments,
+		r.DB.Rebind("SELECT * FROM payment_history WHERE TRUE"+cond+" ORDER BY purchase_date DESC NULLS LAST, id DESC"), args...)
+	return payments, err
+}
+
+func (r *paymentRepository) GetByID(ctx context.Context, id int64) (*models.PaymentHistory, error) {
+	cond, args := scopeCondition(ctx, "showroom_name", scopeShowrooms)
+	var payment models.PaymentHistory
+	err := r.DB.GetContext(ctx, &payment,
+		r.DB.Rebind("SELECT * FROM payment_history WHERE id = ?"+cond), append([]interface{}{id}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/user/car-project/internal/models"
 )
 
@@ -14,8 +15,12 @@ type PermissionRepository interface {
 	GetAll(ctx context.Context) ([]models.Permission, error)
 	Update(ctx context.Context, permission *models.Permission) error
 	Delete(ctx context.Context, id int64) error
-	AssignPermissionToRole(ctx context.Context, roleID, permissionID int64) error
+	GetByIDs(ctx context.Context, ids []int64) ([]models.Permission, error)
+	AssignPermissionsToRole(ctx context.Context, roleID int64, permissionIDs []int64, assignedBy *int64) (int64, error)
+	RevokePermissionsFromRole(ctx context.Context, roleID int64, permissionIDs []int64) (int64, error)
+	GetByRoleID(ctx context.Context, roleID int64) ([]models.Permission, error)
 	GetByUserID(ctx context.Context, userID int64) ([]string, error)
+	GetUserPermissionsVersion(ctx context.Context, userID int64) (int64, error)
 }
 
 type permissionRepository struct {
@@ -31,19 +36,15 @@ func (r *permissionRepository) Create(ctx context.Context, permission *models.Pe
 			  VALUES (:name, :slug, :module, :created_at, :updated_at) 
 			  RETURNING id`
 
-	stmt, err := r.DB.PrepareNamedContext(ctx, query)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
+	return withAudit(ctx, r.DB, auditedRow{models.AuditCreate, models.AuditEntityPermission, "permissions", &permission.ID}, func(tx *sqlx.Tx) error {
+		stmt, err := tx.PrepareNamedContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
 
-	var id int64
-	err = stmt.QueryRowxContext(ctx, permission).Scan(&id)
-	if err != nil {
-		return err
-	}
-	permission.ID = id
-	return nil
+		return stmt.QueryRowxContext(ctx, permission).Scan(&permission.ID)
+	})
 }
 
 func (r *permissionRepository) GetByID(ctx context.Context, id int64) (*models.Permission, error) {
@@ -66,29 +67,103 @@ func (r *permissionRepository) GetAll(ctx context.Context) ([]models.Permission,
 
 func (r *permissionRepository) Update(ctx context.Context, permission *models.Permission) error {
 	query := `UPDATE permissions SET name=:name, module=:module, updated_at=CURRENT_TIMESTAMP WHERE id=:id`
-	_, err := r.DB.NamedExecContext(ctx, query, permission)
-	return err
+	return withAudit(ctx, r.DB, auditedRow{models.AuditUpdate, models.AuditEntityPermission, "permissions", &permission.ID}, func(tx *sqlx.Tx) error {
+		res, err := tx.NamedExecContext(ctx, query, permission)
+		if err != nil {
+			return err
+		}
+		return requireAffected(res)
+	})
 }
 
 func (r *permissionRepository) Delete(ctx context.Context, id int64) error {
-	_, err := r.DB.ExecContext(ctx, "DELETE FROM permissions WHERE id = $1", id)
-	return err
+	return withAudit(ctx, r.DB, auditedRow{models.AuditDelete, models.AuditEntityPermission, "permissions", &id}, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM permissions WHERE id = $1", id)
+		if err != nil {
+			return err
+		}
+		return requireAffected(res)
+	})
+}
+
+func (r *permissionRepository) GetByIDs(ctx context.Context, ids []int64) ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := r.DB.SelectContext(ctx, &permissions, "SELECT * FROM permissions WHERE id = ANY($1) ORDER BY module, name ASC", pq.Array(ids))
+	return permissions, err
+}
+
+// AssignPermissionsToRole grants every permission in one statement and returns how many were newly granted.
+// The newly granted IDs are audited as a grant on the role.
+func (r *permissionRepository) AssignPermissionsToRole(ctx context.Context, roleID int64, permissionIDs []int64, assignedBy *int64) (int64, error) {
+	var granted []int64
+	err := inTx(ctx, r.DB, func(tx *sqlx.Tx) error {
+		err := tx.SelectContext(ctx, &granted,
+			`INSERT INTO permission_role (role_id, permission_id, assigned_by)
+			 SELECT $1, unnest($2::BIGINT[]), $3
+			 ON CONFLICT DO NOTHING
+			 RETURNING permission_id`,
+			roleID, pq.Array(permissionIDs), assignedBy,
+		)
+		if err != nil || len(granted) == 0 {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditGrant, models.AuditEntityRole, roleID, nil, map[string]interface{}{"permission_ids": granted})
+	})
+	return int64(len(granted)), err
 }
 
-func (r *permissionRepository) AssignPermissionToRole(ctx context.Context, roleID, permissionID int64) error {
-	_, err := r.DB.ExecContext(ctx, "INSERT INTO permission_role (role_id, permission_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", roleID, permissionID)
-	return err
+// RevokePermissionsFromRole returns how many of the given permissions the role actually had.
+func (r *permissionRepository) RevokePermissionsFromRole(ctx context.Context, roleID int64, permissionIDs []int64) (int64, error) {
+	var revoked []int64
+	err := inTx(ctx, r.DB, func(tx *sqlx.Tx) error {
+		err := tx.SelectContext(ctx, &revoked,
+			"DELETE FROM permission_role WHERE role_id = $1 AND permission_id = ANY($2) RETURNING permission_id",
+			roleID, pq.Array(permissionIDs),
+		)
+		if err != nil || len(revoked) == 0 {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditRevoke, models.AuditEntityRole, roleID, map[string]interface{}{"permission_ids": revoked}, nil)
+	})
+	return int64(len(revoked)), err
 }
 
+func (r *permissionRepository) GetByRoleID(ctx context.Context, roleID int64) ([]models.Permission, error) {
+	var permissions []models.Permission
+	query := `
+		SELECT p.*
+		FROM permissions p
+		JOIN permission_role pr ON p.id = pr.permission_id
+		WHERE pr.role_id = $1
+		ORDER BY p.module, p.name ASC
+	`
+	err := r.DB.SelectContext(ctx, &permissions, query, roleID)
+	return permissions, err
+}
+
+// GetByUserID returns the slugs of the user's permissions; inactive and deleted users have none.
 func (r *permissionRepository) GetByUserID(ctx context.Context, userID int64) ([]string, error) {
 	query := `
 		SELECT DISTINCT p.slug
 		FROM permissions p
 		JOIN permission_role pr ON p.id = pr.permission_id
 		JOIN role_user ru ON pr.role_id = ru.role_id
-		WHERE ru.user_id = $1
+		JOIN users u ON u.id = ru.user_id
+		WHERE ru.user_id = $1 AND u.is_active AND u.deleted_at IS NULL
+		ORDER BY p.slug
 	`
 	var slugs []string
 	err := r.DB.SelectContext(ctx, &slugs, query, userID)
 	return slugs, err
 }
+
+// GetUserPermissionsVersion returns the user's permissions_version, or 0 if the user does not exist,
+// was deleted or is inactive, so their access tokens stop working straight away.
+func (r *permissionRepository) GetUserPermissionsVersion(ctx context.Context, userID int64) (int64, error) {
+	var version int64
+	err := r.DB.GetContext(ctx, &version, "SELECT permissions_version FROM users WHERE id = $1 AND is_active AND deleted_at IS NULL", userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return version, err
+}
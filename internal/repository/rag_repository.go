@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/user/car-project/internal/models"
 )
 
@@ -22,6 +24,10 @@ type RAGRepository interface {
 	DeleteBySource(ctx context.Context, sourceType, sourceID string) error
 	DeleteAllBySourceType(ctx context.Context, sourceType string) error
 	GetCarsContentForIndexing(ctx context.Context) ([]CarContentRow, error)
+	LogQuery(ctx context.Context, q *models.RAGQuery) error
+	SetQueryFeedback(ctx context.Context, queryID, userID int64, feedback int16, comment *string) error
+	GetCachedEmbeddings(ctx context.Context, model string, hashes []string) (map[string]string, error)
+	PutCachedEmbeddings(ctx context.Context, model string, embeddings map[string]string) error
 }
 
 // CarContentRow holds one car's aggregated text for RAG indexing.
@@ -56,15 +62,17 @@ func (r *ragRepository) SearchByEmbedding(ctx context.Context, embedding string,
 	if topK <= 0 {
 		topK = 5
 	}
-	var chunks []models.RAGChunk
-	err := r.DB.SelectContext(ctx, &chunks,
-		`SELECT id, source_type, source_id, content, COALESCE(metadata::text, '{}') AS metadata, created_at
+	// Car chunks are only returned for cars that are not in the trash and are inside the
+	// caller's location scope.
+	cond, args := scopeCondition(ctx, "c.location", scopeLocations)
+	scopeFilter := ` AND (source_type <> 'car' OR EXISTS (SELECT 1 FROM cars c WHERE c.id::text = source_id AND c.deleted_at IS NULL` + cond + `))`
+	query := r.DB.Rebind(`SELECT id, source_type, source_id, content, COALESCE(metadata::text, '{}') AS metadata, created_at
 		 FROM rag_chunks
-		 WHERE embedding IS NOT NULL
-		 ORDER BY embedding <=> $1::vector
-		 LIMIT $2`,
-		embedding, topK,
-	)
+		 WHERE embedding IS NOT NULL` + scopeFilter + `
+		 ORDER BY embedding <=> ?::vector
+		 LIMIT ?`)
+	var chunks []models.RAGChunk
+	err := r.DB.SelectContext(ctx, &chunks, query, append(args, embedding, topK)...)
 	return chunks, err
 }
 
@@ -106,9 +114,86 @@ func (r *ragRepository) GetCarsContentForIndexing(ctx context.Context) ([]CarCon
 		JOIN car_models mo ON mo.id = c.model_id
 		JOIN car_makes m ON m.id = mo.make_id
 		LEFT JOIN car_details cd ON cd.car_id = c.id
+		WHERE c.deleted_at IS NULL
 		ORDER BY c.id
 	`
 	var rows []CarContentRow
 	err := r.DB.SelectContext(ctx, &rows, query)
 	return rows, err
 }
+
+func (r *ragRepository) LogQuery(ctx context.Context, q *models.RAGQuery) error {
+	query := `INSERT INTO rag_queries (user_id, query, chunk_ids, answer, latency_ms)
+			  VALUES (:user_id, :query, :chunk_ids, :answer, :latency_ms)
+			  RETURNING id, created_at`
+
+	stmt, err := r.DB.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	return stmt.QueryRowxContext(ctx, q).Scan(&q.ID, &q.CreatedAt)
+}
+
+// SetQueryFeedback records a rating on a logged query. Only the user who asked may rate it;
+// sql.ErrNoRows is returned when no matching query exists.
+func (r *ragRepository) SetQueryFeedback(ctx context.Context, queryID, userID int64, feedback int16, comment *string) error {
+	res, err := r.DB.ExecContext(ctx,
+		`UPDATE rag_queries SET feedback = $1, feedback_comment = $2, feedback_at = CURRENT_TIMESTAMP
+		 WHERE id = $3 AND user_id = $4`,
+		feedback, comment, queryID, userID,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetCachedEmbeddings returns cached embeddings (pgvector strings) keyed by content hash.
+// Hashes with no cache entry are simply absent from the map.
+func (r *ragRepository) GetCachedEmbeddings(ctx context.Context, model string, hashes []string) (map[string]string, error) {
+	out := make(map[string]string, len(hashes))
+	if len(hashes) == 0 {
+		return out, nil
+	}
+	var rows []struct {
+		ContentHash string `db:"content_hash"`
+		Embedding   string `db:"embedding"`
+	}
+	err := r.DB.SelectContext(ctx, &rows,
+		`SELECT content_hash, embedding::text AS embedding
+		 FROM rag_embedding_cache
+		 WHERE model = $1 AND content_hash = ANY($2)`,
+		model, pq.Array(hashes),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		out[row.ContentHash] = row.Embedding
+	}
+	return out, nil
+}
+
+func (r *ragRepository) PutCachedEmbeddings(ctx context.Context, model string, embeddings map[string]string) error {
+	for hash, emb := range embeddings {
+		_, err := r.DB.ExecContext(ctx,
+			`INSERT INTO rag_embedding_cache (model, content_hash, embedding)
+			 VALUES ($1, $2, $3::vector)
+			 ON CONFLICT (model, content_hash) DO NOTHING`,
+			model, hash, emb,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
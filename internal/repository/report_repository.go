@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/user/car-project/internal/models"
+)
+
+// ReportRepository aggregates sales and inventory. Sales, receivables, landed costs and LC
+// dates are read from the report_* materialized views, which RefreshViews brings up to date.
+// Car reports are limited to the locations of the Scope in ctx and receivables to its showrooms.
+type ReportRepository interface {
+	// StockAging groups the cars in stock by the number of thresholds in bucketStarts their
+	// days in stock have reached.
+	StockAging(ctx context.Context, f ReportFilter, bucketStarts []int) ([]StockAgingRow, error)
+	// SellThrough counts the cars sold in the period and those in stock per groupBy: "make",
+	// "model" or "body_type".
+	SellThrough(ctx context.Context, f ReportFilter, groupBy string) ([]models.SellThroughRow, error)
+	Revenue(ctx context.Context, f ReportFilter) ([]models.MonthlyRevenue, error)
+	// Receivables lists the purchases made in the period that are not paid in full, largest
+	// outstanding amount first.
+	Receivables(ctx context.Context, f ReportFilter) ([]models.Receivable, error)
+	StockValue(ctx context.Context, f ReportFilter) ([]models.StockValueRow, error)
+	// RefreshViews refreshes every report view concurrently, so reports can read them
+	// meanwhile, and records the outcome of each in report_view_refreshes. A view that fails
+	// does not stop the others; the first failure is returned. It returns ErrRefreshInProgress
+	// while another session is refreshing them.
+	RefreshViews(ctx context.Context) error
+	// ViewRefreshes returns the last refresh of each report view.
+	ViewRefreshes(ctx context.Context) ([]models.ReportViewRefresh, error)
+}
+
+// ErrRefreshInProgress is returned by RefreshViews while another session holds the refresh lock.
+var ErrRefreshInProgress = errors.New("report views are already being refreshed")
+
+// reportViews are the materialized views behind the reports, in refresh order: report_sales
+// reads report_car_costs.
+var reportViews = []string{"report_car_costs", "report_sales", "report_receivables"}
+
+// reportRefreshLock is the advisory lock held while the report views are refreshed, so
+// several API instances do not refresh them at once.
+const reportRefreshLock = 4900001
+
+// ReportFilter limits a report to [From, To); nil bounds are open. It applies to the
+// stock-in date of inventory reports, the order date of sales and the purchase date of
+// receivables.
+type ReportFilter struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// condition returns the conditions limiting column to the filter and their arguments.
+func (f ReportFilter) condition(column string) (string, []interface{}) {
+	var cond string
+	var args []interface{}
+	if f.From != nil {
+		cond += " AND " + column + " >= ?"
+		args = append(args, *f.From)
+	}
+	if f.To != nil {
+		cond += " AND " + column + " < ?"
+		args = append(args, *f.To)
+	}
+	return cond, args
+}
+
+// StockAgingRow is one aging bucket: the cars whose days in stock reached Bucket thresholds.
+type StockAgingRow struct {
+	Bucket    int     `db:"bucket"`
+	Cars      int64   `db:"cars"`
+	TotalDays int64   `db:"total_days"`
+	ListValue float64 `db:"list_value"`
+	CostValue float64 `db:"cost_value"`
+}
+
+const (
+	// inStockStatuses are the statuses of cars still held for sale.
+	inStockStatuses = "'available', 'reserved', 'damaged'"
+	// stockInSQL is the date car c came into stock: its first LC, or when it was created. It
+	// needs report_car_costs joined as k.
+	stockInSQL = `COALESCE(k.first_lc_date, c.created_at::date)`
+)
+
+// sellThroughGroups are the columns sell-through can be grouped by.
+var sellThroughGroups = map[string]string{
+	"make":      "m.name",
+	"model":     "m.name || ' ' || mo.name",
+	"body_type": "COALESCE(c.body_type::text, 'Unknown')",
+}
+
+type reportRepository struct {
+	DB *sqlx.DB
+}
+
+func NewReportRepository(db *sqlx.DB) ReportRepository {
+	return &reportRepository{DB: db}
+}
+
+func (r *reportRepository) StockAging(ctx context.Context, f ReportFilter, bucketStarts []int) ([]StockAgingRow, error) {
+	scope, scopeArgs := scopeCondition(ctx, "c.location", scopeLocations)
+	period, periodArgs := f.condition("stocked_on")
+	starts := make([]int64, len(bucketStarts))
+	for i, d := range bucketStarts {
+		starts[i] = int64(d)
+	}
+	args := append(append(scopeArgs, pq.Array(starts)), periodArgs...)
+	var rows []StockAgingRow
+	err := r.DB.SelectContext(ctx, &rows, r.DB.Rebind(
+		`WITH stock AS (
+		   SELECT `+stockInSQL+` AS stocked_on, p.list_price, k.cost_basis AS cost
+		   FROM cars c
+		   LEFT JOIN car_prices p ON p.car_id = c.id
+		   LEFT JOIN report_car_costs k ON k.car_id = c.id
+		   WHERE c.deleted_at IS NULL AND c.status IN (`+inStockStatuses+`)`+scope+`
+		 )
+		 SELECT width_bucket(CURRENT_DATE - stocked_on, ?::int[]) AS bucket, COUNT(*) AS cars,
+		   SUM(CURRENT_DATE - stocked_on) AS total_days,
+		   COALESCE(SUM(list_price), 0) AS list_value, COALESCE(SUM(cost), 0) AS cost_value
+		 FROM stock WHERE TRUE`+period+`
+		 GROUP BY 1 ORDER BY 1`), args...)
+	return rows, err
+}
+
+func (r *reportRepository) SellThrough(ctx context.Context, f ReportFilter, groupBy string) ([]models.SellThroughRow, error) {
+	group, ok := sellThroughGroups[groupBy]
+	if !ok {
+		group = sellThroughGroups["make"]
+	}
+	period, periodArgs := f.condition("sold_at")
+	scope, scopeArgs := scopeCondition(ctx, "c.location", scopeLocations)
+	var rows []models.SellThroughRow
+	err := r.DB.SelectContext(ctx, &rows, r.DB.Rebind(
+		`WITH sold AS (
+		   SELECT DISTINCT car_id FROM report_sales WHERE TRUE`+period+`
+		 )
+		 SELECT `+group+` AS grp, COUNT(s.car_id) AS sold, COUNT(*) FILTER (WHERE s.car_id IS NULL) AS in_stock
+		 FROM cars c
+		 JOIN car_models mo ON mo.id = c.model_id
+		 JOIN car_makes m ON m.id = mo.make_id
+		 LEFT JOIN sold s ON s.car_id = c.id
+		 WHERE (s.car_id IS NOT NULL OR (c.deleted_at IS NULL AND c.status IN (`+inStockStatuses+`)))`+scope+`
+		 GROUP BY 1 ORDER BY 2 DESC, 1`), append(periodArgs, scopeArgs...)...)
+	return rows, err
+}
+
+func (r *reportRepository) Revenue(ctx context.Context, f ReportFilter) ([]models.MonthlyRevenue, error) {
+	period, periodArgs := f.condition("s.sold_at")
+	scope, scopeArgs := scopeCondition(ctx, "c.location", scopeLocations)
+	var rows []models.MonthlyRevenue
+	err := r.DB.SelectContext(ctx, &rows, r.DB.Rebind(
+		`SELECT date_trunc('month', s.sold_at)::date AS month,
+		   COUNT(DISTINCT s.order_id) AS orders, SUM(s.quantity) AS cars_sold,
+		   SUM(s.amount) AS revenue,
+		   COALESCE(SUM(s.cost_basis * s.quantity), 0) AS cost,
+		   COALESCE(SUM(s.amount) FILTER (WHERE s.cost_basis IS NOT NULL), 0) AS costed_revenue,
+		   COALESCE(SUM(s.quantity) FILTER (WHERE s.cost_basis IS NULL), 0) AS cars_without_cost
+		 FROM report_sales s
+		 JOIN cars c ON c.id = s.car_id
+		 WHERE TRUE`+period+scope+`
+		 GROUP BY 1 ORDER BY 1`), append(periodArgs, scopeArgs...)...)
+	return rows, err
+}
+
+func (r *reportRepository) Receivables(ctx context.Context, f ReportFilter) ([]models.Receivable, error) {
+	period, periodArgs := f.condition("purchase_date")
+	scope, scopeArgs := scopeCondition(ctx, "showroom_name", scopeShowrooms)
+	var rows []models.Receivable
+	err := r.DB.SelectContext(ctx, &rows, r.DB.Rebind(
+		`SELECT payment_id, car_id, customer_name, showroom_name, purchase_date, amount, paid,
+		   outstanding, last_payment_date
+		 FROM report_receivables
+		 WHERE TRUE`+period+scope+`
+		 ORDER BY outstanding DESC, payment_id`), append(periodArgs, scopeArgs...)...)
+	return rows, err
+}
+
+func (r *reportRepository) StockValue(ctx context.Context, f ReportFilter) ([]models.StockValueRow, error) {
+	period, periodArgs := f.condition(stockInSQL)
+	scope, scopeArgs := scopeCondition(ctx, "c.location", scopeLocations)
+	var rows []models.StockValueRow
+	err := r.DB.SelectContext(ctx, &rows, r.DB.Rebind(
+		`SELECT c.location, COUNT(*) AS cars,
+		   COALESCE(SUM(p.list_price), 0) AS list_value, COALESCE(SUM(k.cost_basis), 0) AS cost_value,
+		   COUNT(*) FILTER (WHERE p.list_price IS NULL) AS cars_without_price,
+		   COUNT(*) FILTER (WHERE k.cost_basis IS NULL) AS cars_without_cost
+		 FROM cars c
+		 LEFT JOIN car_prices p ON p.car_id = c.id
+		 LEFT JOIN report_car_costs k ON k.car_id = c.id
+		 WHERE c.deleted_at IS NULL AND c.status IN (`+inStockStatuses+`)`+period+scope+`
+		 GROUP BY c.location ORDER BY list_value DESC, c.location`), append(periodArgs, scopeArgs...)...)
+	return rows, err
+}
+
+func (r *reportRepository) RefreshViews(ctx context.Context) error {
+	// Advisory locks belong to a session, so the whole refresh runs on one connection.
+	conn, err := r.DB.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var locked bool
+	if err := conn.GetContext(ctx, &locked, `SELECT pg_try_advisory_lock($1)`, reportRefreshLock); err != nil {
+		return err
+	}
+	if !locked {
+		return ErrRefreshInProgress
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, reportRefreshLock)
+
+	var failed error
+	for _, view := range reportViews {
+		start := time.Now()
+		if _, err := conn.ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY `+view); err != nil {
+			if failed == nil {
+				failed = fmt.Errorf("refresh %s: %w", view, err)
+			}
+			if _, err := conn.ExecContext(ctx,
+				`INSERT INTO report_view_refreshes (view_name, failed_at, last_error) VALUES ($1, CURRENT_TIMESTAMP, $2)
+				 ON CONFLICT (view_name) DO UPDATE SET failed_at = EXCLUDED.failed_at, last_error = EXCLUDED.last_error`,
+				view, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := conn.ExecContext(ctx,
+			`INSERT INTO report_view_refreshes (view_name, refreshed_at, duration_ms) VALUES ($1, CURRENT_TIMESTAMP, $2)
+			 ON CONFLICT (view_name) DO UPDATE SET refreshed_at = EXCLUDED.refreshed_at, duration_ms = EXCLUDED.duration_ms`,
+			view, time.Since(start).Milliseconds()); err != nil {
+			return err
+		}
+	}
+	return failed
+}
+
+func (r *reportRepository) ViewRefreshes(ctx context.Context) ([]models.ReportViewRefresh, error) {
+	var rows []models.ReportViewRefresh
+	err := r.DB.SelectContext(ctx, &rows,
+		`SELECT view_name, refreshed_at, duration_ms, failed_at, last_error FROM report_view_refreshes ORDER BY view_name`)
+	return rows, err
+}
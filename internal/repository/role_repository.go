@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/user/car-project/internal/models"
@@ -14,9 +15,20 @@ type RoleRepository interface {
 	GetAll(ctx context.Context) ([]models.Role, error)
 	Update(ctx context.Context, role *models.Role) error
 	Delete(ctx context.Context, id int64) error
-	AssignRoleToUser(ctx context.Context, userID, roleID int64) error
+	AssignRoleToUser(ctx context.Context, userID, roleID int64, assignedBy *int64) error
+	RevokeRoleFromUser(ctx context.Context, userID, roleID int64) (bool, error)
+	GetByUserID(ctx context.Context, userID int64) ([]models.Role, error)
+	UserHasRole(ctx context.Context, userID int64, roleSlug string) (bool, error)
+	CountOtherActiveUsersWithRole(ctx context.Context, roleSlug string, excludeUserID int64) (int, error)
 }
 
+// ErrLastAdmin is returned when a change would leave no active administrator.
+var ErrLastAdmin = errors.New("change would leave no active administrator")
+
+// lastAdminLock is the advisory lock held while a change that can remove an active
+// administrator checks that another one remains.
+const lastAdminLock = 3200001
+
 type roleRepository struct {
 	DB *sqlx.DB
 }
@@ -30,19 +42,15 @@ func (r *roleRepository) Create(ctx context.Context, role *models.Role) error {
 			  VALUES (:name, :slug, :description, :created_at, :updated_at) 
 			  RETURNING id`
 
-	stmt, err := r.DB.PrepareNamedContext(ctx, query)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
+	return withAudit(ctx, r.DB, auditedRow{models.AuditCreate, models.AuditEntityRole, "roles", &role.ID}, func(tx *sqlx.Tx) error {
+		stmt, err := tx.PrepareNamedContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
 
-	var id int64
-	err = stmt.QueryRowxContext(ctx, role).Scan(&id)
-	if err != nil {
-		return err
-	}
-	role.ID = id
-	return nil
+		return stmt.QueryRowxContext(ctx, role).Scan(&role.ID)
+	})
 }
 
 func (r *roleRepository) GetByID(ctx context.Context, id int64) (*models.Role, error) {
@@ -65,16 +73,137 @@ func (r *roleRepository) GetAll(ctx context.Context) ([]models.Role, error) {
 
 func (r *roleRepository) Update(ctx context.Context, role *models.Role) error {
 	query := `UPDATE roles SET name=:name, description=:description, updated_at=CURRENT_TIMESTAMP WHERE id=:id`
-	_, err := r.DB.NamedExecContext(ctx, query, role)
-	return err
+	return withAudit(ctx, r.DB, auditedRow{models.AuditUpdate, models.AuditEntityRole, "roles", &role.ID}, func(tx *sqlx.Tx) error {
+		res, err := tx.NamedExecContext(ctx, query, role)
+		if err != nil {
+			return err
+		}
+		return requireAffected(res)
+	})
 }
 
 func (r *roleRepository) Delete(ctx context.Context, id int64) error {
-	_, err := r.DB.ExecContext(ctx, "DELETE FROM roles WHERE id = $1", id)
-	return err
+	return withAudit(ctx, r.DB, auditedRow{models.AuditDelete, models.AuditEntityRole, "roles", &id}, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM roles WHERE id = $1", id)
+		if err != nil {
+			return err
+		}
+		return requireAffected(res)
+	})
+}
+
+// AssignRoleToUser is audited as a grant on the user.
+func (r *roleRepository) AssignRoleToUser(ctx context.Context, userID, roleID int64, assignedBy *int64) error {
+	return inTx(ctx, r.DB, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			"INSERT INTO role_user (user_id, role_id, assigned_by) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+			userID, roleID, assignedBy,
+		)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditGrant, models.AuditEntityUser, userID, nil, map[string]interface{}{"role_id": roleID})
+	})
 }
 
-func (r *roleRepository) AssignRoleToUser(ctx context.Context, userID, roleID int64) error {
-	_, err := r.DB.ExecContext(ctx, "INSERT INTO role_user (user_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", userID, roleID)
-	return err
+// RevokeRoleFromUser removes the assignment and reports whether one existed. Revoking the
+// admin role from the last active administrator returns ErrLastAdmin.
+func (r *roleRepository) RevokeRoleFromUser(ctx context.Context, userID, roleID int64) (bool, error) {
+	var removed bool
+	err := inTx(ctx, r.DB, func(tx *sqlx.Tx) error {
+		var slug string
+		err := tx.GetContext(ctx, &slug, "SELECT slug FROM roles WHERE id = $1", roleID)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if slug == models.RoleSlugAdmin {
+			if err := guardLastAdmin(ctx, tx, userID); err != nil {
+				return err
+			}
+		}
+		res, err := tx.ExecContext(ctx, "DELETE FROM role_user WHERE user_id = $1 AND role_id = $2", userID, roleID)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil || n == 0 {
+			return err
+		}
+		removed = true
+		return recordAudit(ctx, tx, models.AuditRevoke, models.AuditEntityUser, userID, map[string]interface{}{"role_id": roleID}, nil)
+	})
+	return removed, err
+}
+
+func (r *roleRepository) UserHasRole(ctx context.Context, userID int64, roleSlug string) (bool, error) {
+	var has bool
+	query := `SELECT EXISTS (
+		SELECT 1 FROM role_user ru JOIN roles r ON r.id = ru.role_id
+		WHERE ru.user_id = $1 AND r.slug = $2
+	)`
+	err := r.DB.GetContext(ctx, &has, query, userID, roleSlug)
+	return has, err
+}
+
+// CountOtherActiveUsersWithRole counts active, non-deleted users other than excludeUserID holding the role.
+// API key service users are not counted.
+func (r *roleRepository) CountOtherActiveUsersWithRole(ctx context.Context, roleSlug string, excludeUserID int64) (int, error) {
+	var n int
+	query := `
+		SELECT COUNT(DISTINCT u.id)
+		FROM users u
+		JOIN role_user ru ON u.id = ru.user_id
+		JOIN roles r ON r.id = ru.role_id
+		WHERE r.slug = $1 AND u.id <> $2 AND u.is_active AND u.deleted_at IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM api_keys k WHERE k.user_id = u.id)
+	`
+	err := r.DB.GetContext(ctx, &n, query, roleSlug, excludeUserID)
+	return n, err
+}
+
+func (r *roleRepository) GetByUserID(ctx context.Context, userID int64) ([]models.Role, error) {
+	var roles []models.Role
+	query := `
+		SELECT r.*
+		FROM roles r
+		JOIN role_user ru ON r.id = ru.role_id
+		WHERE ru.user_id = $1
+		ORDER BY r.name ASC
+	`
+	err := r.DB.SelectContext(ctx, &roles, query, userID)
+	return roles, err
+}
+
+// guardLastAdmin returns ErrLastAdmin if userID is the only active administrator, so the
+// caller's transaction must not deactivate, delete or demote them. Callers hold the lock
+// until they commit, so concurrent changes to two administrators cannot both pass.
+func guardLastAdmin(ctx context.Context, tx *sqlx.Tx, userID int64) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", lastAdminLock); err != nil {
+		return err
+	}
+	var last bool
+	err := tx.GetContext(ctx, &last, `
+		WITH admins AS (
+			SELECT DISTINCT u.id
+			FROM users u
+			JOIN role_user ru ON u.id = ru.user_id
+			JOIN roles r ON r.id = ru.role_id
+			WHERE r.slug = $2 AND u.is_active AND u.deleted_at IS NULL
+			  AND NOT EXISTS (SELECT 1 FROM api_keys k WHERE k.user_id = u.id)
+		)
+		SELECT EXISTS (SELECT 1 FROM admins WHERE id = $1)
+		   AND NOT EXISTS (SELECT 1 FROM admins WHERE id <> $1)`, userID, models.RoleSlugAdmin)
+	if err != nil {
+		return err
+	}
+	if last {
+		return ErrLastAdmin
+	}
+	return nil
 }
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// ErrOutOfScope is returned when a write would create or move a row outside the caller's data scope.
+var ErrOutOfScope = errors.New("row is outside the caller's data scope")
+
+// Scope limits which rows a request may read or write. Repositories apply it automatically
+// to scoped tables (cars by location, payment_history by showroom) when it is present in
+// the context. A context without a Scope, as used by background jobs, is unrestricted.
+type Scope struct {
+	Bypass    bool
+	Locations []string
+	Showrooms []string
+}
+
+type scopeContextKey struct{}
+
+// WithScope returns a copy of ctx carrying s.
+func WithScope(ctx context.Context, s Scope) context.Context {
+	return context.WithValue(ctx, scopeContextKey{}, s)
+}
+
+// ScopeFromContext returns the scope stored by WithScope, if any.
+func ScopeFromContext(ctx context.Context) (Scope, bool) {
+	s, ok := ctx.Value(scopeContextKey{}).(Scope)
+	return s, ok
+}
+
+func scopeLocations(s Scope) []string { return s.Locations }
+func scopeShowrooms(s Scope) []string { return s.Showrooms }
+
+// scopeCondition returns " AND <column> = ANY(?)" and its argument when ctx carries a
+// restricting scope, or "" and nil otherwise. Queries using it must be Rebind-ed.
+func scopeCondition(ctx context.Context, column string, values func(Scope) []string) (string, []interface{}) {
+	s, ok := ScopeFromContext(ctx)
+	if !ok || s.Bypass {
+		return "", nil
+	}
+	return " AND " + column + " = ANY(?)", []interface{}{pq.Array(values(s))}
+}
+
+// LocationInScope reports whether a car with this location may be written under the scope in ctx.
+func LocationInScope(ctx context.Context, location *string) bool {
+	return inScope(ctx, location, scopeLocations)
+}
+
+// inScope reports whether value may be written under the scope in ctx.
+func inScope(ctx context.Context, value *string, values func(Scope) []string) bool {
+	s, ok := ScopeFromContext(ctx)
+	if !ok || s.Bypass {
+		return true
+	}
+	if value == nil {
+		return false
+	}
+	for _, v := range values(s) {
+		if v == *value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/user/car-project/internal/models"
+)
+
+// ErrTokenAlreadyRotated is returned by ReplaceRefreshToken when the token was revoked or
+// rotated concurrently, which callers must treat as reuse.
+var ErrTokenAlreadyRotated = errors.New("refresh token already rotated")
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	ReplaceRefreshToken(ctx context.Context, oldID int64, next *models.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID int64) error
+	RevokeAccessToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+type tokenRepository struct {
+	DB *sqlx.DB
+}
+
+func NewTokenRepository(db *sqlx.DB) TokenRepository {
+	return &tokenRepository{DB: db}
+}
+
+const insertRefreshTokenQuery = `INSERT INTO refresh_tokens (user_id, token_hash, family_id, access_jti, access_expires_at, expires_at, user_agent, ip_address)
+			  VALUES (:user_id, :token_hash, :family_id, :access_jti, :access_expires_at, :expires_at, :user_agent, :ip_address)
+			  RETURNING id, created_at`
+
+func (r *tokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	stmt, err := r.DB.PrepareNamedContext(ctx, insertRefreshTokenQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	return stmt.QueryRowxContext(ctx, token).Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *tokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.DB.GetContext(ctx, &token, "SELECT * FROM refresh_tokens WHERE token_hash = $1", hash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ReplaceRefreshToken atomically revokes oldID and inserts next as its replacement.
+func (r *tokenRepository) ReplaceRefreshToken(ctx context.Context, oldID int64, next *models.RefreshToken) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`,
+		oldID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrTokenAlreadyRotated
+	}
+
+	stmt, err := tx.PrepareNamedContext(ctx, insertRefreshTokenQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	if err := stmt.QueryRowxContext(ctx, next).Scan(&next.ID, &next.CreatedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET replaced_by = $1 WHERE id = $2`, next.ID, oldID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// revokeWhere revokes matching refresh tokens and denylists the live access tokens issued with them.
+func (r *tokenRepository) revokeWhere(ctx context.Context, where string, arg interface{}) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		 SELECT access_jti, user_id, access_expires_at FROM refresh_tokens
+		 WHERE `+where+` AND access_expires_at > CURRENT_TIMESTAMP
+		 ON CONFLICT (jti) DO NOTHING`,
+		arg,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE `+where+` AND revoked_at IS NULL`,
+		arg,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *tokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.revokeWhere(ctx, "family_id = $1", familyID)
+}
+
+func (r *tokenRepository) RevokeAllForUser(ctx context.Context, userID int64) error {
+	return r.revokeWhere(ctx, "user_id = $1", userID)
+}
+
+func (r *tokenRepository) RevokeAccessToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	_, err := r.DB.ExecContext(ctx,
+		`INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3) ON CONFLICT (jti) DO NOTHING`,
+		jti, userID, expiresAt,
+	)
+	return err
+}
+
+func (r *tokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	err := r.DB.GetContext(ctx, &revoked, "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)", jti)
+	return revoked, err
+}
+
+// DeleteExpired removes denylist entries and refresh tokens that can no longer be used.
+func (r *tokenRepository) DeleteExpired(ctx context.Context) error {
+	if _, err := r.DB.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < CURRENT_TIMESTAMP"); err != nil {
+		return err
+	}
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE expires_at < CURRENT_TIMESTAMP")
+	return err
+}
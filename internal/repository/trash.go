@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/user/car-project/internal/models"
+)
+
+// ErrDuplicate is returned when a restored row would clash with a live row on a unique
+// column, e.g. a new user has since taken a deleted user's email.
+var ErrDuplicate = errors.New("conflicts with an existing record")
+
+// uniqueViolation maps a Postgres unique_violation to ErrDuplicate and leaves other errors alone.
+func uniqueViolation(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// purgeRows runs a DELETE ... RETURNING id, row_to_json(...) with cutoff as $1 and records
+// each removed row in audit_log, in the same transaction.
+func purgeRows(ctx context.Context, db *sqlx.DB, entityType, query string, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := inTx(ctx, db, func(tx *sqlx.Tx) error {
+		type removed struct {
+			id  int64
+			row []byte
+		}
+		var rows []removed
+		res, err := tx.QueryContext(ctx, query, cutoff)
+		if err != nil {
+			return err
+		}
+		for res.Next() {
+			var r removed
+			if err := res.Scan(&r.id, &r.row); err != nil {
+				res.Close()
+				return err
+			}
+			rows = append(rows, r)
+		}
+		res.Close()
+		if err := res.Err(); err != nil {
+			return err
+		}
+
+		for _, r := range rows {
+			var before map[string]interface{}
+			if err := json.Unmarshal(r.row, &before); err != nil {
+				return err
+			}
+			if err := recordAudit(ctx, tx, models.AuditPurge, entityType, r.id, redact(before), nil); err != nil {
+				return err
+			}
+		}
+		purged = int64(len(rows))
+		return nil
+	})
+	return purged, err
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/user/car-project/internal/models"
@@ -14,8 +15,20 @@ type UserRepository interface {
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	GetByUsername(ctx context.Context, username string) (*models.User, error)
 	GetAll(ctx context.Context) ([]models.User, error)
+	// Update and Delete apply only if the user is still at the given version. They return
+	// sql.ErrNoRows when the user does not exist and ErrVersionConflict when it has changed.
+	// Update and Delete return ErrLastAdmin rather than deactivate or delete the last active
+	// administrator.
 	Update(ctx context.Context, user *models.User) error
-	Delete(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id, version int64, deletedBy *int64) error
+	UpdateLastLogin(ctx context.Context, id int64) error
+	UpdatePassword(ctx context.Context, id int64, passwordHash string, updatedBy *int64) error
+	GetDeleted(ctx context.Context) ([]models.User, error)
+	// Restore returns sql.ErrNoRows when the user is not in the trash and ErrDuplicate when
+	// their username or email has since been taken.
+	Restore(ctx context.Context, id int64, restoredBy *int64) error
+	// Purge permanently removes users deleted before cutoff and returns how many were removed.
+	Purge(ctx context.Context, cutoff time.Time) (int64, error)
 }
 
 type userRepository struct {
@@ -27,24 +40,20 @@ func NewUserRepository(db *sqlx.DB) UserRepository {
 }
 
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
-	query := `INSERT INTO users (name, username, email, password_hash, is_active, created_at, updated_at) 
-			  VALUES (:name, :username, :email, :password_hash, :is_active, :created_at, :updated_at) 
-			  RETURNING id`
+	query := `INSERT INTO users (name, username, email, password_hash, is_active, created_by, updated_by, created_at, updated_at) 
+			  VALUES (:name, :username, :email, :password_hash, :is_active, :created_by, :updated_by, :created_at, :updated_at) 
+			  RETURNING id, version`
 
-	// Use NamedQuery to get back the ID
-	stmt, err := r.DB.PrepareNamedContext(ctx, query)
-	if err != nil {
-		return err
-	}
-	defer stmt.Close()
+	return withAudit(ctx, r.DB, auditedRow{models.AuditCreate, models.AuditEntityUser, "users", &user.ID}, func(tx *sqlx.Tx) error {
+		// Use NamedQuery to get back the ID
+		stmt, err := tx.PrepareNamedContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
 
-	var id int64
-	err = stmt.QueryRowxContext(ctx, user).Scan(&id)
-	if err != nil {
-		return err
-	}
-	user.ID = id
-	return nil
+		return stmt.QueryRowxContext(ctx, user).Scan(&user.ID, &user.Version)
+	})
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
@@ -90,15 +99,86 @@ func (r *userRepository) GetAll(ctx context.Context) ([]models.User, error) {
 }
 
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
-	query := `UPDATE users SET name=:name, email=:email, is_active=:is_active, updated_at=CURRENT_TIMESTAMP 
-			  WHERE id=:id AND deleted_at IS NULL`
+	query := `UPDATE users SET name=:name, email=:email, is_active=:is_active, updated_by=:updated_by, updated_at=CURRENT_TIMESTAMP 
+			  WHERE id=:id AND version=:version AND deleted_at IS NULL RETURNING version`
 
-	_, err := r.DB.NamedExecContext(ctx, query, user)
-	return err
+	bound, args, err := sqlx.Named(query, user)
+	if err != nil {
+		return err
+	}
+	return withAudit(ctx, r.DB, auditedRow{models.AuditUpdate, models.AuditEntityUser, "users", &user.ID}, func(tx *sqlx.Tx) error {
+		if !user.IsActive {
+			if err := guardLastAdmin(ctx, tx, user.ID); err != nil {
+				return err
+			}
+		}
+		err := tx.QueryRowxContext(ctx, tx.Rebind(bound), args...).Scan(&user.Version)
+		if err == sql.ErrNoRows {
+			return versionMismatch(ctx, tx, "users", user.ID, "", nil)
+		}
+		return err
+	})
+}
+
+func (r *userRepository) Delete(ctx context.Context, id, version int64, deletedBy *int64) error {
+	return withAudit(ctx, r.DB, auditedRow{models.AuditDelete, models.AuditEntityUser, "users", &id}, func(tx *sqlx.Tx) error {
+		if err := guardLastAdmin(ctx, tx, id); err != nil {
+			return err
+		}
+		// Soft delete
+		res, err := tx.ExecContext(ctx,
+			"UPDATE users SET deleted_at = CURRENT_TIMESTAMP, updated_by = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND version = $2 AND deleted_at IS NULL",
+			id, version, deletedBy)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(res); err != nil {
+			return versionMismatch(ctx, tx, "users", id, "", nil)
+		}
+		return nil
+	})
 }
 
-func (r *userRepository) Delete(ctx context.Context, id int64) error {
-	// Soft delete
-	_, err := r.DB.ExecContext(ctx, "UPDATE users SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1", id)
+func (r *userRepository) UpdateLastLogin(ctx context.Context, id int64) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE users SET last_login_at = CURRENT_TIMESTAMP WHERE id = $1", id)
 	return err
 }
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id int64, passwordHash string, updatedBy *int64) error {
+	return withAudit(ctx, r.DB, auditedRow{models.AuditUpdate, models.AuditEntityUser, "users", &id}, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			"UPDATE users SET password_hash = $1, updated_by = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3 AND deleted_at IS NULL",
+			passwordHash, updatedBy, id)
+		if err != nil {
+			return err
+		}
+		return requireAffected(res)
+	})
+}
+
+func (r *userRepository) GetDeleted(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	err := r.DB.SelectContext(ctx, &users, "SELECT * FROM users WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
+	return users, err
+}
+
+func (r *userRepository) Restore(ctx context.Context, id int64, restoredBy *int64) error {
+	err := withAudit(ctx, r.DB, auditedRow{models.AuditRestore, models.AuditEntityUser, "users", &id}, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			"UPDATE users SET deleted_at = NULL, updated_by = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NOT NULL",
+			id, restoredBy)
+		if err != nil {
+			return err
+		}
+		return requireAffected(res)
+	})
+	return uniqueViolation(err)
+}
+
+// Purge skips users who placed orders, so that order history is never lost with them.
+func (r *userRepository) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	return purgeRows(ctx, r.DB, models.AuditEntityUser,
+		`DELETE FROM users WHERE deleted_at < $1
+		 AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.user_id = users.id)
+		 RETURNING id, row_to_json(users)`, cutoff)
+}
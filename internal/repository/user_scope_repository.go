@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/user/car-project/internal/models"
+)
+
+type UserScopeRepository interface {
+	GetByUserID(ctx context.Context, userID int64) ([]models.UserScope, error)
+	Add(ctx context.Context, scope *models.UserScope) error
+	Remove(ctx context.Context, userID int64, scopeType, value string) (bool, error)
+}
+
+type userScopeRepository struct {
+	DB *sqlx.DB
+}
+
+func NewUserScopeRepository(db *sqlx.DB) UserScopeRepository {
+	return &userScopeRepository{DB: db}
+}
+
+func (r *userScopeRepository) GetByUserID(ctx context.Context, userID int64) ([]models.UserScope, error) {
+	var scopes []models.UserScope
+	err := r.DB.SelectContext(ctx, &scopes,
+		"SELECT * FROM user_scopes WHERE user_id = $1 ORDER BY scope_type, value", userID)
+	return scopes, err
+}
+
+// Add inserts the scope; assigning one the user already has is a no-op.
+// New assignments are audited as a grant on the user.
+func (r *userScopeRepository) Add(ctx context.Context, scope *models.UserScope) error {
+	query := `INSERT INTO user_scopes (user_id, scope_type, value, assigned_by)
+			  VALUES (:user_id, :scope_type, :value, :assigned_by)
+			  ON CONFLICT (user_id, scope_type, value) DO NOTHING`
+	return inTx(ctx, r.DB, func(tx *sqlx.Tx) error {
+		res, err := tx.NamedExecContext(ctx, query, scope)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditGrant, models.AuditEntityUser, scope.UserID, nil,
+			map[string]interface{}{"scope_type": scope.ScopeType, "value": scope.Value})
+	})
+}
+
+func (r *userScopeRepository) Remove(ctx context.Context, userID int64, scopeType, value string) (bool, error) {
+	var removed bool
+	err := inTx(ctx, r.DB, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			"DELETE FROM user_scopes WHERE user_id = $1 AND scope_type = $2 AND value = $3",
+			userID, scopeType, value)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil || n == 0 {
+			return err
+		}
+		removed = true
+		return recordAudit(ctx, tx, models.AuditRevoke, models.AuditEntityUser, userID,
+			map[string]interface{}{"scope_type": scopeType, "value": value}, nil)
+	})
+	return removed, err
+}
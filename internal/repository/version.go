@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrVersionConflict is returned by conditional updates when the row exists but its version
+// no longer matches the one the caller read, i.e. someone else changed it in the meantime.
+var ErrVersionConflict = errors.New("row version does not match")
+
+// versionMismatch explains why a conditional UPDATE on table matched no rows: ErrVersionConflict
+// if the live row exists (within cond, a scope condition using ? placeholders), sql.ErrNoRows if
+// not. table must be a constant.
+func versionMismatch(ctx context.Context, tx *sqlx.Tx, table string, id int64, cond string, args []interface{}) error {
+	var exists bool
+	err := tx.QueryRowxContext(ctx,
+		tx.Rebind("SELECT EXISTS (SELECT 1 FROM "+table+" WHERE id = ? AND deleted_at IS NULL"+cond+")"),
+		append([]interface{}{id}, args...)...,
+	).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return sql.ErrNoRows
+}
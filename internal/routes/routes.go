@@ -2,24 +2,39 @@ package routes
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/user/car-project/internal/config"
 	"github.com/user/car-project/internal/db"
+	"github.com/user/car-project/internal/docgen"
+	"github.com/user/car-project/internal/events"
 	"github.com/user/car-project/internal/handlers"
 	"github.com/user/car-project/internal/middleware"
+	"github.com/user/car-project/internal/notify"
 	"github.com/user/car-project/internal/rag"
 	"github.com/user/car-project/internal/repository"
 	"github.com/user/car-project/internal/service"
+	"github.com/user/car-project/internal/utils"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	_ "github.com/user/car-project/docs"
 )
 
-func SetupRouter(jwtSecret string, jwtExpiryHours int, openAIKey, ragEmbedModel, ragChatModel string, ragTopK int) *gin.Engine {
+// SetupRouter wires repositories, services and handlers. jwtKeys signs and verifies access tokens.
+// reportService is shared with the background view refresher, so its refreshes empty the
+// cache the routes read.
+func SetupRouter(cfg *config.Config, jwtKeys *utils.KeySet, reportService service.ReportService) *gin.Engine {
 	r := gin.New()
+	// Only the configured proxies may set the client IP used for login lockout, API key
+	// allowlists and the audit trail.
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		utils.GetLogger().Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	r.Use(gin.Recovery())
 	r.Use(middleware.TrackIDMiddleware()) // track_id in context + response header; log every request so you can grep by track_id
+	r.Use(middleware.AuditContext())      // track_id and IP for audit_log entries
 	r.Use(gin.Logger())
 	r.Use(middleware.ETagMiddleware())
 
@@ -28,22 +43,75 @@ func SetupRouter(jwtSecret string, jwtExpiryHours int, openAIKey, ragEmbedModel,
 	userRepo := repository.NewUserRepository(db.DB)
 	roleRepo := repository.NewRoleRepository(db.DB)
 	permRepo := repository.NewPermissionRepository(db.DB)
+	tokenRepo := repository.NewTokenRepository(db.DB)
+	loginEventRepo := repository.NewLoginEventRepository(db.DB)
+	passwordResetRepo := repository.NewPasswordResetRepository(db.DB)
+	userScopeRepo := repository.NewUserScopeRepository(db.DB)
+	paymentRepo := repository.NewPaymentRepository(db.DB)
+	apiKeyRepo := repository.NewAPIKeyRepository(db.DB)
+	auditRepo := repository.NewAuditRepository(db.DB)
+	documentRepo := repository.NewDocumentRepository(db.DB)
+	orderRepo := repository.NewOrderRepository(db.DB)
+
+	// Role and permission changes are published here so cached permission sets are invalidated.
+	bus := events.NewBus()
 
 	// Initialize Services
 	carService := service.NewCarService(carRepo)
-	userService := service.NewUserService(userRepo, jwtSecret, jwtExpiryHours)
-	roleService := service.NewRoleService(roleRepo)
-	permService := service.NewPermissionService(permRepo)
+	roleService := service.NewRoleService(roleRepo, permRepo, userRepo, bus)
+	permService := service.NewPermissionService(permRepo, bus, cfg.PermissionCacheTTL)
+	scopeService := service.NewScopeService(userScopeRepo, userRepo, permService)
+	paymentService := service.NewPaymentService(paymentRepo)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, roleRepo, permRepo, bus)
+	auditService := service.NewAuditService(auditRepo)
+	trashService := service.NewTrashService(carRepo, userRepo, cfg.TrashRetention)
+	carImportService := service.NewCarImportService(carRepo)
+	carExportService := service.NewCarExportService(carRepo)
+	carDuplicateService := service.NewCarDuplicateService(carRepo)
+	orderService := service.NewOrderService(orderRepo, permService)
+	documentService := service.NewDocumentService(documentRepo,
+		docgen.NewRenderer(cfg.DocumentTemplateDir, docgen.HTTPImageLoader(10*time.Second, 10<<20)),
+		docgen.Company{Name: cfg.CompanyName, Address: cfg.CompanyAddress, Phone: cfg.CompanyPhone},
+		cfg.DocumentsDir)
+	var tokenAccess service.AccessResolver
+	if cfg.JWTEmbedPermissions {
+		tokenAccess = roleService
+	}
+	tokenService := service.NewTokenService(tokenRepo, userRepo, tokenAccess, jwtKeys, cfg.JWTAccessTTL, cfg.JWTRefreshTTL)
+	userService := service.NewUserService(userRepo, loginEventRepo, roleRepo, tokenService, service.LoginPolicy{
+		MaxFailuresPerUser: cfg.LoginMaxFailuresPerUser,
+		MaxFailuresPerIP:   cfg.LoginMaxFailuresPerIP,
+		Lockout:            cfg.LoginLockout,
+	}, cfg.PasswordPolicy)
+	passwordService := service.NewPasswordService(userRepo, passwordResetRepo, roleRepo, tokenService,
+		notify.NewFileNotifier(cfg.NotifyFile), cfg.PasswordPolicy,
+		service.PasswordResetConfig{TokenTTL: cfg.PasswordResetTTL, URL: cfg.PasswordResetURL})
 
 	// Initialize Handlers
 	carHandler := handlers.NewCarHandler(carService)
 	userHandler := handlers.NewUserHandler(userService)
-	authHandler := handlers.NewAuthHandler(userService)
+	authHandler := handlers.NewAuthHandler(userService, tokenService)
+	passwordHandler := handlers.NewPasswordHandler(passwordService)
+	meHandler := handlers.NewMeHandler(userService, roleService)
 	roleHandler := handlers.NewRoleHandler(roleService)
 	permHandler := handlers.NewPermissionHandler(permService)
+	scopeHandler := handlers.NewScopeHandler(scopeService)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	trashHandler := handlers.NewTrashHandler(trashService)
+	carImportHandler := handlers.NewCarImportHandler(carImportService)
+	carDuplicateHandler := handlers.NewCarDuplicateHandler(carDuplicateService)
+	carExportHandler := handlers.NewCarExportHandler(carExportService)
+	documentHandler := handlers.NewDocumentHandler(documentService)
+	orderHandler := handlers.NewOrderHandler(orderService)
+	reportHandler := handlers.NewReportHandler(reportService)
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Public keys for services that verify our access tokens
+	r.GET("/.well-known/jwks.json", handlers.NewJWKSHandler(jwtKeys).GetJWKS)
+
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"status": "up",
@@ -54,23 +122,37 @@ func SetupRouter(jwtSecret string, jwtExpiryHours int, openAIKey, ragEmbedModel,
 	{
 		// Public routes in api/v1
 		api.POST("/login", authHandler.Login)
+		api.POST("/auth/refresh", authHandler.Refresh)
+		api.POST("/auth/password-reset", passwordHandler.ResetPassword)
 	}
 
 	// Protected routes in api/v1
-	api.Use(middleware.AuthMiddleware(jwtSecret))
+	api.Use(middleware.AuthMiddleware(jwtKeys, tokenService, permService, apiKeyService))
 	{
+		api.POST("/auth/logout", authHandler.Logout)
+		api.GET("/me", meHandler.GetMe)
+		api.PUT("/me/password", passwordHandler.ChangePassword)
+
 		// User CRUD routes
-		users := api.Group("/users")
+		users := api.Group("/users", middleware.RequirePermission(permService, "user-manage"))
 		{
 			users.POST("", userHandler.CreateUser)
 			users.GET("", userHandler.GetUsers)
 			users.GET("/:id", userHandler.GetUserByID)
-			users.PUT("/:id", userHandler.UpdateUser)
-			users.DELETE("/:id", userHandler.DeleteUser)
+			users.PUT("/:id", middleware.RequireIfMatch(), userHandler.UpdateUser)
+			users.DELETE("/:id", middleware.RequireIfMatch(), userHandler.DeleteUser)
+			users.POST("/:id/restore", userHandler.RestoreUser)
+			users.GET("/:id/login-events", userHandler.GetLoginEvents)
+			users.POST("/:id/password-reset", passwordHandler.RequestReset)
+			users.GET("/:id/permissions", roleHandler.GetUserAccess)
+			users.GET("/:id/scopes", scopeHandler.GetUserScopes)
+			// Only users who see every row may hand out scopes, or a user-manage holder could widen their own.
+			users.POST("/:id/scopes", middleware.RequirePermission(permService, service.PermissionScopeBypass), scopeHandler.AddUserScope)
+			users.DELETE("/:id/scopes", middleware.RequirePermission(permService, service.PermissionScopeBypass), scopeHandler.RemoveUserScope)
 		}
 
 		// Role CRUD routes
-		roles := api.Group("/roles")
+		roles := api.Group("/roles", middleware.RequirePermission(permService, "role-manage"))
 		{
 			roles.POST("", roleHandler.CreateRole)
 			roles.GET("", roleHandler.GetRoles)
@@ -78,10 +160,14 @@ func SetupRouter(jwtSecret string, jwtExpiryHours int, openAIKey, ragEmbedModel,
 			roles.PUT("/:id", roleHandler.UpdateRole)
 			roles.DELETE("/:id", roleHandler.DeleteRole)
 			roles.POST("/assign", roleHandler.AssignRole)
+			roles.POST("/revoke", roleHandler.RevokeRole)
+			roles.GET("/:id/permissions", roleHandler.GetRolePermissions)
+			roles.POST("/:id/permissions", roleHandler.GrantPermissions)
+			roles.DELETE("/:id/permissions", roleHandler.RevokePermissions)
 		}
 
 		// Permission CRUD routes
-		perms := api.Group("/permissions")
+		perms := api.Group("/permissions", middleware.RequirePermission(permService, "permission-manage"))
 		{
 			perms.POST("", permHandler.CreatePermission)
 			perms.GET("", permHandler.GetPermissions)
@@ -90,27 +176,104 @@ func SetupRouter(jwtSecret string, jwtExpiryHours int, openAIKey, ragEmbedModel,
 			perms.DELETE("/:id", permHandler.DeletePermission)
 		}
 
-		// Car CRUD routes
-		cars := api.Group("/cars")
+		// API keys for service integrations
+		apiKeys := api.Group("/api-keys", middleware.RequirePermission(permService, "api-key-manage"))
+		{
+			apiKeys.POST("", apiKeyHandler.CreateAPIKey)
+			apiKeys.GET("", apiKeyHandler.GetAPIKeys)
+			apiKeys.GET("/:id", apiKeyHandler.GetAPIKeyByID)
+			apiKeys.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+		}
+
+		// Audit trail
+		api.GET("/audit-logs", middleware.RequirePermission(permService, "audit-read"), auditHandler.ListAuditLogs)
+
+		// Deleted cars and users awaiting purge; cars are limited to the caller's locations
+		trash := api.Group("/trash", middleware.RequirePermission(permService, "trash-manage"), middleware.DataScope(scopeService))
+		{
+			trash.GET("", trashHandler.GetTrash)
+			trash.POST("/purge", trashHandler.PurgeTrash)
+		}
+
+		// Car CRUD routes, limited to the caller's locations
+		cars := api.Group("/cars", middleware.DataScope(scopeService))
 		{
 			cars.POST("", middleware.RequirePermission(permService, "car-create"), carHandler.CreateCar)
+			cars.POST("/import", middleware.RequirePermission(permService, "car-create"), carImportHandler.ImportCars)
 			cars.GET("", middleware.RequirePermission(permService, "car-read"), carHandler.GetCars)
+			cars.GET("/export", middleware.RequirePermission(permService, "car-read"), carExportHandler.ExportCars)
 			cars.GET("/:id", middleware.RequirePermission(permService, "car-read"), carHandler.GetCarByID)
-			cars.PUT("/:id", middleware.RequirePermission(permService, "car-update"), carHandler.UpdateCar)
-			cars.DELETE("/:id", middleware.RequirePermission(permService, "car-delete"), carHandler.DeleteCar)
+			cars.PUT("/:id", middleware.RequirePermission(permService, "car-update"), middleware.RequireIfMatch(), carHandler.UpdateCar)
+			cars.PATCH("/:id", middleware.RequirePermission(permService, "car-update"), middleware.RequireIfMatch(), carHandler.PatchCar)
+			cars.POST("/:id/status", middleware.RequirePermission(permService, "car-update"), middleware.RequireIfMatch(), carHandler.ChangeCarStatus)
+			cars.GET("/:id/status-history", middleware.RequirePermission(permService, "car-read"), carHandler.GetCarStatusHistory)
+			cars.PUT("/:id/price", middleware.RequirePermission(permService, "car-update"), middleware.RequireIfMatch(), carHandler.SetCarPrice)
+			cars.GET("/:id/price-history", middleware.RequirePermission(permService, "car-read"), carHandler.GetCarPriceHistory)
+			cars.DELETE("/:id", middleware.RequirePermission(permService, "car-delete"), middleware.RequireIfMatch(), carHandler.DeleteCar)
+			cars.POST("/:id/restore", middleware.RequirePermission(permService, "car-delete"), carHandler.RestoreCar)
+			cars.GET("/:id/spec-sheet", middleware.RequirePermission(permService, "car-read"), documentHandler.GetSpecSheet)
+			cars.GET("/:id/duplicates", middleware.RequirePermission(permService, "car-read"), carDuplicateHandler.GetDuplicates)
+			cars.POST("/:id/merge", middleware.RequirePermission(permService, "car-merge"), carDuplicateHandler.MergeCars)
+		}
+
+		api.GET("/vin/:vin/decode", middleware.RequirePermission(permService, "car-read"), carHandler.DecodeVIN)
+
+		// Orders for cars in the caller's locations; selling below a car's minimum price needs price-override
+		api.POST("/orders", middleware.RequirePermission(permService, "order-create"), middleware.DataScope(scopeService), orderHandler.CreateOrder)
+
+		// Order invoices, limited to orders whose cars are all in the caller's locations
+		invoices := api.Group("/orders/:id/invoices", middleware.RequirePermission(permService, "invoice-manage"), middleware.DataScope(scopeService))
+		{
+			invoices.POST("", documentHandler.GenerateInvoice)
+			invoices.GET("", documentHandler.GetInvoices)
+			invoices.GET("/:document_id", documentHandler.DownloadInvoice)
+		}
+
+		// Payment history, limited to the caller's showrooms
+		payments := api.Group("/payments", middleware.RequirePermission(permService, "payment-read"), middleware.DataScope(scopeService))
+		{
+			payments.GET("", paymentHandler.GetPayments)
+			payments.GET("/:id", paymentHandler.GetPaymentByID)
+		}
+
+		// Sales and inventory reports, limited to the caller's locations (receivables to showrooms)
+		reports := api.Group("/reports", middleware.RequirePermission(permService, "report-read"), middleware.DataScope(scopeService))
+		{
+			reports.GET("/inventory-aging", reportHandler.GetInventoryAging)
+			reports.GET("/sell-through", reportHandler.GetSellThrough)
+			reports.GET("/revenue", reportHandler.GetRevenue)
+			reports.GET("/receivables", reportHandler.GetReceivables)
+			reports.GET("/stock-value", reportHandler.GetStockValue)
+		}
+
+		// Materialized views behind the reports: refresh status and on-demand refresh
+		reportViews := api.Group("/reports/views", middleware.RequirePermission(permService, "report-manage"))
+		{
+			reportViews.GET("", reportHandler.GetReportViews)
+			reportViews.POST("/refresh", reportHandler.RefreshReportViews)
 		}
 
 		// RAG routes (only when OpenAI API key is set)
-		if openAIKey != "" && db.DB != nil {
+		if cfg.OpenAIAPIKey != "" && db.DB != nil {
 			ragRepo := repository.NewRAGRepository(db.DB)
-			embedder := rag.NewOpenAIEmbedder(openAIKey, ragEmbedModel)
-			llm := rag.NewOpenAILLM(openAIKey, ragChatModel)
-			ragPipeline := rag.NewRAG(embedder, llm, ragRepo, ragTopK)
+			resilience := rag.ResilienceConfig{
+				MaxRetries:      cfg.RAGMaxRetries,
+				MaxBatchSize:    cfg.RAGEmbedBatchSize,
+				TokensPerMinute: cfg.RAGTokensPerMinute,
+			}
+			// Cache outside the resilience layer so cache hits never consume rate-limit budget.
+			embedder := rag.NewCachingEmbedder(
+				rag.NewResilientEmbedder(rag.NewOpenAIEmbedder(cfg.OpenAIAPIKey, cfg.RAGEmbeddingModel), resilience),
+				ragRepo, cfg.RAGEmbeddingModel,
+			)
+			llm := rag.NewResilientLLM(rag.NewOpenAILLM(cfg.OpenAIAPIKey, cfg.RAGChatModel), resilience)
+			ragPipeline := rag.NewRAG(embedder, llm, ragRepo, cfg.RAGTopK)
 			ragService := service.NewRAGService(ragPipeline)
 			ragHandler := handlers.NewRAGHandler(ragService)
-			ragGroup := api.Group("/rag")
+			ragGroup := api.Group("/rag", middleware.DataScope(scopeService))
 			{
 				ragGroup.POST("/ask", middleware.RequirePermission(permService, "rag-ask"), ragHandler.Ask)
+				ragGroup.POST("/ask/:id/feedback", middleware.RequirePermission(permService, "rag-ask"), ragHandler.Feedback)
 				ragGroup.POST("/index/cars", middleware.RequirePermission(permService, "rag-index"), ragHandler.IndexCars)
 			}
 		}
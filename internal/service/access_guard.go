@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// ensureNotLastAdmin returns utils.ErrLastAdmin when removing userID from the admin role
+// (by revoking, deactivating or deleting) would leave no active administrator. It fails
+// early; the repositories check again under a lock when they make the change.
+func ensureNotLastAdmin(ctx context.Context, roles repository.RoleRepository, userID int64) error {
+	isAdmin, err := roles.UserHasRole(ctx, userID, models.RoleSlugAdmin)
+	if err != nil || !isAdmin {
+		return err
+	}
+	others, err := roles.CountOtherActiveUsersWithRole(ctx, models.RoleSlugAdmin, userID)
+	if err != nil {
+		return err
+	}
+	if others == 0 {
+		return utils.ErrLastAdmin
+	}
+	return nil
+}
+
+// ensureCanManageUser returns utils.ErrForbidden when callerID, who is not an administrator,
+// changes, deletes or resets the password of userID, who is, so holding user-manage is not
+// enough to take over an administrator's account.
+func ensureCanManageUser(ctx context.Context, roles repository.RoleRepository, callerID, userID int64) error {
+	targetIsAdmin, err := roles.UserHasRole(ctx, userID, models.RoleSlugAdmin)
+	if err != nil || !targetIsAdmin {
+		return err
+	}
+	callerIsAdmin, err := roles.UserHasRole(ctx, callerID, models.RoleSlugAdmin)
+	if err != nil {
+		return err
+	}
+	if !callerIsAdmin {
+		return utils.ErrForbidden
+	}
+	return nil
+}
+
+// ensureWithinPermissions returns utils.ErrForbidden unless the caller holds every slug,
+// so nobody can hand out permissions they do not have themselves. Wildcards held by the
+// caller count; granting a wildcard requires holding that exact wildcard (or "*").
+func ensureWithinPermissions(ctx context.Context, perms repository.PermissionRepository, callerID int64, slugs []string) error {
+	held, err := perms.GetByUserID(ctx, callerID)
+	if err != nil {
+		return err
+	}
+	if !NewPermissionSet(held).HasAll(slugs...) {
+		return utils.ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// MockRoleRepository answers the admin-count queries used by ensureNotLastAdmin
+type MockRoleRepository struct {
+	repository.RoleRepository
+	admins map[int64]bool // user ID -> active
+}
+
+func (m *MockRoleRepository) UserHasRole(ctx context.Context, userID int64, roleSlug string) (bool, error) {
+	_, ok := m.admins[userID]
+	return ok, nil
+}
+
+func (m *MockRoleRepository) CountOtherActiveUsersWithRole(ctx context.Context, roleSlug string, excludeUserID int64) (int, error) {
+	n := 0
+	for id, active := range m.admins {
+		if id != excludeUserID && active {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// MockPermissionRepository returns a fixed permission set for every user
+type MockPermissionRepository struct {
+	repository.PermissionRepository
+	slugs     []string
+	version   int64
+	calls     int
+	deleteErr error
+}
+
+func (m *MockPermissionRepository) GetByUserID(ctx context.Context, userID int64) ([]string, error) {
+	m.calls++
+	return m.slugs, nil
+}
+
+func (m *MockPermissionRepository) GetUserPermissionsVersion(ctx context.Context, userID int64) (int64, error) {
+	return m.version, nil
+}
+
+func (m *MockPermissionRepository) Delete(ctx context.Context, id int64) error {
+	return m.deleteErr
+}
+
+func TestEnsureNotLastAdmin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("LastActiveAdmin", func(t *testing.T) {
+		roles := &MockRoleRepository{admins: map[int64]bool{1: true, 2: false}}
+		if err := ensureNotLastAdmin(ctx, roles, 1); err != utils.ErrLastAdmin {
+			t.Errorf("Expected ErrLastAdmin, got %v", err)
+		}
+	})
+
+	t.Run("AnotherActiveAdmin", func(t *testing.T) {
+		roles := &MockRoleRepository{admins: map[int64]bool{1: true, 2: true}}
+		if err := ensureNotLastAdmin(ctx, roles, 1); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("NotAnAdmin", func(t *testing.T) {
+		roles := &MockRoleRepository{admins: map[int64]bool{1: true}}
+		if err := ensureNotLastAdmin(ctx, roles, 3); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+}
+
+func TestEnsureCanManageUser(t *testing.T) {
+	ctx := context.Background()
+	roles := &MockRoleRepository{admins: map[int64]bool{1: true, 2: true}}
+
+	if err := ensureCanManageUser(ctx, roles, 3, 1); err != utils.ErrForbidden {
+		t.Errorf("Expected ErrForbidden for a non-admin changing an admin, got %v", err)
+	}
+	if err := ensureCanManageUser(ctx, roles, 2, 1); err != nil {
+		t.Errorf("Expected an admin to change another admin, got %v", err)
+	}
+	if err := ensureCanManageUser(ctx, roles, 3, 4); err != nil {
+		t.Errorf("Expected a non-admin to change a non-admin, got %v", err)
+	}
+}
+
+func TestEnsureWithinPermissions(t *testing.T) {
+	perms := &MockPermissionRepository{slugs: []string{"car-read", "role-manage"}}
+
+	if err := ensureWithinPermissions(context.Background(), perms, 1, []string{"car-read"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := ensureWithinPermissions(context.Background(), perms, 1, []string{"car-read", "car-delete"}); err != utils.ErrForbidden {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/events"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// APIKeyPrefix starts every API key so leaked keys are easy to recognise in logs and scanners.
+const APIKeyPrefix = "ck_"
+
+// serviceUserPasswordHash is not a valid bcrypt hash, so password login as an API key's service user always fails.
+const serviceUserPasswordHash = "!"
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, req dto.CreateAPIKeyRequest, createdBy int64) (*dto.CreatedAPIKeyResponse, error)
+	GetAPIKeys(ctx context.Context) ([]dto.APIKeyResponse, error)
+	GetAPIKeyByID(ctx context.Context, id int64) (*dto.APIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+	// Authenticate resolves a raw X-API-Key value presented from clientIP. It returns
+	// ErrUnauthorized for unknown, revoked or expired keys and ErrForbidden when clientIP is not allowed.
+	Authenticate(ctx context.Context, rawKey, clientIP string) (*models.APIKey, error)
+}
+
+type apiKeyService struct {
+	repo     repository.APIKeyRepository
+	roleRepo repository.RoleRepository
+	permRepo repository.PermissionRepository
+	bus      events.Bus
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, roleRepo repository.RoleRepository, permRepo repository.PermissionRepository, bus events.Bus) APIKeyService {
+	return &apiKeyService{repo: repo, roleRepo: roleRepo, permRepo: permRepo, bus: bus}
+}
+
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, req dto.CreateAPIKeyRequest, createdBy int64) (*dto.CreatedAPIKeyResponse, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, utils.ErrBadRequest
+	}
+	role, err := s.roleRepo.GetByID(ctx, req.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, utils.ErrNotFound
+	}
+
+	// A key may not carry permissions its creator does not hold.
+	perms, err := s.permRepo.GetByRoleID(ctx, req.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	slugs := make([]string, 0, len(perms))
+	for _, p := range perms {
+		slugs = append(slugs, p.Slug)
+	}
+	if err := ensureWithinPermissions(ctx, s.permRepo, createdBy, slugs); err != nil {
+		return nil, err
+	}
+
+	// The stored hash covers the prefixed key, so the bare token's hash is not used.
+	raw, _, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	rawKey := APIKeyPrefix + raw
+	prefix := rawKey[:len(APIKeyPrefix)+8]
+
+	allowed := req.AllowedIPs
+	if allowed == nil {
+		allowed = []string{}
+	}
+	key := &models.APIKey{
+		Name:       req.Name,
+		KeyPrefix:  prefix,
+		KeyHash:    utils.HashToken(rawKey),
+		RoleID:     req.RoleID,
+		AllowedIPs: allowed,
+		ExpiresAt:  req.ExpiresAt,
+		CreatedBy:  &createdBy,
+	}
+	user := &models.User{
+		Name:         "API key: " + req.Name,
+		Username:     "apikey-" + raw[:8],
+		Email:        "apikey-" + raw[:8] + "@api-keys.invalid",
+		PasswordHash: serviceUserPasswordHash,
+	}
+	if err := s.repo.Create(ctx, key, user); err != nil {
+		return nil, err
+	}
+
+	return &dto.CreatedAPIKeyResponse{APIKeyResponse: toAPIKeyResponse(key), Key: rawKey}, nil
+}
+
+func (s *apiKeyService) GetAPIKeys(ctx context.Context) ([]dto.APIKeyResponse, error) {
+	keys, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]dto.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		resp = append(resp, toAPIKeyResponse(&keys[i]))
+	}
+	return resp, nil
+}
+
+func (s *apiKeyService) GetAPIKeyByID(ctx context.Context, id int64) (*dto.APIKeyResponse, error) {
+	key, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, utils.ErrNotFound
+	}
+	resp := toAPIKeyResponse(key)
+	return &resp, nil
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id int64) error {
+	key, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return utils.ErrNotFound
+	}
+	revoked, err := s.repo.Revoke(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return utils.ErrNotFound
+	}
+	s.bus.Publish(events.AccessChanged, events.AccessChange{UserID: key.UserID})
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey, clientIP string) (*models.APIKey, error) {
+	key, err := s.repo.GetByHash(ctx, utils.HashToken(rawKey))
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now())) {
+		return nil, utils.ErrUnauthorized
+	}
+	if !ipAllowed(key.AllowedIPs, clientIP) {
+		return nil, utils.ErrForbidden
+	}
+	if err := s.repo.TouchLastUsed(ctx, key.ID); err != nil {
+		utils.GetLogger().Printf("Failed to record use of API key %d: %v", key.ID, err)
+	}
+	return key, nil
+}
+
+// ipAllowed reports whether ip matches one of the allowlist entries (single addresses or
+// CIDR ranges). An empty allowlist allows every address.
+func ipAllowed(allowlist []string, ip string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, entry := range allowlist {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(addr) {
+				return true
+			}
+		} else if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func toAPIKeyResponse(k *models.APIKey) dto.APIKeyResponse {
+	allowed := []string(k.AllowedIPs)
+	if allowed == nil {
+		allowed = []string{}
+	}
+	return dto.APIKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		KeyPrefix:  k.KeyPrefix,
+		UserID:     k.UserID,
+		RoleID:     k.RoleID,
+		AllowedIPs: allowed,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+		CreatedBy:  k.CreatedBy,
+		CreatedAt:  k.CreatedAt,
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// MockAPIKeyRepository stores keys by hash
+type MockAPIKeyRepository struct {
+	repository.APIKeyRepository
+	keys map[string]*models.APIKey
+}
+
+func (m *MockAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return m.keys[hash], nil
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(ctx context.Context, id int64) error {
+	return nil
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	past := time.Now().Add(-time.Hour)
+	repo := &MockAPIKeyRepository{keys: map[string]*models.APIKey{
+		utils.HashToken("ck_live"):    {ID: 1, UserID: 10},
+		utils.HashToken("ck_expired"): {ID: 2, UserID: 11, ExpiresAt: &past},
+		utils.HashToken("ck_revoked"): {ID: 3, UserID: 12, RevokedAt: &past},
+		utils.HashToken("ck_office"):  {ID: 4, UserID: 13, AllowedIPs: pq.StringArray{"10.0.0.0/8", "192.168.1.5"}},
+	}}
+	svc := NewAPIKeyService(repo, nil, nil, nil)
+
+	if key, err := svc.Authenticate(ctx, "ck_live", "1.2.3.4"); err != nil || key.UserID != 10 {
+		t.Errorf("Expected live key to authenticate as user 10, got %v", err)
+	}
+	for _, raw := range []string{"ck_unknown", "ck_expired", "ck_revoked"} {
+		if _, err := svc.Authenticate(ctx, raw, "1.2.3.4"); err != utils.ErrUnauthorized {
+			t.Errorf("Expected ErrUnauthorized for %s, got %v", raw, err)
+		}
+	}
+	if _, err := svc.Authenticate(ctx, "ck_office", "10.1.2.3"); err != nil {
+		t.Errorf("Expected CIDR match to be allowed, got %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, "ck_office", "192.168.1.5"); err != nil {
+		t.Errorf("Expected exact IP match to be allowed, got %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, "ck_office", "192.168.1.6"); err != utils.ErrForbidden {
+		t.Errorf("Expected ErrForbidden outside the allowlist, got %v", err)
+	}
+}
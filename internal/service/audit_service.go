@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+type AuditService interface {
+	// ListAuditLogs returns one page of matching entries, newest first, and the total number of matches.
+	ListAuditLogs(ctx context.Context, q dto.AuditLogQuery) ([]models.AuditLog, int64, error)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+func (s *auditService) ListAuditLogs(ctx context.Context, q dto.AuditLogQuery) ([]models.AuditLog, int64, error) {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Limit < 1 {
+		q.Limit = utils.DefaultPageSize
+	}
+	filter := repository.AuditFilter{
+		EntityType:  q.EntityType,
+		EntityID:    q.EntityID,
+		ActorUserID: q.ActorID,
+		From:        q.From,
+		Limit:       q.Limit,
+		Offset:      (q.Page - 1) * q.Limit,
+	}
+	if q.To != nil {
+		// Include the whole of the "to" day.
+		end := q.To.AddDate(0, 0, 1)
+		filter.To = &end
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, 0, utils.ErrBadRequest
+	}
+
+	entries, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	if entries == nil {
+		entries = []models.AuditLog{}
+	}
+	return entries, total, nil
+}
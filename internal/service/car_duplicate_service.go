@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// DuplicateConfidenceThreshold is the confidence from which a stored car is taken to be the
+// same car: creating or importing another one is refused unless duplicates are allowed.
+const DuplicateConfidenceThreshold = 0.8
+
+// duplicateEvidence is how strongly a match on each identifier suggests the same car. The
+// weights of all matches are combined as independent evidence, 1 - (1-w1)(1-w2)...; a match
+// on the model only counts together with an identifier.
+var duplicateEvidence = []struct {
+	field  string
+	weight float64
+}{
+	{"chassis_no_full", 0.95},
+	{"ref_no", 0.7},
+	{"engine_number", 0.6},
+}
+
+const sameModelWeight = 0.4
+
+// DuplicateCarError is returned when a new car is likely a duplicate of a stored one. It
+// matches ErrAlreadyExists with errors.Is.
+type DuplicateCarError struct {
+	Duplicates []dto.CarDuplicate
+}
+
+func (e *DuplicateCarError) Error() string {
+	d := e.Duplicates[0]
+	return fmt.Sprintf("likely the same car as car %d (confidence %.2f)", d.CarID, d.Confidence)
+}
+
+func (e *DuplicateCarError) Unwrap() error { return utils.ErrAlreadyExists }
+
+// CarDuplicateService finds stored cars that are probably the same physical car, and merges them.
+type CarDuplicateService interface {
+	// DuplicatesOf lists the likely duplicates of a stored car, most likely first.
+	DuplicatesOf(ctx context.Context, id int64) ([]dto.CarDuplicate, error)
+	// MergeCars moves the duplicate's photos, documents and LC links to the survivor and
+	// moves the duplicate to the trash. Merging a car into itself is ErrBadRequest.
+	MergeCars(ctx context.Context, survivorID, duplicateID int64) (*dto.CarMergeResult, error)
+}
+
+type carDuplicateService struct {
+	repo repository.CarRepository
+}
+
+func NewCarDuplicateService(repo repository.CarRepository) CarDuplicateService {
+	return &carDuplicateService{repo: repo}
+}
+
+func (s *carDuplicateService) DuplicatesOf(ctx context.Context, id int64) ([]dto.CarDuplicate, error) {
+	car, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, carError(err)
+	}
+	found, err := findDuplicates(ctx, s.repo, []models.Car{*car})
+	if err != nil {
+		return nil, err
+	}
+	if found[0] == nil {
+		return []dto.CarDuplicate{}, nil
+	}
+	return found[0], nil
+}
+
+func (s *carDuplicateService) MergeCars(ctx context.Context, survivorID, duplicateID int64) (*dto.CarMergeResult, error) {
+	if survivorID == duplicateID {
+		return nil, fmt.Errorf("%w: a car cannot be merged into itself", utils.ErrBadRequest)
+	}
+	counts, err := s.repo.Merge(ctx, survivorID, duplicateID)
+	if err != nil {
+		return nil, carError(err)
+	}
+	car, err := s.repo.GetByID(ctx, survivorID)
+	if err != nil {
+		return nil, carError(err)
+	}
+	return &dto.CarMergeResult{
+		Car:         *car,
+		MergedCarID: duplicateID,
+		Photos:      counts.Photos,
+		Documents:   counts.Documents,
+		LCLinks:     counts.LCLinks,
+	}, nil
+}
+
+// findDuplicates returns, for each car, the stored cars it may duplicate, most likely first.
+// A car never duplicates itself, so stored cars can be checked too. Stored cars in every
+// location are compared, but only the id of a car outside the caller's scope is returned.
+func findDuplicates(ctx context.Context, repo repository.CarRepository, cars []models.Car) ([][]dto.CarDuplicate, error) {
+	keys := map[string][]string{}
+	for i := range cars {
+		for _, e := range duplicateEvidence {
+			if k := identifierKey(&cars[i], e.field); k != "" {
+				keys[e.field] = append(keys[e.field], k)
+			}
+		}
+	}
+	found := make([][]dto.CarDuplicate, len(cars))
+	if len(keys) == 0 {
+		return found, nil
+	}
+	candidates, err := repo.DuplicateCandidates(ctx, keys["ref_no"], keys["chassis_no_full"], keys["engine_number"])
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range cars {
+		for j := range candidates {
+			if candidates[j].ID == cars[i].ID && cars[i].ID != 0 {
+				continue
+			}
+			if d, ok := scoreDuplicate(&cars[i], &candidates[j]); ok {
+				if !repository.LocationInScope(ctx, candidates[j].Location) {
+					d.Car = nil
+				}
+				found[i] = append(found[i], d)
+			}
+		}
+		sort.SliceStable(found[i], func(a, b int) bool { return found[i][a].Confidence > found[i][b].Confidence })
+	}
+	return found, nil
+}
+
+// scoreDuplicate reports whether candidate may be the same car as car, and how likely.
+func scoreDuplicate(car, candidate *models.Car) (dto.CarDuplicate, bool) {
+	stored := *candidate
+	d := dto.CarDuplicate{CarID: candidate.ID, Car: &stored}
+	miss := 1.0
+	for _, e := range duplicateEvidence {
+		if k := identifierKey(car, e.field); k != "" && k == identifierKey(candidate, e.field) {
+			d.MatchedOn = append(d.MatchedOn, e.field)
+			miss *= 1 - e.weight
+		}
+	}
+	if len(d.MatchedOn) == 0 {
+		return d, false
+	}
+	if car.ModelID != 0 && car.ModelID == candidate.ModelID {
+		d.MatchedOn = append(d.MatchedOn, "model_id")
+		miss *= 1 - sameModelWeight
+	}
+	d.Confidence = math.Round((1-miss)*100) / 100
+	return d, true
+}
+
+func identifierKey(car *models.Car, field string) string {
+	var v *string
+	switch field {
+	case "ref_no":
+		v = car.RefNo
+	case "chassis_no_full":
+		v = car.ChassisNoFull
+	case "engine_number":
+		v = car.EngineNumber
+	}
+	if v == nil {
+		return ""
+	}
+	return repository.IdentifierKey(*v)
+}
+
+// likelyDuplicates returns the duplicates at or above DuplicateConfidenceThreshold.
+func likelyDuplicates(found []dto.CarDuplicate) []dto.CarDuplicate {
+	var likely []dto.CarDuplicate
+	for _, d := range found {
+		if d.Confidence >= DuplicateConfidenceThreshold {
+			likely = append(likely, d)
+		}
+	}
+	return likely
+}
+
+// duplicateNote describes a possible duplicate for the hints of a response.
+func duplicateNote(d dto.CarDuplicate) string {
+	ref := "no ref_no"
+	if d.Car == nil {
+		ref = "outside your data scope"
+	} else if d.Car.RefNo != nil {
+		ref = "ref_no " + *d.Car.RefNo
+	}
+	return fmt.Sprintf("Car %d (%s) may be the same car, matching %s (confidence %.2f)",
+		d.CarID, ref, strings.Join(d.MatchedOn, ", "), d.Confidence)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestScoreDuplicate(t *testing.T) {
+	car := &models.Car{ModelID: 3, RefNo: strPtr("ab-123"), EngineNumber: strPtr("2ZR 0001")}
+
+	tests := []struct {
+		name       string
+		candidate  models.Car
+		confidence float64
+		matched    int
+	}{
+		{"RefNoFormatting", models.Car{ModelID: 4, RefNo: strPtr("AB 123")}, 0.7, 1},
+		{"RefNoAndModel", models.Car{ModelID: 3, RefNo: strPtr("AB123")}, 0.82, 2},
+		{"RefNoAndEngine", models.Car{ModelID: 4, RefNo: strPtr("AB.123"), EngineNumber: strPtr("2zr-0001")}, 0.88, 2},
+		{"ModelAlone", models.Car{ModelID: 3, RefNo: strPtr("XY-1")}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := scoreDuplicate(car, &tt.candidate)
+			if ok != (tt.matched > 0) {
+				t.Fatalf("Expected match %v, got %v", tt.matched > 0, ok)
+			}
+			if ok && (d.Confidence != tt.confidence || len(d.MatchedOn) != tt.matched) {
+				t.Errorf("Expected confidence %.2f on %d fields, got %.2f on %v", tt.confidence, tt.matched, d.Confidence, d.MatchedOn)
+			}
+		})
+	}
+}
+
+func TestDuplicatesOf(t *testing.T) {
+	repo := &MockRepository{cars: []models.Car{
+		{ID: 1, ModelID: 3, RefNo: strPtr("R-1")},
+		{ID: 2, ModelID: 3, RefNo: strPtr("r1")},
+		{ID: 3, ModelID: 3, RefNo: strPtr("R-2")},
+	}}
+	svc := &carDuplicateService{repo: &getByIDRepository{MockRepository: repo}}
+
+	found, err := svc.DuplicatesOf(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(found) != 1 || found[0].CarID != 2 || found[0].Confidence != 0.82 {
+		t.Errorf("Expected car 2 with confidence 0.82, got %+v", found)
+	}
+}
+
+func TestDuplicatesOutsideScope(t *testing.T) {
+	repo := &MockRepository{cars: []models.Car{
+		{ID: 1, ModelID: 3, ChassisNoFull: strPtr("JTDBR32E160012345"), Location: strPtr("Dhaka")},
+		{ID: 2, ModelID: 3, RefNo: strPtr("R-2"), ChassisNoFull: strPtr("JTDBR32E160012345"), Location: strPtr("Chattogram")},
+	}}
+	ctx := repository.WithScope(context.Background(), repository.Scope{Locations: []string{"Dhaka"}})
+
+	found, err := findDuplicates(ctx, repo, []models.Car{{ModelID: 3, ChassisNoFull: strPtr("JTDBR32E160012345")}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(found[0]) != 2 {
+		t.Fatalf("Expected both cars to match, got %+v", found[0])
+	}
+	for _, d := range found[0] {
+		if d.CarID == 1 && d.Car == nil {
+			t.Errorf("Expected the car in scope to be returned in full")
+		}
+		if d.CarID == 2 && (d.Car != nil || d.Confidence == 0 || len(d.MatchedOn) == 0) {
+			t.Errorf("Expected only the id, match and confidence of the car outside the scope, got %+v", d)
+		}
+	}
+}
+
+// getByIDRepository returns the stored car from MockRepository.cars.
+type getByIDRepository struct {
+	*MockRepository
+}
+
+func (r *getByIDRepository) GetByID(ctx context.Context, id int64) (*models.Car, error) {
+	for _, c := range r.cars {
+		if c.ID == id {
+			return &c, nil
+		}
+	}
+	return nil, utils.ErrNotFound
+}
+
+func TestMergeCars(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		repo := &MockRepository{}
+		result, err := NewCarDuplicateService(repo).MergeCars(ctx, 1, 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if repo.merged != [2]int64{1, 2} || result.MergedCarID != 2 || result.Photos != 2 || result.Car.ID != 1 {
+			t.Errorf("Expected car 2 merged into 1, got %+v", result)
+		}
+	})
+
+	t.Run("IntoItself", func(t *testing.T) {
+		if _, err := NewCarDuplicateService(&MockRepository{}).MergeCars(ctx, 1, 1); !errors.Is(err, utils.ErrBadRequest) {
+			t.Errorf("Expected ErrBadRequest, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/pdf"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/spreadsheet"
+	"github.com/user/car-project/internal/utils"
+)
+
+// Export formats accepted by CarExportService.
+const (
+	ExportCSV  = "csv"
+	ExportXLSX = "xlsx"
+	ExportPDF  = "pdf"
+)
+
+// CarExportService writes the car listing to files. Rows are streamed from the database to
+// the writer, so exports of any size use constant memory.
+type CarExportService interface {
+	// ExportCars writes the cars matching q to w: a spreadsheet with one row per car for
+	// ExportCSV and ExportXLSX, or a printable stock sheet grouped by location for ExportPDF.
+	// An unknown format or invalid filter is ErrBadRequest.
+	ExportCars(ctx context.Context, q dto.CarListQuery, format string, w io.Writer) error
+}
+
+type carExportService struct {
+	repo repository.CarRepository
+	now  func() time.Time
+}
+
+func NewCarExportService(repo repository.CarRepository) CarExportService {
+	return &carExportService{repo: repo, now: time.Now}
+}
+
+// carExportColumns are the spreadsheet columns. The headers are those accepted by the bulk
+// import, so an exported file can be edited and imported again.
+var carExportColumns = []struct {
+	header string
+	value  func(*repository.CarExportRow) interface{}
+}{
+	{"id", func(r *repository.CarExportRow) interface{} { return r.ID }},
+	{"make", func(r *repository.CarExportRow) interface{} { return r.Make }},
+	{"model", func(r *repository.CarExportRow) interface{} { return r.Model }},
+	{"ref_no", func(r *repository.CarExportRow) interface{} { return r.RefNo }},
+	{"package", func(r *repository.CarExportRow) interface{} { return r.Package }},
+	{"body_type", func(r *repository.CarExportRow) interface{} { return r.BodyType }},
+	{"year", func(r *repository.CarExportRow) interface{} { return r.Year }},
+	{"color", func(r *repository.CarExportRow) interface{} { return r.Color }},
+	{"reg_year_month", func(r *repository.CarExportRow) interface{} { return r.RegYearMonth }},
+	{"mileage_km", func(r *repository.CarExportRow) interface{} { return r.MileageKM }},
+	{"chassis_no_full", func(r *repository.CarExportRow) interface{} { return r.ChassisNoFull }},
+	{"engine_cc", func(r *repository.CarExportRow) interface{} { return r.EngineCC }},
+	{"fuel", func(r *repository.CarExportRow) interface{} { return r.Fuel }},
+	{"transmission", func(r *repository.CarExportRow) interface{} { return r.Transmission }},
+	{"drive", func(r *repository.CarExportRow) interface{} { return r.Drive }},
+	{"engine_number", func(r *repository.CarExportRow) interface{} { return r.EngineNumber }},
+	{"seats", func(r *repository.CarExportRow) interface{} { return r.Seats }},
+	{"number_of_keys", func(r *repository.CarExportRow) interface{} { return r.NumberOfKeys }},
+	{"keys_feature", func(r *repository.CarExportRow) interface{} { return r.KeysFeature }},
+	{"steering", func(r *repository.CarExportRow) interface{} { return r.Steering }},
+	{"location", func(r *repository.CarExportRow) interface{} { return r.Location }},
+	{"country_origin", func(r *repository.CarExportRow) interface{} { return r.CountryOrigin }},
+	{"status", func(r *repository.CarExportRow) interface{} { return r.Status }},
+	{"grade", func(r *repository.CarExportRow) interface{} { return r.GradeOverall }},
+	{"created_at", func(r *repository.CarExportRow) interface{} { return r.CreatedAt }},
+	{"updated_at", func(r *repository.CarExportRow) interface{} { return r.UpdatedAt }},
+}
+
+func (s *carExportService) ExportCars(ctx context.Context, q dto.CarListQuery, format string, w io.Writer) error {
+	filter, err := carFilter(q)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case ExportCSV, ExportXLSX:
+		return s.exportSpreadsheet(ctx, filter, spreadsheet.Format(format), w)
+	case ExportPDF:
+		return s.exportStockSheet(ctx, filter, w)
+	}
+	return utils.ErrBadRequest
+}
+
+func (s *carExportService) exportSpreadsheet(ctx context.Context, filter repository.CarFilter, format spreadsheet.Format, w io.Writer) error {
+	sw, err := spreadsheet.NewWriter(format, w)
+	if err != nil {
+		return err
+	}
+	headers := make([]interface{}, len(carExportColumns))
+	for i, col := range carExportColumns {
+		headers[i] = col.header
+	}
+	if err := sw.WriteRow(headers...); err != nil {
+		return err
+	}
+	values := make([]interface{}, len(carExportColumns))
+	err = s.repo.Export(ctx, filter, func(row *repository.CarExportRow) error {
+		for i, col := range carExportColumns {
+			values[i] = col.value(row)
+		}
+		return sw.WriteRow(values...)
+	})
+	if err != nil {
+		return err
+	}
+	return sw.Close()
+}
+
+// Stock sheet layout, in points on a landscape A4 page.
+const (
+	sheetMargin     = 36
+	sheetRowHeight  = 16
+	sheetFontSize   = 9
+	sheetHeaderSize = 16
+)
+
+// stockSheetColumns are the stock sheet columns: title, left edge, width and whether the
+// values are right-aligned.
+var stockSheetColumns = []struct {
+	title string
+	x     float64
+	width float64
+	right bool
+	value func(*repository.CarExportRow) string
+}{
+	{"Make / Model", sheetMargin, 300, false, func(r *repository.CarExportRow) string { return r.Make + " " + r.Model }},
+	{"Ref No", sheetMargin + 310, 130, false, func(r *repository.CarExportRow) string { return deref(r.RefNo) }},
+	{"Year", sheetMargin + 450, 50, true, func(r *repository.CarExportRow) string {
+		if r.Year == nil {
+			return ""
+		}
+		return strconv.Itoa(int(*r.Year))
+	}},
+	{"Mileage (km)", sheetMargin + 510, 90, true, func(r *repository.CarExportRow) string {
+		if r.MileageKM == nil {
+			return ""
+		}
+		return utils.GroupThousands(int64(*r.MileageKM))
+	}},
+	{"Grade", sheetMargin + 630, 50, false, func(r *repository.CarExportRow) string { return deref(r.GradeOverall) }},
+	{"Status", sheetMargin + 690, 80, false, func(r *repository.CarExportRow) string { return deref(r.Status) }},
+}
+
+// stockSheet lays out the stock sheet one car at a time, starting new pages as needed.
+type stockSheet struct {
+	doc       *pdf.Document
+	page      *pdf.Page
+	pageNo    int
+	y         float64
+	generated string
+	location  string
+	inGroup   int
+	total     int
+}
+
+func (s *carExportService) exportStockSheet(ctx context.Context, filter repository.CarFilter, w io.Writer) error {
+	now := s.now()
+	doc := pdf.New(w, pdf.A4Height, pdf.A4Width)
+	doc.SetTitle("Stock sheet " + now.Format("2006-01-02"))
+	sheet := &stockSheet{doc: doc, generated: now.Format("2006-01-02 15:04")}
+
+	first := true
+	err := s.repo.Export(ctx, filter, func(row *repository.CarExportRow) error {
+		location := deref(row.Location)
+		if location == "" {
+			location = "No location"
+		}
+		if first || location != sheet.location {
+			if !first {
+				sheet.endGroup()
+			}
+			sheet.startGroup(location)
+			first = false
+		}
+		sheet.car(row)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if first {
+		sheet.newPage()
+		sheet.page.Text(sheetMargin, sheet.y+sheetRowHeight, pdf.Helvetica, 11, "No cars match the selected filters.")
+	} else {
+		sheet.endGroup()
+		sheet.ensureSpace(2)
+		sheet.y += sheetRowHeight
+		sheet.page.Text(sheetMargin, sheet.y, pdf.HelveticaBold, 11, fmt.Sprintf("Total: %d cars", sheet.total))
+	}
+	return doc.Close()
+}
+
+// newPage starts a page with the title and page number.
+func (ss *stockSheet) newPage() {
+	ss.page = ss.doc.AddPage()
+	ss.pageNo++
+	ss.page.Text(sheetMargin, sheetMargin+sheetHeaderSize, pdf.HelveticaBold, sheetHeaderSize, "Stock Sheet")
+	ss.page.TextRight(ss.doc.Width()-sheetMargin, sheetMargin+sheetHeaderSize, pdf.Helvetica, sheetFontSize,
+		fmt.Sprintf("Generated %s  ·  Page %d", ss.generated, ss.pageNo))
+	ss.page.Line(sheetMargin, sheetMargin+sheetHeaderSize+8, ss.doc.Width()-sheetMargin, sheetMargin+sheetHeaderSize+8, 1)
+	ss.y = sheetMargin + sheetHeaderSize + 8
+}
+
+// ensureSpace starts a new page unless rows more rows fit on the current one, and reports
+// whether it did.
+func (ss *stockSheet) ensureSpace(rows int) bool {
+	if ss.page != nil && ss.y+float64(rows)*sheetRowHeight <= ss.doc.Height()-sheetMargin {
+		return false
+	}
+	ss.newPage()
+	return true
+}
+
+// heading draws the location band and the column titles.
+func (ss *stockSheet) heading(title string) {
+	ss.y += sheetRowHeight + 6
+	ss.page.FillRect(sheetMargin, ss.y-12, ss.doc.Width()-2*sheetMargin, sheetRowHeight, 0.88)
+	ss.page.Text(sheetMargin+4, ss.y, pdf.HelveticaBold, 11, title)
+	ss.y += sheetRowHeight
+	for _, col := range stockSheetColumns {
+		if col.right {
+			ss.page.TextRight(col.x+col.width, ss.y, pdf.HelveticaBold, sheetFontSize, col.title)
+		} else {
+			ss.page.Text(col.x, ss.y, pdf.HelveticaBold, sheetFontSize, col.title)
+		}
+	}
+	ss.page.Line(sheetMargin, ss.y+4, ss.doc.Width()-sheetMargin, ss.y+4, 0.5)
+}
+
+func (ss *stockSheet) startGroup(location string) {
+	ss.location = location
+	ss.inGroup = 0
+	ss.ensureSpace(4)
+	ss.heading(location)
+}
+
+func (ss *stockSheet) car(row *repository.CarExportRow) {
+	if ss.ensureSpace(1) {
+		ss.heading(ss.location + " (continued)")
+	}
+	ss.y += sheetRowHeight
+	for _, col := range stockSheetColumns {
+		text := pdf.Truncate(pdf.Helvetica, sheetFontSize, col.value(row), col.width)
+		if col.right {
+			ss.page.TextRight(col.x+col.width, ss.y, pdf.Helvetica, sheetFontSize, text)
+		} else {
+			ss.page.Text(col.x, ss.y, pdf.Helvetica, sheetFontSize, text)
+		}
+	}
+	ss.inGroup++
+	ss.total++
+}
+
+func (ss *stockSheet) endGroup() {
+	ss.ensureSpace(1)
+	ss.y += sheetRowHeight
+	ss.page.TextRight(ss.doc.Width()-sheetMargin, ss.y, pdf.HelveticaBold, sheetFontSize,
+		fmt.Sprintf("%s: %d cars", ss.location, ss.inGroup))
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
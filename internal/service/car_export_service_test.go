@@ -0,0 +1,59 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/models"
+)
+
+func TestExportCars(t *testing.T) {
+	ctx := context.Background()
+	ref, tokyo := "R1", "Tokyo"
+	mileage := int32(12500)
+	repo := &MockRepository{cars: []models.Car{
+		{ID: 1, RefNo: &ref, Location: &tokyo, MileageKM: &mileage},
+		{ID: 2},
+	}}
+	svc := NewCarExportService(repo)
+
+	t.Run("CSV", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := svc.ExportCars(ctx, dto.CarListQuery{}, ExportCSV, &buf); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		lines := strings.Split(strings.TrimPrefix(buf.String(), "\ufeff"), "\n")
+		if len(lines) != 4 || !strings.HasPrefix(lines[0], "id,make,model,ref_no,") {
+			t.Fatalf("Expected a header and 2 rows, got %q", lines)
+		}
+		if !strings.HasPrefix(lines[1], "1,,,R1,") || !strings.Contains(lines[1], ",12500,") {
+			t.Errorf("Expected car 1 with its ref_no and mileage, got %q", lines[1])
+		}
+	})
+
+	t.Run("StockSheet", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := svc.ExportCars(ctx, dto.CarListQuery{}, ExportPDF, &buf); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) || !bytes.Contains(buf.Bytes(), []byte("/Count 1")) {
+			t.Errorf("Expected a one-page PDF")
+		}
+	})
+
+	t.Run("RejectsInvalidRequests", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := svc.ExportCars(ctx, dto.CarListQuery{}, "xls", &buf); err == nil {
+			t.Errorf("Expected an error for an unknown format")
+		}
+		if err := svc.ExportCars(ctx, dto.CarListQuery{YearFrom: 2020, YearTo: 2010}, ExportCSV, &buf); err == nil {
+			t.Errorf("Expected an error for an empty year range")
+		}
+		if buf.Len() != 0 {
+			t.Errorf("Expected nothing to be written, got %q", buf.String())
+		}
+	})
+}
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/user/car-project/internal/chassis"
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// MaxImportRows caps the number of data rows in one import file.
+const MaxImportRows = 5000
+
+// CarImportService validates spreadsheets of cars and stores them in bulk.
+type CarImportService interface {
+	// ImportCars maps rows (the first row is the header) to cars and validates every row.
+	// When commit is set and no row has errors, all cars are written in one transaction;
+	// otherwise nothing is written. Possible duplicates of stored cars are listed in the
+	// report; likely ones are row errors unless allowDuplicates is set. Problems with the file
+	// as a whole wrap ErrBadRequest.
+	ImportCars(ctx context.Context, rows [][]string, commit, allowDuplicates bool) (*dto.CarImportReport, error)
+}
+
+type carImportService struct {
+	repo repository.CarRepository
+}
+
+func NewCarImportService(repo repository.CarRepository) CarImportService {
+	return &carImportService{repo: repo}
+}
+
+// carImportAliases lists, for each import field, the normalized header names accepted for it
+// besides the field's own name. "make" and "model" are resolved to model_id.
+var carImportAliases = map[string][]string{
+	"make":            {"maker", "manufacturer", "brand"},
+	"model":           {"model_name"},
+	"model_id":        nil,
+	"ref_no":          {"ref", "reference", "stock_no"},
+	"package":         nil,
+	"body_type":       {"body"},
+	"year":            {"model_year"},
+	"color":           {"colour"},
+	"reg_year_month":  {"registration"},
+	"mileage_km":      {"mileage", "km", "odometer"},
+	"chassis_no_full": {"chassis_no", "chassis", "vin"},
+	"engine_cc":       {"cc", "displacement"},
+	"fuel":            {"fuel_type"},
+	"transmission":    {"gearbox"},
+	"drive":           {"drivetrain"},
+	"engine_number":   {"engine_no"},
+	"seats":           nil,
+	"number_of_keys":  {"keys"},
+	"keys_feature":    nil,
+	"steering":        nil,
+	"location":        nil,
+	"country_origin":  {"origin"},
+	"status":          nil,
+}
+
+// carImportColumns maps every accepted normalized header name to its import field.
+var carImportColumns = func() map[string]string {
+	columns := map[string]string{}
+	for field, aliases := range carImportAliases {
+		columns[field] = field
+		for _, a := range aliases {
+			columns[a] = field
+		}
+	}
+	return columns
+}()
+
+// carImportEnums are the enum columns; values are matched case-insensitively and stored in
+// their canonical spelling.
+var carImportEnums = map[string][]string{
+	"body_type":    models.CarBodyTypes,
+	"fuel":         models.CarFuels,
+	"transmission": models.CarTransmissions,
+	"drive":        models.CarDrives,
+	"steering":     models.CarSteerings,
+}
+
+// normalizeHeader turns "Chassis No." or "chassis-no" into "chassis_no".
+func normalizeHeader(h string) string {
+	var b strings.Builder
+	sep := false
+	for _, r := range strings.ToLower(strings.TrimSpace(h)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if sep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			sep = false
+		} else {
+			sep = true
+		}
+	}
+	return b.String()
+}
+
+func modelKey(make, model string) string {
+	return strings.ToLower(strings.TrimSpace(make)) + "\x00" + strings.ToLower(strings.TrimSpace(model))
+}
+
+// importRow is one data row being validated.
+type importRow struct {
+	line int
+	car  models.Car
+}
+
+func (s *carImportService) ImportCars(ctx context.Context, rows [][]string, commit, allowDuplicates bool) (*dto.CarImportReport, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", utils.ErrBadRequest)
+	}
+	if len(rows)-1 > MaxImportRows {
+		return nil, fmt.Errorf("%w: the file has more than %d rows", utils.ErrBadRequest, MaxImportRows)
+	}
+
+	report := &dto.CarImportReport{Errors: []dto.CarImportRowError{}}
+	fields := make([]string, len(rows[0]))
+	mapped := map[string]bool{}
+	for i, h := range rows[0] {
+		if f, ok := carImportColumns[normalizeHeader(h)]; ok && !mapped[f] {
+			fields[i] = f
+			mapped[f] = true
+		} else if strings.TrimSpace(h) != "" {
+			report.IgnoredColumns = append(report.IgnoredColumns, h)
+		}
+	}
+	if !mapped["model_id"] && !(mapped["make"] && mapped["model"]) {
+		return nil, fmt.Errorf("%w: the header needs model_id, or make and model columns", utils.ErrBadRequest)
+	}
+
+	names, err := s.repo.ModelNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	modelIDs := map[string]int64{}
+	knownIDs := map[int64]bool{}
+	for _, n := range names {
+		modelIDs[modelKey(n.Make, n.Model)] = n.ID
+		knownIDs[n.ID] = true
+	}
+
+	rowErrors := map[int]bool{}
+	addError := func(line int, field, msg string) {
+		report.Errors = append(report.Errors, dto.CarImportRowError{Row: line, Field: field, Message: msg})
+		rowErrors[line] = true
+	}
+
+	var parsed []importRow
+	for i, row := range rows[1:] {
+		line := i + 2
+		values := map[string]string{}
+		for j, v := range row {
+			if j < len(fields) && fields[j] != "" {
+				if v = strings.TrimSpace(v); v != "" {
+					values[fields[j]] = v
+				}
+			}
+		}
+		if len(values) == 0 {
+			continue // blank line
+		}
+		report.TotalRows++
+
+		r := importRow{line: line}
+		for field, v := range values {
+			if err := setImportField(&r.car, field, v); err != nil {
+				addError(line, field, err.Error())
+			}
+		}
+		if _, ok := values["model_id"]; ok {
+			if r.car.ModelID != 0 && !knownIDs[r.car.ModelID] {
+				addError(line, "model_id", "unknown model_id")
+			}
+		} else if id, ok := modelIDs[modelKey(values["make"], values["model"])]; ok {
+			r.car.ModelID = id
+		} else {
+			addError(line, "model", fmt.Sprintf("unknown make/model %q %q", values["make"], values["model"]))
+		}
+		if r.car.ChassisNoFull != nil {
+			if info, err := chassis.Decode(*r.car.ChassisNoFull); err == nil {
+				fillFromChassis(&r.car, info)
+			}
+		}
+		if r.car.Status == nil {
+			available := "available"
+			r.car.Status = &available
+		} else if slices.Contains(models.CarStatuses, *r.car.Status) && !slices.Contains(carStartingStatuses, *r.car.Status) {
+			addError(line, "status", "a new car must be available or reserved")
+		}
+		if err := utils.ValidateStruct(&r.car); err != nil {
+			if ve, ok := err.(validator.ValidationErrors); ok {
+				for _, fe := range ve {
+					if field := carJSONName(fe.Field()); field != "model_id" || !rowErrors[line] {
+						addError(line, field, fmt.Sprintf("failed on the '%s' rule", fe.Tag()))
+					}
+				}
+			} else {
+				addError(line, "", err.Error())
+			}
+		}
+		if !repository.LocationInScope(ctx, r.car.Location) {
+			addError(line, "location", "location is outside your data scope")
+		}
+		parsed = append(parsed, r)
+	}
+
+	s.checkUniqueness(ctx, parsed, addError)
+	if err := s.checkTaken(ctx, parsed, addError); err != nil {
+		return nil, err
+	}
+	if err := s.checkDuplicates(ctx, parsed, report, allowDuplicates, addError); err != nil {
+		return nil, err
+	}
+
+	report.InvalidRows = len(rowErrors)
+	report.ValidRows = report.TotalRows - report.InvalidRows
+	if !commit || report.InvalidRows > 0 || len(parsed) == 0 {
+		return report, nil
+	}
+
+	cars := make([]models.Car, len(parsed))
+	for i, r := range parsed {
+		cars[i] = r.car
+	}
+	if err := carError(s.repo.CreateMany(ctx, cars)); err != nil {
+		return nil, err
+	}
+	report.Committed = true
+	for _, c := range cars {
+		report.CarIDs = append(report.CarIDs, c.ID)
+	}
+	// The report has no hints; unscoped locations are only logged.
+	unscopedLocationNotes(ctx, s.repo, cars)
+	return report, nil
+}
+
+// checkUniqueness reports ref_no and chassis numbers repeated within the file, ignoring case,
+// spaces and punctuation.
+func (s *carImportService) checkUniqueness(ctx context.Context, rows []importRow, addError func(int, string, string)) {
+	for _, field := range []string{"ref_no", "chassis_no_full"} {
+		seen := map[string]int{}
+		for i := range rows {
+			key := identifierKey(&rows[i].car, field)
+			if key == "" {
+				continue
+			}
+			if first, ok := seen[key]; ok {
+				addError(rows[i].line, field, fmt.Sprintf("duplicates row %d", first))
+			} else {
+				seen[key] = rows[i].line
+			}
+		}
+	}
+}
+
+// checkTaken reports ref_no and chassis numbers already used by cars in the database.
+func (s *carImportService) checkTaken(ctx context.Context, rows []importRow, addError func(int, string, string)) error {
+	var refNos, chassisNos []string
+	for _, r := range rows {
+		if r.car.RefNo != nil {
+			refNos = append(refNos, *r.car.RefNo)
+		}
+		if r.car.ChassisNoFull != nil {
+			chassisNos = append(chassisNos, *r.car.ChassisNoFull)
+		}
+	}
+	takenRefs, takenChassis, err := s.repo.TakenIdentifiers(ctx, refNos, chassisNos)
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if r.car.RefNo != nil && takenRefs[*r.car.RefNo] {
+			addError(r.line, "ref_no", "already used by another car")
+		}
+		if r.car.ChassisNoFull != nil && takenChassis[*r.car.ChassisNoFull] {
+			addError(r.line, "chassis_no_full", "already used by another car")
+		}
+	}
+	return nil
+}
+
+// checkDuplicates lists stored cars that may be the same car as a row, and reports rows
+// that likely are as errors unless allowDuplicates is set.
+func (s *carImportService) checkDuplicates(ctx context.Context, rows []importRow, report *dto.CarImportReport, allowDuplicates bool, addError func(int, string, string)) error {
+	cars := make([]models.Car, len(rows))
+	for i, r := range rows {
+		cars[i] = r.car
+	}
+	found, err := findDuplicates(ctx, s.repo, cars)
+	if err != nil {
+		return err
+	}
+	for i, r := range rows {
+		for _, d := range found[i] {
+			dup := dto.CarImportDuplicate{Row: r.line, CarID: d.CarID, MatchedOn: d.MatchedOn, Confidence: d.Confidence}
+			if d.Car != nil {
+				dup.RefNo = d.Car.RefNo
+			}
+			report.Duplicates = append(report.Duplicates, dup)
+		}
+		if likely := likelyDuplicates(found[i]); len(likely) > 0 && !allowDuplicates {
+			addError(r.line, likely[0].MatchedOn[0], fmt.Sprintf("likely the same car as car %d (confidence %.2f)", likely[0].CarID, likely[0].Confidence))
+		}
+	}
+	return nil
+}
+
+// setImportField parses one spreadsheet value into the car.
+func setImportField(car *models.Car, field, v string) error {
+	if allowed, ok := carImportEnums[field]; ok {
+		canonical := ""
+		for _, a := range allowed {
+			if strings.EqualFold(a, v) {
+				canonical = a
+			}
+		}
+		if canonical == "" {
+			return fmt.Errorf("%q is not one of %s", v, strings.Join(allowed, ", "))
+		}
+		v = canonical
+	}
+
+	switch field {
+	case "make", "model":
+		return nil
+	case "model_id":
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("%q is not a valid ID", v)
+		}
+		car.ModelID = id
+		return nil
+	case "year", "seats":
+		n, err := parseImportInt(v, 16)
+		if err != nil {
+			return err
+		}
+		i16 := int16(n)
+		if field == "year" {
+			car.Year = &i16
+		} else {
+			car.Seats = &i16
+		}
+		return nil
+	case "mileage_km", "engine_cc", "number_of_keys":
+		n, err := parseImportInt(v, 32)
+		if err != nil {
+			return err
+		}
+		i32 := int32(n)
+		switch field {
+		case "mileage_km":
+			car.MileageKM = &i32
+		case "engine_cc":
+			car.EngineCC = &i32
+		default:
+			car.NumberOfKeys = &i32
+		}
+		return nil
+	}
+
+	targets := map[string]**string{
+		"ref_no": &car.RefNo, "package": &car.Package, "body_type": &car.BodyType, "color": &car.Color,
+		"reg_year_month": &car.RegYearMonth, "chassis_no_full": &car.ChassisNoFull, "fuel": &car.Fuel,
+		"transmission": &car.Transmission, "drive": &car.Drive, "engine_number": &car.EngineNumber,
+		"keys_feature": &car.KeysFeature, "steering": &car.Steering, "location": &car.Location,
+		"country_origin": &car.CountryOrigin, "status": &car.Status,
+	}
+	switch field {
+	case "status":
+		v = strings.ToLower(v)
+	case "chassis_no_full":
+		info, err := chassis.Decode(v)
+		if err != nil {
+			return err
+		}
+		v = info.Number
+	}
+	*targets[field] = &v
+	return nil
+}
+
+// parseImportInt accepts whole numbers written with thousands separators ("12,500") or as
+// spreadsheet decimals ("1998.0").
+func parseImportInt(v string, bits int) (int64, error) {
+	clean := strings.NewReplacer(",", "", " ", "").Replace(v)
+	clean = strings.TrimSuffix(clean, ".0")
+	n, err := strconv.ParseInt(clean, 10, bits)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a valid number", v)
+	}
+	return n, nil
+}
+
+// carJSONName maps a models.Car Go field name, as reported by the validator, to its JSON name.
+func carJSONName(goName string) string {
+	if f, ok := reflect.TypeOf(models.Car{}).FieldByName(goName); ok {
+		return strings.Split(f.Tag.Get("json"), ",")[0]
+	}
+	return goName
+}
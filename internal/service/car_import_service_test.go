@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// MockImportRepository knows one model and one taken ref_no, and records created cars
+type MockImportRepository struct {
+	repository.CarRepository
+	created []models.Car
+}
+
+func (m *MockImportRepository) ModelNames(ctx context.Context) ([]repository.CarModelName, error) {
+	return []repository.CarModelName{{ID: 3, Make: "Toyota", Model: "Corolla"}}, nil
+}
+
+func (m *MockImportRepository) TakenIdentifiers(ctx context.Context, refNos, chassisNos []string) (map[string]bool, map[string]bool, error) {
+	return map[string]bool{"TAKEN": true}, map[string]bool{}, nil
+}
+
+// DuplicateCandidates knows one stored Corolla, R-100 with engine 2ZR 0001.
+func (m *MockImportRepository) DuplicateCandidates(ctx context.Context, refNos, chassisNos, engineNumbers []string) ([]models.Car, error) {
+	ref, engine := "R-100", "2ZR 0001"
+	stored := []models.Car{{ID: 50, ModelID: 3, RefNo: &ref, EngineNumber: &engine}}
+	return matchCandidates(stored, refNos, chassisNos, engineNumbers), nil
+}
+
+func (m *MockImportRepository) CreateMany(ctx context.Context, cars []models.Car) error {
+	for i := range cars {
+		cars[i].ID = int64(100 + i)
+	}
+	m.created = cars
+	return nil
+}
+
+func (m *MockImportRepository) UnscopedLocations(ctx context.Context, locations []string) ([]string, error) {
+	return nil, nil
+}
+
+func TestImportCars(t *testing.T) {
+	ctx := context.Background()
+	header := []string{"Make", "Model", "Ref No.", "Fuel Type", "Mileage", "Colour", "Notes"}
+
+	t.Run("CommitsValidRows", func(t *testing.T) {
+		repo := &MockImportRepository{}
+		svc := NewCarImportService(repo)
+		rows := [][]string{
+			header,
+			{"toyota", "COROLLA", "R1", "petrol", "12,500", "White", "ignored"},
+			{"", "", "", "", "", "", ""},
+			{"Toyota", "Corolla", "R2", "Hybrid", "800", "", ""},
+		}
+
+		report, err := svc.ImportCars(ctx, rows, true, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !report.Committed || report.TotalRows != 2 || len(report.CarIDs) != 2 {
+			t.Errorf("Expected 2 committed rows, got %+v", report)
+		}
+		if len(report.IgnoredColumns) != 1 || report.IgnoredColumns[0] != "Notes" {
+			t.Errorf("Expected Notes to be ignored, got %v", report.IgnoredColumns)
+		}
+		car := repo.created[0]
+		if car.ModelID != 3 || *car.Fuel != "Petrol" || *car.MileageKM != 12500 || *car.Status != "available" {
+			t.Errorf("Expected model 3, Petrol, 12500 km, available; got %d, %s, %d, %s",
+				car.ModelID, *car.Fuel, *car.MileageKM, *car.Status)
+		}
+	})
+
+	t.Run("ReportsRowErrorsWithoutWriting", func(t *testing.T) {
+		repo := &MockImportRepository{}
+		svc := NewCarImportService(repo)
+		rows := [][]string{
+			header,
+			{"Toyota", "Corolla", "R1", "Steam", "", "", ""},
+			{"Honda", "Civic", "R2", "", "", "", ""},
+			{"Toyota", "Corolla", "TAKEN", "", "", "", ""},
+			{"Toyota", "Corolla", "R5", "", "lots", "", ""},
+			{"Toyota", "Corolla", "R5", "", "", "", ""},
+		}
+
+		report, err := svc.ImportCars(ctx, rows, true, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if report.Committed || repo.created != nil {
+			t.Errorf("Expected nothing to be written")
+		}
+		if report.InvalidRows != 5 || report.ValidRows != 0 {
+			t.Errorf("Expected 5 invalid rows, got %+v", report)
+		}
+		want := map[int]string{2: "fuel", 3: "model", 4: "ref_no", 5: "mileage_km", 6: "ref_no"}
+		for _, e := range report.Errors {
+			if want[e.Row] != e.Field {
+				t.Errorf("Unexpected error %+v", e)
+			}
+		}
+	})
+
+	t.Run("RefusesLaterStartingStatus", func(t *testing.T) {
+		rows := [][]string{
+			{"Make", "Model", "Ref No.", "Status"},
+			{"Toyota", "Corolla", "R1", "reserved"},
+			{"Toyota", "Corolla", "R2", "sold"},
+			{"Toyota", "Corolla", "R3", "stolen"},
+		}
+		report, err := NewCarImportService(&MockImportRepository{}).ImportCars(ctx, rows, false, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(report.Errors) != 2 || report.Errors[0].Row != 3 || report.Errors[0].Field != "status" || report.Errors[1].Row != 4 {
+			t.Errorf("Expected status errors on rows 3 and 4, got %+v", report.Errors)
+		}
+	})
+
+	t.Run("ValidatesAndDecodesChassisNumbers", func(t *testing.T) {
+		repo := &MockImportRepository{}
+		rows := [][]string{
+			{"Make", "Model", "Ref No.", "VIN"},
+			{"Toyota", "Corolla", "R1", "jtdbr32e160012345"},
+			{"Toyota", "Corolla", "R2", "not a vin"},
+			{"Toyota", "Corolla", "R3", "JTDBR32E160012345"},
+		}
+		report, err := NewCarImportService(repo).ImportCars(ctx, rows, false, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		want := map[int]bool{3: true, 4: true}
+		if len(report.Errors) != 2 {
+			t.Errorf("Expected 2 errors, got %+v", report.Errors)
+		}
+		for _, e := range report.Errors {
+			if !want[e.Row] || e.Field != "chassis_no_full" {
+				t.Errorf("Unexpected error %+v", e)
+			}
+		}
+	})
+
+	t.Run("ReportsDuplicatesOfStoredCars", func(t *testing.T) {
+		rows := [][]string{
+			{"Make", "Model", "Ref No.", "Engine No."},
+			{"Toyota", "Corolla", "r 100", ""},
+			{"Toyota", "Corolla", "R2", "2ZR-0001"},
+			{"Toyota", "Corolla", "R-3", ""},
+		}
+		report, err := NewCarImportService(&MockImportRepository{}).ImportCars(ctx, rows, false, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(report.Duplicates) != 2 || report.Duplicates[0].Row != 2 || report.Duplicates[1].Row != 3 {
+			t.Errorf("Expected duplicates for rows 2 and 3, got %+v", report.Duplicates)
+		}
+		if len(report.Errors) != 1 || report.Errors[0].Row != 2 || report.Errors[0].Field != "ref_no" {
+			t.Errorf("Expected only row 2 to be rejected, got %+v", report.Errors)
+		}
+
+		report, err = NewCarImportService(&MockImportRepository{}).ImportCars(ctx, rows, false, true)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(report.Errors) != 0 || len(report.Duplicates) != 2 {
+			t.Errorf("Expected duplicates to be listed but allowed, got %+v", report)
+		}
+	})
+
+	t.Run("RejectsRepeatsWithinTheFile", func(t *testing.T) {
+		rows := [][]string{header, {"Toyota", "Corolla", "X-1"}, {"Toyota", "Corolla", "x1"}}
+		report, err := NewCarImportService(&MockImportRepository{}).ImportCars(ctx, rows, false, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(report.Errors) != 1 || report.Errors[0].Row != 3 || report.Errors[0].Field != "ref_no" {
+			t.Errorf("Expected row 3 to repeat row 2, got %+v", report.Errors)
+		}
+	})
+
+	t.Run("DryRunDoesNotWrite", func(t *testing.T) {
+		repo := &MockImportRepository{}
+		report, err := NewCarImportService(repo).ImportCars(ctx, [][]string{header, {"Toyota", "Corolla", "R1"}}, false, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if report.Committed || repo.created != nil || report.ValidRows != 1 {
+			t.Errorf("Expected 1 valid row and no writes, got %+v", report)
+		}
+	})
+
+	t.Run("RejectsHeaderWithoutModel", func(t *testing.T) {
+		_, err := NewCarImportService(&MockImportRepository{}).ImportCars(ctx, [][]string{{"ref_no", "color"}}, false, false)
+		if !errors.Is(err, utils.ErrBadRequest) {
+			t.Errorf("Expected ErrBadRequest, got %v", err)
+		}
+	})
+}
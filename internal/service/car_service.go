@@ -1,17 +1,60 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/user/car-project/internal/chassis"
+	"github.com/user/car-project/internal/dto"
 	"github.com/user/car-project/internal/models"
 	"github.com/user/car-project/internal/repository"
 	"github.com/user/car-project/internal/utils"
 )
 
 type CarService interface {
-	CreateCar(car *models.Car) error
-	GetCars() ([]models.Car, error)
-	GetCarByID(id int64) (*models.Car, error)
-	UpdateCar(car *models.Car) error
-	DeleteCar(id int64) error
+	// CreateCar stores a new car. Its chassis number must be a valid VIN or frame number
+	// (a *chassis.Error otherwise); year and country_origin left empty are filled in from a
+	// VIN. A car that is likely a duplicate of a stored one is refused with a
+	// *DuplicateCarError unless allowDuplicate is set. The returned notes describe the values
+	// filled in, a VIN issued by another make than the car model's and possible duplicates.
+	CreateCar(ctx context.Context, car *models.Car, allowDuplicate bool) ([]string, error)
+	// DecodeChassis decodes a VIN or frame number and matches its manufacturer to a car make.
+	DecodeChassis(ctx context.Context, number string) (*dto.ChassisDecodeResponse, error)
+	// GetCars lists the cars matching q, newest first. A year range that ends before it
+	// starts is ErrBadRequest.
+	GetCars(ctx context.Context, q dto.CarListQuery) ([]models.Car, error)
+	GetCarByID(ctx context.Context, id int64) (*models.Car, error)
+	// UpdateCar applies only if car.Version is still current, and sets car.Version to the new
+	// version. Like CreateCar, it rejects invalid chassis numbers. Changing the status is
+	// ErrBadRequest; it has its own transition rules, see ChangeStatus.
+	UpdateCar(ctx context.Context, car *models.Car) error
+	// PatchCar applies a JSON merge patch or JSON Patch (selected by contentType, see
+	// utils.ApplyPatch) to the car at version and writes only the fields it changes.
+	// Invalid patches fail with a *utils.PatchError, invalid values with validator.ValidationErrors.
+	PatchCar(ctx context.Context, id, version int64, patch []byte, contentType string) (*models.Car, error)
+	// ChangeStatus moves the car at version to another status, if carStatusTransitions
+	// allows it. Refused transitions are a *StatusTransitionError; a missing reason is
+	// ErrBadRequest.
+	ChangeStatus(ctx context.Context, id, version int64, req dto.CarStatusChangeRequest) (*models.Car, error)
+	// StatusHistory lists the car's status changes, oldest first.
+	StatusHistory(ctx context.Context, id int64) ([]models.CarStatusChange, error)
+	// Pricing returns the car's prices, cost basis and margin.
+	Pricing(ctx context.Context, id int64) (*models.CarPricing, error)
+	// SetPrice sets the list and minimum price of the car at version. A minimum above the
+	// list price is ErrBadRequest.
+	SetPrice(ctx context.Context, id, version int64, req dto.CarPriceRequest) (*models.Car, error)
+	// PriceHistory lists the car's price changes, oldest first.
+	PriceHistory(ctx context.Context, id int64) ([]models.CarPriceChange, error)
+	// DeleteCar moves the car to the trash if it is still at version.
+	DeleteCar(ctx context.Context, id, version int64) error
+	RestoreCar(ctx context.Context, id int64) error
 }
 
 type carService struct {
@@ -22,27 +65,416 @@ func NewCarService(repo repository.CarRepository) CarService {
 	return &carService{repo: repo}
 }
 
-func (s *carService) CreateCar(car *models.Car) error {
-	// Add business logic/validation here if needed
-	return s.repo.Create(car)
+func (s *carService) CreateCar(ctx context.Context, car *models.Car, allowDuplicate bool) ([]string, error) {
+	if car.Status == nil {
+		available := models.CarStatusAvailable
+		car.Status = &available
+	} else if !slices.Contains(carStartingStatuses, *car.Status) {
+		return nil, errStartingStatus
+	}
+	info, err := normalizeChassis(car)
+	if err != nil {
+		return nil, err
+	}
+	var notes []string
+	if info != nil {
+		notes = fillFromChassis(car, info)
+		if info.Manufacturer != "" {
+			carMake, err := s.repo.ModelMake(ctx, car.ModelID)
+			if err != nil && err != sql.ErrNoRows {
+				return nil, err
+			}
+			if carMake != nil && !strings.EqualFold(carMake.Name, info.Manufacturer) {
+				notes = append(notes, fmt.Sprintf("The VIN was issued to %s, but model %d is a %s", info.Manufacturer, car.ModelID, carMake.Name))
+			}
+		}
+	}
+
+	found, err := findDuplicates(ctx, s.repo, []models.Car{*car})
+	if err != nil {
+		return nil, err
+	}
+	if likely := likelyDuplicates(found[0]); len(likely) > 0 && !allowDuplicate {
+		return nil, &DuplicateCarError{Duplicates: likely}
+	}
+	for _, d := range found[0] {
+		notes = append(notes, duplicateNote(d))
+	}
+
+	if err := carError(s.repo.Create(ctx, car)); err != nil {
+		return nil, err
+	}
+	return append(notes, unscopedLocationNotes(ctx, s.repo, []models.Car{*car})...), nil
+}
+
+// unscopedLocationNotes warns about cars placed at a location that no user has in their
+// data scope: scoping is deny-by-default, so only holders of data-scope-bypass see them
+// until the location is assigned through /users/{id}/scopes.
+func unscopedLocationNotes(ctx context.Context, repo repository.CarRepository, cars []models.Car) []string {
+	seen := map[string]bool{}
+	var locations []string
+	for _, c := range cars {
+		if c.Location != nil && !seen[*c.Location] {
+			seen[*c.Location] = true
+			locations = append(locations, *c.Location)
+		}
+	}
+	unscoped, err := repo.UnscopedLocations(ctx, locations)
+	if err != nil {
+		utils.GetLogger().Printf("Failed to check data scopes of car locations: %v", err)
+		return nil
+	}
+	var notes []string
+	for _, l := range unscoped {
+		utils.GetLogger().Printf("Warning: no user is scoped to location %q; its cars are only visible with data-scope-bypass", l)
+		notes = append(notes, fmt.Sprintf("No user has location %s in their data scope, so only holders of data-scope-bypass can see this car until it is assigned", l))
+	}
+	return notes
+}
+
+func (s *carService) DecodeChassis(ctx context.Context, number string) (*dto.ChassisDecodeResponse, error) {
+	info, err := chassis.Decode(number)
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.ChassisDecodeResponse{Info: *info}
+	if info.Manufacturer != "" {
+		carMake, err := s.repo.MakeByName(ctx, info.Manufacturer)
+		switch err {
+		case nil:
+			resp.MakeID = &carMake.ID
+		case sql.ErrNoRows:
+		default:
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// normalizeChassis stores the car's chassis number in normalized form and decodes it. It
+// returns nil for a car without one, and a *chassis.Error for an invalid one.
+func normalizeChassis(car *models.Car) (*chassis.Info, error) {
+	if car.ChassisNoFull == nil {
+		return nil, nil
+	}
+	if strings.TrimSpace(*car.ChassisNoFull) == "" {
+		car.ChassisNoFull = nil
+		return nil, nil
+	}
+	info, err := chassis.Decode(*car.ChassisNoFull)
+	if err != nil {
+		return nil, err
+	}
+	car.ChassisNoFull = &info.Number
+	return info, nil
+}
+
+// fillFromChassis sets the car's year and country of origin from its decoded VIN when they
+// are empty, and returns a note for each value it filled in.
+func fillFromChassis(car *models.Car, info *chassis.Info) []string {
+	var notes []string
+	if car.Year == nil && info.ModelYear != nil {
+		year := int16(*info.ModelYear)
+		car.Year = &year
+		notes = append(notes, fmt.Sprintf("year %d was decoded from the VIN", year))
+	}
+	if (car.CountryOrigin == nil || strings.TrimSpace(*car.CountryOrigin) == "") && info.Country != "" {
+		country := info.Country
+		car.CountryOrigin = &country
+		notes = append(notes, fmt.Sprintf("country_origin %s was decoded from the %s", country, chassisKindName(info)))
+	}
+	return notes
 }
 
-func (s *carService) GetCars() ([]models.Car, error) {
-	return s.repo.GetAll()
+func chassisKindName(info *chassis.Info) string {
+	if info.Kind == chassis.KindFrame {
+		return "frame number"
+	}
+	return "VIN"
 }
 
-func (s *carService) GetCarByID(id int64) (*models.Car, error) {
-	car, err := s.repo.GetByID(id)
+func (s *carService) GetCars(ctx context.Context, q dto.CarListQuery) ([]models.Car, error) {
+	filter, err := carFilter(q)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetAll(ctx, filter)
+}
+
+// carFilter converts listing query parameters to a repository filter.
+func carFilter(q dto.CarListQuery) (repository.CarFilter, error) {
+	if q.YearFrom != 0 && q.YearTo != 0 && q.YearFrom > q.YearTo {
+		return repository.CarFilter{}, utils.ErrBadRequest
+	}
+	return repository.CarFilter{
+		MakeID:       q.MakeID,
+		ModelID:      q.ModelID,
+		Status:       q.Status,
+		Location:     q.Location,
+		Fuel:         q.Fuel,
+		BodyType:     q.BodyType,
+		Transmission: q.Transmission,
+		YearFrom:     q.YearFrom,
+		YearTo:       q.YearTo,
+		Search:       q.Q,
+	}, nil
+}
+
+func (s *carService) GetCarByID(ctx context.Context, id int64) (*models.Car, error) {
+	car, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, utils.ErrNotFound
 	}
 	return car, nil
 }
 
-func (s *carService) UpdateCar(car *models.Car) error {
-	return s.repo.Update(car)
+func (s *carService) UpdateCar(ctx context.Context, car *models.Car) error {
+	if car.Status != nil || car.ChassisNoFull != nil {
+		current, err := s.GetCarByID(ctx, car.ID)
+		if err != nil {
+			return err
+		}
+		if car.Status != nil && (current.Status == nil || *current.Status != *car.Status) {
+			return errStatusNotWritable
+		}
+		// Like PatchCar, only validate a VIN that changes, so cars saved with a VIN that
+		// predates validation can still be updated.
+		if car.ChassisNoFull != nil && (current.ChassisNoFull == nil || *current.ChassisNoFull != *car.ChassisNoFull) {
+			if _, err := normalizeChassis(car); err != nil {
+				return err
+			}
+		}
+	}
+	return carError(s.repo.Update(ctx, car))
+}
+
+// errStatusNotWritable rejects status changes through updates.
+var errStatusNotWritable = fmt.Errorf("%w: status can only be changed with POST /cars/{id}/status", utils.ErrBadRequest)
+
+func (s *carService) PatchCar(ctx context.Context, id, version int64, patch []byte, contentType string) (*models.Car, error) {
+	car, err := s.GetCarByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if car.Version != version {
+		return nil, utils.ErrPreconditionFailed
+	}
+
+	doc, err := json.Marshal(car)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := utils.ApplyPatch(doc, patch, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	var before, after map[string]interface{}
+	if err := json.Unmarshal(doc, &before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patched, &after); err != nil {
+		return nil, &utils.PatchError{Reason: "patched document must be a JSON object"}
+	}
+	fields := map[string]bool{}
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+	var changed []string
+	for field := range fields {
+		if reflect.DeepEqual(before[field], after[field]) {
+			continue
+		}
+		if models.CarNonPatchableFields[field] {
+			if field == "status" {
+				return nil, &utils.PatchError{Reason: "status can only be changed with POST /cars/{id}/status"}
+			}
+			return nil, &utils.PatchError{Reason: "field " + field + " is read-only"}
+		}
+		if _, known := before[field]; !known {
+			return nil, &utils.PatchError{Reason: "unknown field " + field}
+		}
+		changed = append(changed, field)
+	}
+	if len(changed) == 0 {
+		return car, nil
+	}
+
+	var updated models.Car
+	if err := json.Unmarshal(patched, &updated); err != nil {
+		return nil, &utils.PatchError{Reason: err.Error()}
+	}
+	if err := utils.ValidateFields(&updated, changed); err != nil {
+		return nil, err
+	}
+	for _, field := range changed {
+		if field == "chassis_no_full" {
+			if _, err := normalizeChassis(&updated); err != nil {
+				return nil, err
+			}
+		}
+	}
+	updated.ID, updated.Version = id, version
+	if err := carError(s.repo.Patch(ctx, &updated, changed)); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (s *carService) DeleteCar(ctx context.Context, id, version int64) error {
+	return carError(s.repo.Delete(ctx, id, version))
+}
+
+func (s *carService) RestoreCar(ctx context.Context, id int64) error {
+	return carError(s.repo.Restore(ctx, id))
+}
+
+// carStatusTransitions lists the statuses a car may move to from each status. A sold car
+// can only go back to available, when a sale falls through or the car is returned.
+var carStatusTransitions = map[string][]string{
+	models.CarStatusAvailable: {models.CarStatusReserved, models.CarStatusSold, models.CarStatusDamaged, models.CarStatusLost, models.CarStatusStolen},
+	models.CarStatusReserved:  {models.CarStatusAvailable, models.CarStatusSold, models.CarStatusDamaged, models.CarStatusLost, models.CarStatusStolen},
+	models.CarStatusSold:      {models.CarStatusAvailable},
+	models.CarStatusDamaged:   {models.CarStatusAvailable, models.CarStatusSold, models.CarStatusLost, models.CarStatusStolen},
+	models.CarStatusLost:      {models.CarStatusAvailable, models.CarStatusDamaged, models.CarStatusStolen},
+	models.CarStatusStolen:    {models.CarStatusAvailable, models.CarStatusDamaged, models.CarStatusLost},
+}
+
+// carStartingStatuses are the statuses a car may be created or imported with. It reaches
+// the others through ChangeStatus, so their transition and reason rules always apply.
+var carStartingStatuses = []string{models.CarStatusAvailable, models.CarStatusReserved}
+
+// errStartingStatus rejects creating a car in a status it can only reach through ChangeStatus.
+var errStartingStatus = fmt.Errorf("%w: a new car must be available or reserved; change its status with POST /cars/{id}/status", utils.ErrBadRequest)
+
+// carStatusesNeedingReason must be explained when a car is moved to them.
+var carStatusesNeedingReason = map[string]bool{models.CarStatusDamaged: true, models.CarStatusLost: true, models.CarStatusStolen: true}
+
+// StatusTransitionError explains why a car cannot move to a status. Allowed lists the
+// statuses it can move to. It matches ErrConflict with errors.Is.
+type StatusTransitionError struct {
+	From    string
+	To      string
+	Reason  string
+	Allowed []string
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("cannot change status from %s to %s: %s", e.From, e.To, e.Reason)
+}
+
+func (e *StatusTransitionError) Unwrap() error { return utils.ErrConflict }
+
+// checkStatusTransition reports whether a car may move from one status to another. A car
+// on an open order cannot be sold outside it.
+func checkStatusTransition(from, to string, openOrder bool) error {
+	allowed := carStatusTransitions[from]
+	switch {
+	case from == to:
+		return &StatusTransitionError{From: from, To: to, Reason: "the car is already " + to, Allowed: allowed}
+	case !slices.Contains(allowed, to):
+		return &StatusTransitionError{From: from, To: to, Reason: "transition not allowed", Allowed: allowed}
+	case to == models.CarStatusSold && openOrder:
+		return &StatusTransitionError{From: from, To: to, Reason: "the car is on an open order", Allowed: allowed}
+	}
+	return nil
+}
+
+func (s *carService) ChangeStatus(ctx context.Context, id, version int64, req dto.CarStatusChangeRequest) (*models.Car, error) {
+	var reason *string
+	if r := strings.TrimSpace(req.Reason); r != "" {
+		reason = &r
+	} else if carStatusesNeedingReason[req.Status] {
+		return nil, fmt.Errorf("%w: a reason is required when a car is %s", utils.ErrBadRequest, req.Status)
+	}
+	car, err := s.repo.ChangeStatus(ctx, id, version, req.Status, reason, func(from string, openOrder bool) error {
+		return checkStatusTransition(from, req.Status, openOrder)
+	})
+	if err != nil {
+		return nil, carError(err)
+	}
+	return car, nil
+}
+
+func (s *carService) StatusHistory(ctx context.Context, id int64) ([]models.CarStatusChange, error) {
+	if _, err := s.GetCarByID(ctx, id); err != nil {
+		return nil, err
+	}
+	changes, err := s.repo.StatusHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		changes = []models.CarStatusChange{}
+	}
+	return changes, nil
+}
+
+func (s *carService) Pricing(ctx context.Context, id int64) (*models.CarPricing, error) {
+	pricing, err := s.repo.Pricing(ctx, id)
+	if err != nil {
+		return nil, carError(err)
+	}
+	setMargin(pricing)
+	return pricing, nil
+}
+
+// setMargin fills in the margin of the list price over the cost basis, when both are known.
+func setMargin(p *models.CarPricing) {
+	if p.ListPrice == nil || p.CostBasis == nil {
+		return
+	}
+	margin := math.Round((*p.ListPrice-*p.CostBasis)*100) / 100
+	p.Margin = &margin
+	if *p.ListPrice > 0 {
+		percent := math.Round(margin / *p.ListPrice * 10000) / 100
+		p.MarginPercent = &percent
+	}
 }
 
-func (s *carService) DeleteCar(id int64) error {
-	return s.repo.Delete(id)
+func (s *carService) SetPrice(ctx context.Context, id, version int64, req dto.CarPriceRequest) (*models.Car, error) {
+	if req.MinPrice != nil && *req.MinPrice > req.ListPrice {
+		return nil, fmt.Errorf("%w: min_price cannot be above list_price", utils.ErrBadRequest)
+	}
+	var reason *string
+	if r := strings.TrimSpace(req.Reason); r != "" {
+		reason = &r
+	}
+	car, err := s.repo.SetPrice(ctx, id, version, req.ListPrice, req.MinPrice, reason)
+	if err != nil {
+		return nil, carError(err)
+	}
+	return car, nil
+}
+
+func (s *carService) PriceHistory(ctx context.Context, id int64) ([]models.CarPriceChange, error) {
+	if _, err := s.GetCarByID(ctx, id); err != nil {
+		return nil, err
+	}
+	changes, err := s.repo.PriceHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		changes = []models.CarPriceChange{}
+	}
+	return changes, nil
+}
+
+// carError maps repository errors for missing or out-of-scope cars to service errors.
+func carError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return utils.ErrNotFound
+	case errors.Is(err, repository.ErrOutOfScope):
+		return utils.ErrForbidden
+	case errors.Is(err, repository.ErrDuplicate):
+		return utils.ErrAlreadyExists
+	case errors.Is(err, repository.ErrVersionConflict):
+		return utils.ErrPreconditionFailed
+	}
+	return err
 }
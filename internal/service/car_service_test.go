@@ -1,35 +1,156 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-playground/validator/v10"
+	"github.com/user/car-project/internal/chassis"
+	"github.com/user/car-project/internal/dto"
 	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
 )
 
 // MockRepository is a simple mock for testing
 type MockRepository struct {
-	cars []models.Car
-	err  error
+	cars    []models.Car
+	err     error
+	patched []string
+	carMake *models.CarMake
+	merged  [2]int64
+	// unscoped is returned by UnscopedLocations.
+	unscoped []string
+	// status and openOrder are the current state seen by ChangeStatus.
+	status    string
+	openOrder bool
+	// pricing is returned by Pricing; SetPrice records its arguments in it.
+	pricing models.CarPricing
 }
 
-func (m *MockRepository) Create(car *models.Car) error  { return m.err }
-func (m *MockRepository) GetAll() ([]models.Car, error) { return m.cars, m.err }
-func (m *MockRepository) GetByID(id int64) (*models.Car, error) {
+func (m *MockRepository) Create(ctx context.Context, car *models.Car) error       { return m.err }
+func (m *MockRepository) CreateMany(ctx context.Context, cars []models.Car) error { return m.err }
+func (m *MockRepository) ModelNames(ctx context.Context) ([]repository.CarModelName, error) {
+	return nil, m.err
+}
+func (m *MockRepository) UnscopedLocations(ctx context.Context, locations []string) ([]string, error) {
+	return m.unscoped, nil
+}
+func (m *MockRepository) TakenIdentifiers(ctx context.Context, refNos, chassisNos []string) (map[string]bool, map[string]bool, error) {
+	return map[string]bool{}, map[string]bool{}, m.err
+}
+func (m *MockRepository) ModelMake(ctx context.Context, modelID int64) (*models.CarMake, error) {
+	if m.carMake == nil {
+		return nil, sql.ErrNoRows
+	}
+	return m.carMake, m.err
+}
+func (m *MockRepository) MakeByName(ctx context.Context, name string) (*models.CarMake, error) {
+	if m.carMake == nil || !strings.EqualFold(m.carMake.Name, name) {
+		return nil, sql.ErrNoRows
+	}
+	return m.carMake, m.err
+}
+func (m *MockRepository) DuplicateCandidates(ctx context.Context, refNos, chassisNos, engineNumbers []string) ([]models.Car, error) {
+	return matchCandidates(m.cars, refNos, chassisNos, engineNumbers), m.err
+}
+func (m *MockRepository) Merge(ctx context.Context, survivorID, duplicateID int64) (*repository.CarMergeCounts, error) {
+	m.merged = [2]int64{survivorID, duplicateID}
+	return &repository.CarMergeCounts{Photos: 2, Documents: 1}, m.err
+}
+func (m *MockRepository) ChangeStatus(ctx context.Context, id, version int64, status string, reason *string, allowed func(from string, openOrder bool) error) (*models.Car, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if err := allowed(m.status, m.openOrder); err != nil {
+		return nil, err
+	}
+	m.status = status
+	return &models.Car{ID: id, Status: &status, Version: version + 1}, nil
+}
+func (m *MockRepository) StatusHistory(ctx context.Context, id int64) ([]models.CarStatusChange, error) {
+	return nil, m.err
+}
+func (m *MockRepository) Pricing(ctx context.Context, id int64) (*models.CarPricing, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	pricing := m.pricing
+	return &pricing, nil
+}
+func (m *MockRepository) SetPrice(ctx context.Context, id, version int64, listPrice float64, minPrice *float64, reason *string) (*models.Car, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.pricing.ListPrice, m.pricing.MinPrice = &listPrice, minPrice
+	return &models.Car{ID: id, Version: version + 1}, nil
+}
+func (m *MockRepository) PriceHistory(ctx context.Context, id int64) ([]models.CarPriceChange, error) {
+	return nil, m.err
+}
+func (m *MockRepository) GetAll(ctx context.Context, filter repository.CarFilter) ([]models.Car, error) {
+	return m.cars, m.err
+}
+func (m *MockRepository) Export(ctx context.Context, filter repository.CarFilter, fn func(*repository.CarExportRow) error) error {
+	for _, c := range m.cars {
+		if err := fn(&repository.CarExportRow{Car: c}); err != nil {
+			return err
+		}
+	}
+	return m.err
+}
+func (m *MockRepository) GetByID(ctx context.Context, id int64) (*models.Car, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
 	return &models.Car{ID: id}, nil
 }
-func (m *MockRepository) Update(car *models.Car) error { return m.err }
-func (m *MockRepository) Delete(id int64) error        { return m.err }
+func (m *MockRepository) Update(ctx context.Context, car *models.Car) error { return m.err }
+func (m *MockRepository) Patch(ctx context.Context, car *models.Car, fields []string) error {
+	m.patched = fields
+	car.Version++
+	return m.err
+}
+func (m *MockRepository) Delete(ctx context.Context, id, version int64) error { return m.err }
+func (m *MockRepository) GetDeleted(ctx context.Context) ([]models.Car, error) {
+	return m.cars, m.err
+}
+func (m *MockRepository) Restore(ctx context.Context, id int64) error { return m.err }
+func (m *MockRepository) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	var n int64
+	for _, c := range m.cars {
+		if c.DeletedAt != nil && c.DeletedAt.Before(cutoff) {
+			n++
+		}
+	}
+	return n, m.err
+}
+
+// matchCandidates is DuplicateCandidates over cars.
+func matchCandidates(cars []models.Car, refNos, chassisNos, engineNumbers []string) []models.Car {
+	var found []models.Car
+	for _, c := range cars {
+		for field, keys := range map[string][]string{"ref_no": refNos, "chassis_no_full": chassisNos, "engine_number": engineNumbers} {
+			if k := identifierKey(&c, field); k != "" && slices.Contains(keys, k) {
+				found = append(found, c)
+				break
+			}
+		}
+	}
+	return found
+}
 
 func TestGetCarByID(t *testing.T) {
 	mockRepo := &MockRepository{}
 	svc := NewCarService(mockRepo)
 
 	t.Run("Success", func(t *testing.T) {
-		car, err := svc.GetCarByID(1)
+		car, err := svc.GetCarByID(context.Background(), 1)
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
@@ -40,9 +161,368 @@ func TestGetCarByID(t *testing.T) {
 
 	t.Run("NotFound", func(t *testing.T) {
 		mockRepo.err = errors.New("not found")
-		_, err := svc.GetCarByID(1)
+		_, err := svc.GetCarByID(context.Background(), 1)
 		if err == nil {
 			t.Error("Expected error, got nil")
 		}
 	})
 }
+
+func TestUpdateCarVersionConflict(t *testing.T) {
+	svc := NewCarService(&MockRepository{err: repository.ErrVersionConflict})
+
+	if err := svc.UpdateCar(context.Background(), &models.Car{ID: 1, Version: 2}); err != utils.ErrPreconditionFailed {
+		t.Errorf("Expected ErrPreconditionFailed, got %v", err)
+	}
+	if err := svc.DeleteCar(context.Background(), 1, 2); err != utils.ErrPreconditionFailed {
+		t.Errorf("Expected ErrPreconditionFailed, got %v", err)
+	}
+}
+
+func TestUpdateCarStatus(t *testing.T) {
+	ctx := context.Background()
+	svc := NewCarService(&getByIDRepository{&MockRepository{cars: []models.Car{{ID: 1, Status: strPtr("available")}}}})
+
+	if err := svc.UpdateCar(ctx, &models.Car{ID: 1, Status: strPtr("sold")}); !errors.Is(err, utils.ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest for a status change, got %v", err)
+	}
+	if err := svc.UpdateCar(ctx, &models.Car{ID: 1, Status: strPtr("available")}); err != nil {
+		t.Errorf("Expected the unchanged status to be accepted, got %v", err)
+	}
+}
+
+func TestPatchCar(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("WritesOnlyChangedFields", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		svc := NewCarService(mockRepo)
+		car, err := svc.PatchCar(ctx, 1, 0, []byte(`{"color":"Red","model_id":0}`), utils.MergePatchContentType)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if car.Color == nil || *car.Color != "Red" {
+			t.Errorf("Expected color Red, got %v", car.Color)
+		}
+		if len(mockRepo.patched) != 1 || mockRepo.patched[0] != "color" {
+			t.Errorf("Expected only color to be written, got %v", mockRepo.patched)
+		}
+	})
+
+	t.Run("JSONPatch", func(t *testing.T) {
+		mockRepo := &MockRepository{}
+		svc := NewCarService(mockRepo)
+		_, err := svc.PatchCar(ctx, 1, 0, []byte(`[{"op":"replace","path":"/mileage_km","value":1200}]`), utils.JSONPatchContentType)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(mockRepo.patched) != 1 || mockRepo.patched[0] != "mileage_km" {
+			t.Errorf("Expected only mileage_km to be written, got %v", mockRepo.patched)
+		}
+	})
+
+	t.Run("RejectsReadOnlyAndUnknownFields", func(t *testing.T) {
+		svc := NewCarService(&MockRepository{})
+		for _, patch := range []string{`{"version":9}`, `{"wheels":4}`} {
+			_, err := svc.PatchCar(ctx, 1, 0, []byte(patch), utils.MergePatchContentType)
+			if !errors.Is(err, utils.ErrBadRequest) {
+				t.Errorf("Expected bad request for %s, got %v", patch, err)
+			}
+		}
+	})
+
+	t.Run("ValidatesChangedFields", func(t *testing.T) {
+		svc := NewCarService(&getByIDRepository{&MockRepository{cars: []models.Car{{ID: 1, RefNo: strPtr("R1")}}}})
+		_, err := svc.PatchCar(ctx, 1, 0, []byte(`{"ref_no":null}`), utils.MergePatchContentType)
+		var fields validator.ValidationErrors
+		if !errors.As(err, &fields) {
+			t.Fatalf("Expected validation errors, got %v", err)
+		}
+		if len(fields) != 1 || fields[0].Field() != "RefNo" {
+			t.Errorf("Expected only ref_no to fail, got %v", fields)
+		}
+	})
+
+	t.Run("RejectsStatus", func(t *testing.T) {
+		svc := NewCarService(&MockRepository{})
+		_, err := svc.PatchCar(ctx, 1, 0, []byte(`{"status":"sold"}`), utils.MergePatchContentType)
+		var invalid *utils.PatchError
+		if !errors.As(err, &invalid) {
+			t.Errorf("Expected a patch error, got %v", err)
+		}
+	})
+
+	t.Run("StaleVersion", func(t *testing.T) {
+		svc := NewCarService(&MockRepository{})
+		if _, err := svc.PatchCar(ctx, 1, 5, []byte(`{"color":"Red"}`), utils.MergePatchContentType); err != utils.ErrPreconditionFailed {
+			t.Errorf("Expected ErrPreconditionFailed, got %v", err)
+		}
+	})
+}
+
+func TestCreateCarChassis(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("FillsYearAndCountryFromVIN", func(t *testing.T) {
+		svc := NewCarService(&MockRepository{carMake: &models.CarMake{ID: 3, Name: "Toyota"}})
+		vin := " jtdbr32e16001 2345 "
+		car := &models.Car{ModelID: 7, ChassisNoFull: &vin}
+		hints, err := svc.CreateCar(ctx, car, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if *car.ChassisNoFull != "JTDBR32E160012345" {
+			t.Errorf("Expected normalized VIN, got %s", *car.ChassisNoFull)
+		}
+		if car.Year == nil || *car.Year != 2006 {
+			t.Errorf("Expected year 2006, got %v", car.Year)
+		}
+		if car.CountryOrigin == nil || *car.CountryOrigin != "Japan" {
+			t.Errorf("Expected country Japan, got %v", car.CountryOrigin)
+		}
+		if len(hints) != 2 {
+			t.Errorf("Expected 2 hints, got %v", hints)
+		}
+	})
+
+	t.Run("KeepsGivenValuesAndWarnsOnMakeMismatch", func(t *testing.T) {
+		svc := NewCarService(&MockRepository{carMake: &models.CarMake{ID: 4, Name: "Nissan"}})
+		vin := "JTDBR32E160012345"
+		year := int16(2002)
+		country := "Japan"
+		car := &models.Car{ModelID: 7, ChassisNoFull: &vin, Year: &year, CountryOrigin: &country}
+		hints, err := svc.CreateCar(ctx, car, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if *car.Year != 2002 {
+			t.Errorf("Expected year to stay 2002, got %d", *car.Year)
+		}
+		if len(hints) != 1 || !strings.Contains(hints[0], "Nissan") {
+			t.Errorf("Expected a make mismatch hint, got %v", hints)
+		}
+	})
+
+	t.Run("RejectsInvalidChassisNumber", func(t *testing.T) {
+		svc := NewCarService(&MockRepository{})
+		bad := "1M8GDM9A1KP042788"
+		_, err := svc.CreateCar(ctx, &models.Car{ChassisNoFull: &bad}, false)
+		var invalid *chassis.Error
+		if !errors.As(err, &invalid) {
+			t.Errorf("Expected a chassis error, got %v", err)
+		}
+		if err := svc.UpdateCar(ctx, &models.Car{ID: 1, ChassisNoFull: &bad}); !errors.Is(err, chassis.ErrInvalid) {
+			t.Errorf("Expected ErrInvalid on update, got %v", err)
+		}
+		if _, err := svc.PatchCar(ctx, 1, 0, []byte(`{"chassis_no_full":"ABC"}`), utils.MergePatchContentType); !errors.Is(err, chassis.ErrInvalid) {
+			t.Errorf("Expected ErrInvalid on patch, got %v", err)
+		}
+	})
+
+	t.Run("KeepsUnchangedLegacyChassisNumber", func(t *testing.T) {
+		legacy := "CH-1"
+		svc := NewCarService(&getByIDRepository{&MockRepository{cars: []models.Car{{ID: 1, ChassisNoFull: &legacy}}}})
+		if err := svc.UpdateCar(ctx, &models.Car{ID: 1, ChassisNoFull: strPtr("CH-1")}); err != nil {
+			t.Errorf("Expected an unchanged chassis number to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("StatusWithoutChassisNumber", func(t *testing.T) {
+		stored := "CH-1"
+		svc := NewCarService(&getByIDRepository{&MockRepository{cars: []models.Car{{ID: 1, Status: strPtr("available"), ChassisNoFull: &stored}}}})
+		if err := svc.UpdateCar(ctx, &models.Car{ID: 1, Status: strPtr("available")}); err != nil {
+			t.Errorf("Expected an update without a chassis number to be accepted, got %v", err)
+		}
+	})
+}
+
+func TestCreateCarStartingStatus(t *testing.T) {
+	ctx := context.Background()
+	svc := NewCarService(&MockRepository{})
+
+	if _, err := svc.CreateCar(ctx, &models.Car{ModelID: 3, Status: strPtr("reserved")}, false); err != nil {
+		t.Errorf("Expected a reserved car to be created, got %v", err)
+	}
+	for _, status := range []string{"sold", "damaged", "lost", "stolen"} {
+		if _, err := svc.CreateCar(ctx, &models.Car{ModelID: 3, Status: strPtr(status)}, false); !errors.Is(err, utils.ErrBadRequest) {
+			t.Errorf("Expected ErrBadRequest creating a %s car, got %v", status, err)
+		}
+	}
+}
+
+func TestCreateCarWarnsOnUnscopedLocation(t *testing.T) {
+	location := "Chattogram"
+	svc := NewCarService(&MockRepository{unscoped: []string{location}})
+	hints, err := svc.CreateCar(context.Background(), &models.Car{ModelID: 3, Location: &location}, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(hints) != 1 || !strings.Contains(hints[0], "data-scope-bypass") {
+		t.Errorf("Expected a hint about the unscoped location, got %v", hints)
+	}
+}
+
+func TestCreateCarDuplicates(t *testing.T) {
+	ctx := context.Background()
+	ref, engine := "AB-123", "2ZR-0001"
+	repo := &MockRepository{cars: []models.Car{{ID: 9, ModelID: 3, RefNo: &ref, EngineNumber: &engine}}}
+	svc := NewCarService(repo)
+
+	t.Run("RefusesLikelyDuplicate", func(t *testing.T) {
+		newRef := "ab 123"
+		_, err := svc.CreateCar(ctx, &models.Car{ModelID: 3, RefNo: &newRef}, false)
+		var duplicate *DuplicateCarError
+		if !errors.As(err, &duplicate) || duplicate.Duplicates[0].CarID != 9 {
+			t.Fatalf("Expected a duplicate of car 9, got %v", err)
+		}
+		if !errors.Is(err, utils.ErrAlreadyExists) {
+			t.Errorf("Expected the error to match ErrAlreadyExists")
+		}
+	})
+
+	t.Run("AllowDuplicate", func(t *testing.T) {
+		newRef := "ab 123"
+		hints, err := svc.CreateCar(ctx, &models.Car{ModelID: 3, RefNo: &newRef}, true)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(hints) != 1 || !strings.Contains(hints[0], "Car 9") {
+			t.Errorf("Expected a hint about car 9, got %v", hints)
+		}
+	})
+
+	t.Run("HintsAtUnlikelyDuplicate", func(t *testing.T) {
+		newRef, newEngine := "CD-1", "2zr0001"
+		hints, err := svc.CreateCar(ctx, &models.Car{ModelID: 4, RefNo: &newRef, EngineNumber: &newEngine}, false)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(hints) != 1 || !strings.Contains(hints[0], "engine_number") {
+			t.Errorf("Expected a hint about the engine number, got %v", hints)
+		}
+	})
+}
+
+func TestChangeStatus(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		from      string
+		openOrder bool
+		req       dto.CarStatusChangeRequest
+		want      error
+	}{
+		{"Reserve", models.CarStatusAvailable, false, dto.CarStatusChangeRequest{Status: "reserved"}, nil},
+		{"DamagedWithReason", models.CarStatusReserved, false, dto.CarStatusChangeRequest{Status: "damaged", Reason: "Hail"}, nil},
+		{"DamagedWithoutReason", models.CarStatusAvailable, false, dto.CarStatusChangeRequest{Status: "damaged", Reason: "  "}, utils.ErrBadRequest},
+		{"SoldToReserved", models.CarStatusSold, false, dto.CarStatusChangeRequest{Status: "reserved"}, utils.ErrConflict},
+		{"Unchanged", models.CarStatusAvailable, false, dto.CarStatusChangeRequest{Status: "available"}, utils.ErrConflict},
+		{"SellWithOpenOrder", models.CarStatusReserved, true, dto.CarStatusChangeRequest{Status: "sold"}, utils.ErrConflict},
+		{"ReturnSoldCar", models.CarStatusSold, true, dto.CarStatusChangeRequest{Status: "available"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &MockRepository{status: tt.from, openOrder: tt.openOrder}
+			car, err := NewCarService(repo).ChangeStatus(ctx, 1, 3, tt.req)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, err)
+			}
+			if tt.want == nil && (*car.Status != tt.req.Status || car.Version != 4) {
+				t.Errorf("Expected status %s at version 4, got %s at %d", tt.req.Status, *car.Status, car.Version)
+			}
+		})
+	}
+
+	t.Run("RefusalListsAllowedStatuses", func(t *testing.T) {
+		_, err := NewCarService(&MockRepository{status: models.CarStatusSold}).ChangeStatus(ctx, 1, 3, dto.CarStatusChangeRequest{Status: "lost", Reason: "?"})
+		var refused *StatusTransitionError
+		if !errors.As(err, &refused) || len(refused.Allowed) != 1 || refused.Allowed[0] != models.CarStatusAvailable {
+			t.Errorf("Expected only available to be allowed, got %v", err)
+		}
+	})
+}
+
+func TestCarPricing(t *testing.T) {
+	ctx := context.Background()
+	price := func(v float64) *float64 { return &v }
+
+	t.Run("Margin", func(t *testing.T) {
+		repo := &MockRepository{pricing: models.CarPricing{ListPrice: price(2500000), CostBasis: price(2150000.5)}}
+		pricing, err := NewCarService(repo).Pricing(ctx, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if pricing.Margin == nil || *pricing.Margin != 349999.5 || pricing.MarginPercent == nil || *pricing.MarginPercent != 14 {
+			t.Errorf("Expected margin 349999.5 (14%%), got %v and %v", pricing.Margin, pricing.MarginPercent)
+		}
+	})
+
+	t.Run("NoMarginWithoutCost", func(t *testing.T) {
+		pricing, err := NewCarService(&MockRepository{pricing: models.CarPricing{ListPrice: price(2500000)}}).Pricing(ctx, 1)
+		if err != nil || pricing.Margin != nil || pricing.MarginPercent != nil {
+			t.Errorf("Expected no margin, got %v (%v)", pricing, err)
+		}
+	})
+
+	t.Run("MissingCar", func(t *testing.T) {
+		if _, err := NewCarService(&MockRepository{err: sql.ErrNoRows}).Pricing(ctx, 1); err != utils.ErrNotFound {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("SetPrice", func(t *testing.T) {
+		repo := &MockRepository{}
+		car, err := NewCarService(repo).SetPrice(ctx, 1, 3, dto.CarPriceRequest{ListPrice: 2500000, MinPrice: price(2300000)})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if car.Version != 4 || *repo.pricing.ListPrice != 2500000 || *repo.pricing.MinPrice != 2300000 {
+			t.Errorf("Expected prices 2500000/2300000 at version 4, got %+v at %d", repo.pricing, car.Version)
+		}
+	})
+
+	t.Run("MinimumAboveListPrice", func(t *testing.T) {
+		_, err := NewCarService(&MockRepository{}).SetPrice(ctx, 1, 3, dto.CarPriceRequest{ListPrice: 100, MinPrice: price(150)})
+		if !errors.Is(err, utils.ErrBadRequest) {
+			t.Errorf("Expected ErrBadRequest, got %v", err)
+		}
+	})
+
+	t.Run("StaleVersion", func(t *testing.T) {
+		_, err := NewCarService(&MockRepository{err: repository.ErrVersionConflict}).SetPrice(ctx, 1, 3, dto.CarPriceRequest{ListPrice: 100})
+		if err != utils.ErrPreconditionFailed {
+			t.Errorf("Expected ErrPreconditionFailed, got %v", err)
+		}
+	})
+}
+
+func TestCreateCarDefaultsStatus(t *testing.T) {
+	car := &models.Car{ModelID: 3}
+	if _, err := NewCarService(&MockRepository{}).CreateCar(context.Background(), car, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if car.Status == nil || *car.Status != models.CarStatusAvailable {
+		t.Errorf("Expected status available, got %v", car.Status)
+	}
+}
+
+func TestDecodeChassis(t *testing.T) {
+	svc := NewCarService(&MockRepository{carMake: &models.CarMake{ID: 3, Name: "Toyota"}})
+
+	decoded, err := svc.DecodeChassis(context.Background(), "JTDBR32E160012345")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if decoded.Manufacturer != "Toyota" || decoded.MakeID == nil || *decoded.MakeID != 3 {
+		t.Errorf("Expected Toyota with make_id 3, got %+v", decoded)
+	}
+
+	decoded, err = svc.DecodeChassis(context.Background(), "ZVW30-1234567")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if decoded.Kind != chassis.KindFrame || decoded.MakeID != nil {
+		t.Errorf("Expected an unmatched frame number, got %+v", decoded)
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/user/car-project/internal/docgen"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+const pdfMimeType = "application/pdf"
+
+// DocumentService renders printable documents. Spec sheets are rendered on request;
+// invoices are stored under the documents directory and recorded as documents of their order.
+type DocumentService interface {
+	// SpecSheet renders the car's spec sheet as a PDF.
+	SpecSheet(ctx context.Context, carID int64) ([]byte, error)
+	// GenerateInvoice renders and stores a new invoice for the order. An order without
+	// items is ErrBadRequest.
+	GenerateInvoice(ctx context.Context, orderID int64, generatedBy *int64) (*models.Document, error)
+	// ListInvoices lists the invoices generated for the order, newest first.
+	ListInvoices(ctx context.Context, orderID int64) ([]models.Document, error)
+	// OpenInvoice returns a stored invoice and the path of its file.
+	OpenInvoice(ctx context.Context, orderID, id int64) (*models.Document, string, error)
+}
+
+type documentService struct {
+	repo     repository.DocumentRepository
+	renderer *docgen.Renderer
+	company  docgen.Company
+	dir      string
+	now      func() time.Time
+}
+
+// NewDocumentService stores generated files under dir; document rows record their path
+// relative to it.
+func NewDocumentService(repo repository.DocumentRepository, renderer *docgen.Renderer, company docgen.Company, dir string) DocumentService {
+	return &documentService{repo: repo, renderer: renderer, company: company, dir: dir, now: time.Now}
+}
+
+func (s *documentService) SpecSheet(ctx context.Context, carID int64) ([]byte, error) {
+	data, err := s.repo.SpecSheet(ctx, carID)
+	if err == sql.ErrNoRows {
+		return nil, utils.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	sheet := docgen.SpecSheet{
+		Company:     s.company,
+		Car:         data.Car,
+		Make:        data.Make,
+		Model:       data.Model,
+		Grade:       data.Grade,
+		Detail:      data.Detail,
+		SubDetails:  data.SubDetails,
+		GeneratedAt: s.now(),
+	}
+	if data.PhotoURL != nil {
+		sheet.PhotoURL = *data.PhotoURL
+	}
+	var buf bytes.Buffer
+	title := fmt.Sprintf("%s %s spec sheet", data.Make, data.Model)
+	if err := s.renderer.Render(ctx, &buf, docgen.SpecSheetTemplate, title, sheet); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *documentService) GenerateInvoice(ctx context.Context, orderID int64, generatedBy *int64) (*models.Document, error) {
+	data, err := s.repo.Invoice(ctx, orderID)
+	if err == sql.ErrNoRows {
+		return nil, utils.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data.Items) == 0 {
+		return nil, utils.ErrBadRequest
+	}
+
+	now := s.now()
+	invoice := invoiceData(data, s.company, now)
+	var buf bytes.Buffer
+	if err := s.renderer.Render(ctx, &buf, docgen.InvoiceTemplate, "Invoice "+invoice.Number, invoice); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s-%s.pdf", invoice.Number, now.UTC().Format("20060102T150405.000"))
+	rel := filepath.Join("invoices", fmt.Sprint(orderID), name)
+	path := filepath.Join(s.dir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o640); err != nil {
+		return nil, err
+	}
+
+	size := int64(buf.Len())
+	mime := pdfMimeType
+	doc := &models.Document{
+		OrderID:      &orderID,
+		DocumentType: models.DocumentTypeInvoice,
+		FileName:     name,
+		FilePath:     rel,
+		FileSize:     &size,
+		MimeType:     &mime,
+		UploadedBy:   generatedBy,
+	}
+	if err := s.repo.Create(ctx, doc); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return doc, nil
+}
+
+// invoiceData fills the invoice template from an order. The customer is taken from the
+// payment history of the order's cars, falling back to the user who placed the order.
+func invoiceData(data *repository.InvoiceData, company docgen.Company, now time.Time) docgen.Invoice {
+	invoice := docgen.Invoice{
+		Company: company,
+		Number:  fmt.Sprintf("INV-%06d", data.Order.ID),
+		Date:    now,
+		Order:   data.Order,
+		Total:   data.Order.TotalAmount,
+	}
+	for i, item := range data.Items {
+		description := item.Make + " " + item.Model
+		if item.Year != nil {
+			description += fmt.Sprintf(" %d", *item.Year)
+		}
+		if item.Notes != nil && strings.TrimSpace(*item.Notes) != "" {
+			description += " (" + strings.TrimSpace(*item.Notes) + ")"
+		}
+		line := docgen.InvoiceLine{
+			No:          i + 1,
+			Description: description,
+			RefNo:       deref(item.RefNo),
+			ChassisNo:   deref(item.ChassisNo),
+			Quantity:    item.Quantity,
+			UnitPrice:   item.Price,
+			Amount:      item.Price * float64(item.Quantity),
+		}
+		invoice.Lines = append(invoice.Lines, line)
+		invoice.Subtotal += line.Amount
+	}
+
+	invoice.Customer = docgen.Customer{Name: data.Buyer.Name, Email: data.Buyer.Email, Address: deref(data.Order.ShippingAddress)}
+	if c := data.Customer; c != nil {
+		invoice.Customer.Name = deref(c.CustomerName)
+		invoice.Customer.NID = deref(c.NIDNumber)
+		invoice.Customer.TIN = deref(c.TinCertificate)
+		invoice.Customer.Phone = deref(c.ContactNumber)
+		if c.CustomerAddress != nil {
+			invoice.Customer.Address = *c.CustomerAddress
+		}
+		if c.Email != nil {
+			invoice.Customer.Email = *c.Email
+		}
+	}
+	return invoice
+}
+
+func (s *documentService) ListInvoices(ctx context.Context, orderID int64) ([]models.Document, error) {
+	docs, err := s.repo.ListByOrder(ctx, orderID, models.DocumentTypeInvoice)
+	if err != nil {
+		return nil, err
+	}
+	if docs == nil {
+		docs = []models.Document{}
+	}
+	return docs, nil
+}
+
+func (s *documentService) OpenInvoice(ctx context.Context, orderID, id int64) (*models.Document, string, error) {
+	doc, err := s.repo.GetByOrder(ctx, orderID, id)
+	if err == sql.ErrNoRows {
+		return nil, "", utils.ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if doc.DocumentType != models.DocumentTypeInvoice {
+		return nil, "", utils.ErrNotFound
+	}
+	path := filepath.Join(s.dir, filepath.Clean(doc.FilePath))
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, "", utils.ErrNotFound
+	} else if err != nil {
+		return nil, "", err
+	}
+	return doc, path, nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/user/car-project/internal/docgen"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// MockDocumentRepository serves one order and records created documents
+type MockDocumentRepository struct {
+	repository.DocumentRepository
+	invoice   *repository.InvoiceData
+	created   []models.Document
+	createErr error
+}
+
+func (m *MockDocumentRepository) Invoice(ctx context.Context, orderID int64) (*repository.InvoiceData, error) {
+	if m.invoice == nil || m.invoice.Order.ID != orderID {
+		return nil, sql.ErrNoRows
+	}
+	return m.invoice, nil
+}
+
+func (m *MockDocumentRepository) Create(ctx context.Context, doc *models.Document) error {
+	if m.createErr != nil {
+		return m.createErr
+	}
+	doc.ID = int64(len(m.created) + 1)
+	m.created = append(m.created, *doc)
+	return nil
+}
+
+func (m *MockDocumentRepository) GetByOrder(ctx context.Context, orderID, id int64) (*models.Document, error) {
+	for _, d := range m.created {
+		if d.ID == id && d.OrderID != nil && *d.OrderID == orderID {
+			return &d, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func testInvoiceData() *repository.InvoiceData {
+	year := int16(2019)
+	name, nid := "Karim Traders", "1990123456"
+	return &repository.InvoiceData{
+		Order: models.Order{ID: 42, UserID: 3, TotalAmount: 2400000, Status: "approved"},
+		Items: []repository.InvoiceItem{
+			{OrderItem: models.OrderItem{CarID: 1, Quantity: 1, Price: 1250000}, Make: "Toyota", Model: "Axio", Year: &year},
+			{OrderItem: models.OrderItem{CarID: 2, Quantity: 1, Price: 1200000}, Make: "Honda", Model: "Vezel"},
+		},
+		Customer: &models.PaymentHistory{CustomerName: &name, NIDNumber: &nid},
+		Buyer:    models.User{ID: 3, Name: "Sales Desk", Email: "desk@example.com"},
+	}
+}
+
+func TestDocumentService_GenerateInvoice(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 5, 10, 15, 0, 0, time.UTC)
+	newService := func(repo *MockDocumentRepository) (*documentService, string) {
+		dir := t.TempDir()
+		return &documentService{repo: repo, renderer: docgen.NewRenderer("", nil), dir: dir, now: func() time.Time { return now }}, dir
+	}
+
+	t.Run("StoresPDFAndDocument", func(t *testing.T) {
+		repo := &MockDocumentRepository{invoice: testInvoiceData()}
+		svc, dir := newService(repo)
+		userID := int64(9)
+		doc, err := svc.GenerateInvoice(ctx, 42, &userID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if doc.DocumentType != models.DocumentTypeInvoice || doc.OrderID == nil || *doc.OrderID != 42 || doc.CarID != nil {
+			t.Errorf("Expected an invoice document of order 42, got %+v", doc)
+		}
+		if doc.UploadedBy == nil || *doc.UploadedBy != 9 {
+			t.Errorf("Expected the document to record user 9")
+		}
+		data, err := os.ReadFile(filepath.Join(dir, doc.FilePath))
+		if err != nil {
+			t.Fatalf("Expected the file to be stored, got %v", err)
+		}
+		if !bytes.HasPrefix(data, []byte("%PDF-")) || doc.FileSize == nil || *doc.FileSize != int64(len(data)) {
+			t.Errorf("Expected a PDF of the recorded size")
+		}
+
+		_, path, err := svc.OpenInvoice(ctx, 42, doc.ID)
+		if err != nil || path != filepath.Join(dir, doc.FilePath) {
+			t.Errorf("Expected to open the stored invoice, got %q, %v", path, err)
+		}
+		if _, _, err := svc.OpenInvoice(ctx, 43, doc.ID); err != utils.ErrNotFound {
+			t.Errorf("Expected ErrNotFound for another order, got %v", err)
+		}
+	})
+
+	t.Run("RemovesFileWhenInsertFails", func(t *testing.T) {
+		repo := &MockDocumentRepository{invoice: testInvoiceData(), createErr: errors.New("db down")}
+		svc, dir := newService(repo)
+		if _, err := svc.GenerateInvoice(ctx, 42, nil); err == nil {
+			t.Fatalf("Expected an error")
+		}
+		files, _ := os.ReadDir(filepath.Join(dir, "invoices", "42"))
+		if len(files) != 0 {
+			t.Errorf("Expected no stored files, got %d", len(files))
+		}
+	})
+
+	t.Run("UnknownOrder", func(t *testing.T) {
+		svc, _ := newService(&MockDocumentRepository{invoice: testInvoiceData()})
+		if _, err := svc.GenerateInvoice(ctx, 7, nil); err != utils.ErrNotFound {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("EmptyOrder", func(t *testing.T) {
+		data := testInvoiceData()
+		data.Items = nil
+		svc, _ := newService(&MockDocumentRepository{invoice: data})
+		if _, err := svc.GenerateInvoice(ctx, 42, nil); err != utils.ErrBadRequest {
+			t.Errorf("Expected ErrBadRequest, got %v", err)
+		}
+	})
+}
+
+func TestInvoiceData(t *testing.T) {
+	now := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	t.Run("LinesAndTotals", func(t *testing.T) {
+		invoice := invoiceData(testInvoiceData(), docgen.Company{Name: "Car Project"}, now)
+		if invoice.Number != "INV-000042" {
+			t.Errorf("Expected INV-000042, got %s", invoice.Number)
+		}
+		if len(invoice.Lines) != 2 || invoice.Lines[0].Description != "Toyota Axio 2019" || invoice.Lines[1].No != 2 {
+			t.Errorf("Expected two numbered lines, got %+v", invoice.Lines)
+		}
+		if invoice.Subtotal != 2450000 || invoice.Total != 2400000 {
+			t.Errorf("Expected subtotal 2450000 and the order total 2400000, got %v and %v", invoice.Subtotal, invoice.Total)
+		}
+	})
+
+	t.Run("CustomerFromPaymentHistory", func(t *testing.T) {
+		invoice := invoiceData(testInvoiceData(), docgen.Company{}, now)
+		if invoice.Customer.Name != "Karim Traders" || invoice.Customer.NID != "1990123456" {
+			t.Errorf("Expected the payment history customer, got %+v", invoice.Customer)
+		}
+		if invoice.Customer.Email != "desk@example.com" {
+			t.Errorf("Expected the buyer's email when payment history has none, got %q", invoice.Customer.Email)
+		}
+	})
+
+	t.Run("CustomerFallsBackToBuyer", func(t *testing.T) {
+		data := testInvoiceData()
+		data.Customer = nil
+		address := "House 1, Road 2, Dhaka"
+		data.Order.ShippingAddress = &address
+		invoice := invoiceData(data, docgen.Company{}, now)
+		if invoice.Customer.Name != "Sales Desk" || invoice.Customer.Address != address {
+			t.Errorf("Expected the buyer and shipping address, got %+v", invoice.Customer)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// PermissionPriceOverride lets a user order cars below their minimum price.
+const PermissionPriceOverride = "price-override"
+
+// saleableStatuses are the car statuses in which a car can be ordered.
+var saleableStatuses = []string{models.CarStatusAvailable, models.CarStatusReserved}
+
+// BelowMinimumPriceError is returned when an order sells cars below their minimum price and
+// the user does not hold price-override. It matches ErrForbidden with errors.Is.
+type BelowMinimumPriceError struct {
+	Items []BelowMinimumPrice
+}
+
+// BelowMinimumPrice is a car offered below its minimum price.
+type BelowMinimumPrice struct {
+	CarID    int64
+	Price    float64
+	MinPrice float64
+}
+
+func (e *BelowMinimumPriceError) Error() string {
+	b := e.Items[0]
+	return fmt.Sprintf("car %d is priced %.2f, below its minimum of %.2f", b.CarID, b.Price, b.MinPrice)
+}
+
+func (e *BelowMinimumPriceError) Unwrap() error { return utils.ErrForbidden }
+
+// UnsaleableCarError is returned when a car cannot be ordered in its current state. It
+// matches ErrConflict with errors.Is.
+type UnsaleableCarError struct {
+	CarID  int64
+	Reason string
+}
+
+func (e *UnsaleableCarError) Error() string {
+	return fmt.Sprintf("car %d cannot be ordered: %s", e.CarID, e.Reason)
+}
+
+func (e *UnsaleableCarError) Unwrap() error { return utils.ErrConflict }
+
+type OrderService interface {
+	// CreateOrder places an order by userID for the cars in req, each priced at its list price
+	// unless a price is given. Cars must be available or reserved and not on another open
+	// order (an *UnsaleableCarError otherwise). Prices below a car's minimum are a
+	// *BelowMinimumPriceError unless the user holds price-override; the returned notes list
+	// the ones that were allowed. A car without a list price or price is ErrBadRequest.
+	CreateOrder(ctx context.Context, userID int64, req dto.OrderCreateRequest) (*dto.OrderResponse, []string, error)
+}
+
+type orderService struct {
+	repo  repository.OrderRepository
+	perms PermissionService
+}
+
+func NewOrderService(repo repository.OrderRepository, perms PermissionService) OrderService {
+	return &orderService{repo: repo, perms: perms}
+}
+
+func (s *orderService) CreateOrder(ctx context.Context, userID int64, req dto.OrderCreateRequest) (*dto.OrderResponse, []string, error) {
+	items := make([]models.OrderItem, len(req.Items))
+	seen := map[int64]bool{}
+	for i, item := range req.Items {
+		if seen[item.CarID] {
+			return nil, nil, fmt.Errorf("%w: car %d is on the order more than once", utils.ErrBadRequest, item.CarID)
+		}
+		seen[item.CarID] = true
+		items[i] = models.OrderItem{CarID: item.CarID, Quantity: 1, Notes: item.Notes}
+	}
+
+	set, err := s.perms.GetUserPermissionSet(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	order := models.Order{UserID: userID, ShippingAddress: req.ShippingAddress}
+	var notes []string
+	err = s.repo.Create(ctx, &order, items, func(cars []repository.SaleCar) error {
+		var err error
+		notes, err = priceItems(&order, items, req.Items, cars, set.Has(PermissionPriceOverride))
+		return err
+	})
+	if errors.Is(err, repository.ErrRepeatedCar) {
+		return nil, nil, fmt.Errorf("%w: %v", utils.ErrBadRequest, err)
+	}
+	if err != nil {
+		return nil, nil, carError(err)
+	}
+	return &dto.OrderResponse{Order: order, Items: items}, notes, nil
+}
+
+// priceItems checks that the cars can be sold, sets the items' prices and the order total,
+// and returns a note for each car sold below its minimum price with override.
+func priceItems(order *models.Order, items []models.OrderItem, reqs []dto.OrderItemRequest, cars []repository.SaleCar, override bool) ([]string, error) {
+	byID := make(map[int64]repository.SaleCar, len(cars))
+	for _, car := range cars {
+		byID[car.ID] = car
+	}
+
+	var below []BelowMinimumPrice
+	var notes []string
+	total := 0.0
+	for i := range items {
+		car := byID[items[i].CarID]
+		status := models.CarStatusAvailable
+		if car.Status != nil {
+			status = *car.Status
+		}
+		switch {
+		case !slices.Contains(saleableStatuses, status):
+			return nil, &UnsaleableCarError{CarID: car.ID, Reason: "the car is " + status}
+		case car.OnOpenOrder:
+			return nil, &UnsaleableCarError{CarID: car.ID, Reason: "the car is on another open order"}
+		}
+
+		switch {
+		case reqs[i].Price != nil:
+			items[i].Price = *reqs[i].Price
+		case car.ListPrice != nil:
+			items[i].Price = *car.ListPrice
+		default:
+			return nil, fmt.Errorf("%w: car %d has no list price; give the price of the item", utils.ErrBadRequest, car.ID)
+		}
+		if car.MinPrice != nil && items[i].Price < *car.MinPrice {
+			below = append(below, BelowMinimumPrice{CarID: car.ID, Price: items[i].Price, MinPrice: *car.MinPrice})
+		}
+		total += items[i].Price * float64(items[i].Quantity)
+	}
+
+	if len(below) > 0 {
+		if !override {
+			return nil, &BelowMinimumPriceError{Items: below}
+		}
+		for _, b := range below {
+			notes = append(notes, fmt.Sprintf("Car %d was sold for %.2f, below its minimum price of %.2f", b.CarID, b.Price, b.MinPrice))
+		}
+	}
+	order.TotalAmount = math.Round(total*100) / 100
+	return notes, nil
+}
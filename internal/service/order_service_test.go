@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/events"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// MockOrderRepository passes its cars to check and records the order it would store.
+type MockOrderRepository struct {
+	cars  []repository.SaleCar
+	err   error
+	order *models.Order
+	items []models.OrderItem
+}
+
+func (m *MockOrderRepository) Create(ctx context.Context, order *models.Order, items []models.OrderItem, check func(cars []repository.SaleCar) error) error {
+	if m.err != nil {
+		return m.err
+	}
+	if err := check(m.cars); err != nil {
+		return err
+	}
+	order.ID = 1
+	m.order, m.items = order, items
+	return nil
+}
+
+func TestCreateOrder(t *testing.T) {
+	ctx := context.Background()
+	price := func(v float64) *float64 { return &v }
+	saleCar := func(id int64, status string, list, min *float64) repository.SaleCar {
+		return repository.SaleCar{ID: id, Status: &status, ListPrice: list, MinPrice: min}
+	}
+	newService := func(repo *MockOrderRepository, slugs ...string) OrderService {
+		return NewOrderService(repo, NewPermissionService(&MockPermissionRepository{slugs: slugs}, events.NewBus(), time.Minute))
+	}
+
+	t.Run("PricesAtListPrice", func(t *testing.T) {
+		repo := &MockOrderRepository{cars: []repository.SaleCar{
+			saleCar(1, models.CarStatusAvailable, price(2500000), price(2300000)),
+			saleCar(2, models.CarStatusReserved, price(1800000), nil),
+		}}
+		order, notes, err := newService(repo).CreateOrder(ctx, 7, dto.OrderCreateRequest{Items: []dto.OrderItemRequest{
+			{CarID: 1}, {CarID: 2, Price: price(1750000)},
+		}})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if order.UserID != 7 || order.TotalAmount != 4250000 || len(notes) != 0 {
+			t.Errorf("Expected a 4250000 order by user 7 without notes, got %+v %v", order.Order, notes)
+		}
+		if repo.items[0].Price != 2500000 || repo.items[1].Price != 1750000 || repo.items[0].Quantity != 1 {
+			t.Errorf("Expected items at 2500000 and 1750000, got %+v", repo.items)
+		}
+	})
+
+	t.Run("BelowMinimumRefused", func(t *testing.T) {
+		repo := &MockOrderRepository{cars: []repository.SaleCar{saleCar(1, models.CarStatusAvailable, price(2500000), price(2300000))}}
+		_, _, err := newService(repo, "order-create").CreateOrder(ctx, 7, dto.OrderCreateRequest{Items: []dto.OrderItemRequest{
+			{CarID: 1, Price: price(2200000)},
+		}})
+		var below *BelowMinimumPriceError
+		if !errors.As(err, &below) || !errors.Is(err, utils.ErrForbidden) || below.Items[0].MinPrice != 2300000 {
+			t.Fatalf("Expected a BelowMinimumPriceError, got %v", err)
+		}
+		if repo.order != nil {
+			t.Error("Expected no order to be stored")
+		}
+	})
+
+	t.Run("BelowMinimumWithOverride", func(t *testing.T) {
+		repo := &MockOrderRepository{cars: []repository.SaleCar{saleCar(1, models.CarStatusAvailable, price(2500000), price(2300000))}}
+		order, notes, err := newService(repo, "order-create", "price-override").CreateOrder(ctx, 7, dto.OrderCreateRequest{Items: []dto.OrderItemRequest{
+			{CarID: 1, Price: price(2200000)},
+		}})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if order.TotalAmount != 2200000 || len(notes) != 1 {
+			t.Errorf("Expected a 2200000 order with one note, got %v %v", order.TotalAmount, notes)
+		}
+	})
+
+	t.Run("UnsaleableCar", func(t *testing.T) {
+		for _, car := range []repository.SaleCar{
+			saleCar(1, models.CarStatusSold, price(100), nil),
+			{ID: 1, ListPrice: price(100), OnOpenOrder: true},
+		} {
+			repo := &MockOrderRepository{cars: []repository.SaleCar{car}}
+			_, _, err := newService(repo).CreateOrder(ctx, 7, dto.OrderCreateRequest{Items: []dto.OrderItemRequest{{CarID: 1}}})
+			var unsaleable *UnsaleableCarError
+			if !errors.As(err, &unsaleable) || !errors.Is(err, utils.ErrConflict) {
+				t.Errorf("Expected an UnsaleableCarError, got %v", err)
+			}
+		}
+	})
+
+	t.Run("NoPrice", func(t *testing.T) {
+		repo := &MockOrderRepository{cars: []repository.SaleCar{saleCar(1, models.CarStatusAvailable, nil, nil)}}
+		_, _, err := newService(repo).CreateOrder(ctx, 7, dto.OrderCreateRequest{Items: []dto.OrderItemRequest{{CarID: 1}}})
+		if !errors.Is(err, utils.ErrBadRequest) {
+			t.Errorf("Expected ErrBadRequest, got %v", err)
+		}
+	})
+
+	t.Run("RepeatedCar", func(t *testing.T) {
+		_, _, err := newService(&MockOrderRepository{}).CreateOrder(ctx, 7, dto.OrderCreateRequest{Items: []dto.OrderItemRequest{{CarID: 1}, {CarID: 1}}})
+		if !errors.Is(err, utils.ErrBadRequest) {
+			t.Errorf("Expected ErrBadRequest, got %v", err)
+		}
+	})
+
+	t.Run("RepeatedCarInRepository", func(t *testing.T) {
+		_, _, err := newService(&MockOrderRepository{err: repository.ErrRepeatedCar}).CreateOrder(ctx, 7, dto.OrderCreateRequest{Items: []dto.OrderItemRequest{{CarID: 1}}})
+		if !errors.Is(err, utils.ErrBadRequest) {
+			t.Errorf("Expected ErrBadRequest, got %v", err)
+		}
+	})
+
+	t.Run("MissingCar", func(t *testing.T) {
+		_, _, err := newService(&MockOrderRepository{err: sql.ErrNoRows}).CreateOrder(ctx, 7, dto.OrderCreateRequest{Items: []dto.OrderItemRequest{{CarID: 1}}})
+		if err != utils.ErrNotFound {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/notify"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type PasswordService interface {
+	ChangePassword(ctx context.Context, userID int64, req dto.ChangePasswordRequest, client ClientInfo) (*dto.TokenResponse, error)
+	RequestReset(ctx context.Context, userID int64, requestedBy int64) (*dto.PasswordResetIssuedResponse, error)
+	ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error
+}
+
+// PasswordResetConfig controls admin-initiated reset tokens.
+type PasswordResetConfig struct {
+	TokenTTL time.Duration
+	URL      string // optional link prefix; the token is appended, e.g. "https://app/reset?token="
+}
+
+type passwordService struct {
+	users    repository.UserRepository
+	resets   repository.PasswordResetRepository
+	roles    repository.RoleRepository
+	tokens   TokenService
+	notifier notify.Notifier
+	policy   utils.PasswordPolicy
+	reset    PasswordResetConfig
+}
+
+func NewPasswordService(users repository.UserRepository, resets repository.PasswordResetRepository, roles repository.RoleRepository, tokens TokenService, notifier notify.Notifier, policy utils.PasswordPolicy, reset PasswordResetConfig) PasswordService {
+	if reset.TokenTTL <= 0 {
+		reset.TokenTTL = time.Hour
+	}
+	return &passwordService{
+		users:    users,
+		resets:   resets,
+		roles:    roles,
+		tokens:   tokens,
+		notifier: notifier,
+		policy:   policy,
+		reset:    reset,
+	}
+}
+
+// ChangePassword verifies the current password, stores the new one and revokes every session.
+// A fresh token pair is returned so the caller stays logged in on this device.
+func (s *passwordService) ChangePassword(ctx context.Context, userID int64, req dto.ChangePasswordRequest, client ClientInfo) (*dto.TokenResponse, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, utils.ErrNotFound
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		return nil, utils.ErrUnauthorized
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return nil, &utils.PasswordPolicyError{Problems: []string{"New password must differ from the current password"}}
+	}
+
+	if err := s.setPassword(ctx, userID, req.NewPassword, &userID); err != nil {
+		return nil, err
+	}
+	return s.tokens.IssueTokens(ctx, userID, client)
+}
+
+// RequestReset issues a single-use, time-limited reset token and delivers it through the notifier.
+// Earlier outstanding tokens for the user are invalidated.
+func (s *passwordService) RequestReset(ctx context.Context, userID int64, requestedBy int64) (*dto.PasswordResetIssuedResponse, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, utils.ErrNotFound
+	}
+	if err := ensureCanManageUser(ctx, s.roles, requestedBy, userID); err != nil {
+		return nil, err
+	}
+
+	raw, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.resets.InvalidateForUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	token := &models.PasswordResetToken{
+		UserID:    userID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.reset.TokenTTL),
+		CreatedBy: &requestedBy,
+	}
+	if err := s.resets.Create(ctx, token); err != nil {
+		return nil, err
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\nAn administrator started a password reset for your account (%s).\n", user.Name, user.Username)
+	if s.reset.URL != "" {
+		body += fmt.Sprintf("Open this link to choose a new password: %s%s\n", s.reset.URL, raw)
+	} else {
+		body += fmt.Sprintf("Use this reset token to choose a new password: %s\n", raw)
+	}
+	body += fmt.Sprintf("\nThe token can be used once and expires at %s.\n", token.ExpiresAt.Format(time.RFC1123))
+
+	if err := s.notifier.Notify(ctx, notify.Message{To: user.Email, Subject: "Password reset", Body: body}); err != nil {
+		return nil, fmt.Errorf("deliver reset token: %w", err)
+	}
+
+	return &dto.PasswordResetIssuedResponse{
+		UserID:    userID,
+		SentTo:    user.Email,
+		ExpiresAt: token.ExpiresAt,
+	}, nil
+}
+
+// ResetPassword consumes a reset token, sets the new password and revokes every session.
+func (s *passwordService) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error {
+	if problems := s.policy.Validate(req.NewPassword); len(problems) > 0 {
+		return &utils.PasswordPolicyError{Problems: problems}
+	}
+
+	token, err := s.resets.GetByHash(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		return err
+	}
+	if token == nil || token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return utils.ErrUnauthorized
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	err = s.resets.ResetPassword(ctx, token, string(hashed))
+	if errors.Is(err, repository.ErrResetTokenUsed) {
+		return utils.ErrUnauthorized
+	}
+	if err != nil {
+		return userError(err)
+	}
+	return s.tokens.RevokeAllForUser(ctx, token.UserID)
+}
+
+func (s *passwordService) setPassword(ctx context.Context, userID int64, password string, updatedBy *int64) error {
+	if problems := s.policy.Validate(password); len(problems) > 0 {
+		return &utils.PasswordPolicyError{Problems: problems}
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.users.UpdatePassword(ctx, userID, string(hashed), updatedBy); err != nil {
+		return userError(err)
+	}
+	if err := s.resets.InvalidateForUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.tokens.RevokeAllForUser(ctx, userID)
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+type PaymentService interface {
+	GetPayments(ctx context.Context) ([]models.PaymentHistory, error)
+	GetPaymentByID(ctx context.Context, id int64) (*models.PaymentHistory, error)
+}
+
+type paymentService struct {
+	repo repository.PaymentRepository
+}
+
+func NewPaymentService(repo repository.PaymentRepository) PaymentService {
+	return &paymentService{repo: repo}
+}
+
+func (s *paymentService) GetPayments(ctx context.Context) ([]models.PaymentHistory, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *paymentService) GetPaymentByID(ctx context.Context, id int64) (*models.PaymentHistory, error) {
+	payment, err := s.repo.GetByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, utils.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// permissionCache keeps resolved permission sets per user for a fixed TTL.
+// A generation counter stops a lookup that raced with an invalidation from
+// storing the stale set it read.
+type permissionCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	entries    map[int64]permissionCacheEntry
+	generation uint64
+	now        func() time.Time
+}
+
+type permissionCacheEntry struct {
+	set     PermissionSet
+	version int64
+	expires time.Time
+}
+
+func newPermissionCache(ttl time.Duration) *permissionCache {
+	return &permissionCache{ttl: ttl, entries: make(map[int64]permissionCacheEntry), now: time.Now}
+}
+
+// get returns the cached entry, or false plus the generation to pass to put after loading it.
+func (c *permissionCache) get(userID int64) (permissionCacheEntry, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[userID]; ok && c.now().Before(e.expires) {
+		return e, c.generation, true
+	}
+	return permissionCacheEntry{}, c.generation, false
+}
+
+func (c *permissionCache) put(userID int64, e permissionCacheEntry, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	e.expires = c.now().Add(c.ttl)
+	c.entries[userID] = e
+}
+
+// invalidate drops userID's entry, or every entry when userID is 0.
+func (c *permissionCache) invalidate(userID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	if userID == 0 {
+		c.entries = make(map[int64]permissionCacheEntry)
+		return
+	}
+	delete(c.entries, userID)
+}
@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/events"
 	"github.com/user/car-project/internal/models"
 	"github.com/user/car-project/internal/repository"
 	"github.com/user/car-project/internal/utils"
@@ -17,14 +19,29 @@ type PermissionService interface {
 	UpdatePermission(ctx context.Context, id int64, req dto.UpdatePermissionRequest) error
 	DeletePermission(ctx context.Context, id int64) error
 	GetUserPermissions(ctx context.Context, userID int64) ([]string, error)
+	GetUserPermissionSet(ctx context.Context, userID int64) (PermissionSet, error)
+	GetPermissionsVersion(ctx context.Context, userID int64) (int64, error)
 }
 
 type permissionService struct {
-	repo repository.PermissionRepository
+	repo  repository.PermissionRepository
+	bus   events.Bus
+	cache *permissionCache
 }
 
-func NewPermissionService(repo repository.PermissionRepository) PermissionService {
-	return &permissionService{repo: repo}
+// NewPermissionService resolves permission sets through a per-process cache that lives for cacheTTL
+// (0 disables it) and is invalidated by events.AccessChanged on bus.
+func NewPermissionService(repo repository.PermissionRepository, bus events.Bus, cacheTTL time.Duration) PermissionService {
+	s := &permissionService{repo: repo, bus: bus}
+	if cacheTTL > 0 {
+		s.cache = newPermissionCache(cacheTTL)
+		bus.Subscribe(events.AccessChanged, func(payload interface{}) {
+			if change, ok := payload.(events.AccessChange); ok {
+				s.cache.invalidate(change.UserID)
+			}
+		})
+	}
+	return s
 }
 
 func (s *permissionService) CreatePermission(ctx context.Context, req dto.CreatePermissionRequest) (*dto.PermissionResponse, error) {
@@ -105,13 +122,66 @@ func (s *permissionService) UpdatePermission(ctx context.Context, id int64, req
 		perm.Module = req.Module
 	}
 
-	return s.repo.Update(ctx, perm)
+	// The permission may have been deleted since it was read.
+	if err := s.repo.Update(ctx, perm); err == sql.ErrNoRows {
+		return utils.ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
 }
 
 func (s *permissionService) DeletePermission(ctx context.Context, id int64) error {
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err == sql.ErrNoRows {
+		return utils.ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	s.bus.Publish(events.AccessChanged, events.AccessChange{})
+	return nil
 }
 
 func (s *permissionService) GetUserPermissions(ctx context.Context, userID int64) ([]string, error) {
 	return s.repo.GetByUserID(ctx, userID)
 }
+
+func (s *permissionService) GetUserPermissionSet(ctx context.Context, userID int64) (PermissionSet, error) {
+	e, err := s.resolve(ctx, userID)
+	return e.set, err
+}
+
+// GetPermissionsVersion returns the user's current permissions_version (0 for unknown or inactive users),
+// always read from the database.
+func (s *permissionService) GetPermissionsVersion(ctx context.Context, userID int64) (int64, error) {
+	return s.repo.GetUserPermissionsVersion(ctx, userID)
+}
+
+// resolve returns the user's permission set. A cached set is only used while the user's
+// permissions_version still matches the database, so changes made through another instance,
+// which does not publish to this one's bus, take effect at once.
+func (s *permissionService) resolve(ctx context.Context, userID int64) (permissionCacheEntry, error) {
+	// Read the version first: if assignments change in between, the entry carries an
+	// older version than its permissions, so the next lookup reloads it.
+	version, err := s.repo.GetUserPermissionsVersion(ctx, userID)
+	if err != nil {
+		return permissionCacheEntry{}, err
+	}
+	var generation uint64
+	if s.cache != nil {
+		e, gen, ok := s.cache.get(userID)
+		if ok && e.version == version {
+			return e, nil
+		}
+		generation = gen
+	}
+
+	slugs, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return permissionCacheEntry{}, err
+	}
+	e := permissionCacheEntry{set: NewPermissionSet(slugs), version: version}
+	if s.cache != nil {
+		s.cache.put(userID, e, generation)
+	}
+	return e, nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/user/car-project/internal/events"
+	"github.com/user/car-project/internal/utils"
+)
+
+func TestPermissionSet(t *testing.T) {
+	set := NewPermissionSet([]string{"car-*", "rag-ask"})
+
+	if !set.Has("car-delete") {
+		t.Error("Expected car-* to grant car-delete")
+	}
+	if set.Has("rag-index") {
+		t.Error("Expected rag-index to be denied")
+	}
+	if !set.HasAny("rag-index", "rag-ask") {
+		t.Error("Expected HasAny to match rag-ask")
+	}
+	if set.HasAll("rag-index", "rag-ask") {
+		t.Error("Expected HasAll to fail without rag-index")
+	}
+	if !NewPermissionSet([]string{"*"}).Has("user-manage") {
+		t.Error("Expected * to grant everything")
+	}
+}
+
+func TestPermissionServiceCache(t *testing.T) {
+	ctx := context.Background()
+	repo := &MockPermissionRepository{slugs: []string{"car-read"}}
+	bus := events.NewBus()
+	svc := NewPermissionService(repo, bus, time.Minute)
+
+	svc.GetUserPermissionSet(ctx, 1)
+	svc.GetUserPermissionSet(ctx, 1)
+	if repo.calls != 1 {
+		t.Errorf("Expected 1 repository call with a warm cache, got %d", repo.calls)
+	}
+
+	repo.slugs = []string{"car-read", "car-delete"}
+	bus.Publish(events.AccessChanged, events.AccessChange{UserID: 1})
+	set, _ := svc.GetUserPermissionSet(ctx, 1)
+	if !set.Has("car-delete") {
+		t.Error("Expected invalidation to pick up the new permission")
+	}
+	if repo.calls != 2 {
+		t.Errorf("Expected 2 repository calls after invalidation, got %d", repo.calls)
+	}
+}
+
+func TestPermissionServiceCacheChecksVersion(t *testing.T) {
+	ctx := context.Background()
+	repo := &MockPermissionRepository{slugs: []string{"car-read"}, version: 1}
+	svc := NewPermissionService(repo, events.NewBus(), time.Minute)
+
+	svc.GetUserPermissionSet(ctx, 1)
+	// Another instance changed the user's roles; nothing was published on this bus.
+	repo.slugs, repo.version = []string{"car-read", "car-delete"}, 2
+	set, _ := svc.GetUserPermissionSet(ctx, 1)
+	if !set.Has("car-delete") {
+		t.Error("Expected a newer permissions_version to bypass the cached set")
+	}
+	if repo.calls != 2 {
+		t.Errorf("Expected 2 repository calls, got %d", repo.calls)
+	}
+}
+
+func TestDeleteMissingPermission(t *testing.T) {
+	svc := NewPermissionService(&MockPermissionRepository{deleteErr: sql.ErrNoRows}, events.NewBus(), 0)
+	if err := svc.DeletePermission(context.Background(), 99); err != utils.ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
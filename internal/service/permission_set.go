@@ -0,0 +1,59 @@
+package service
+
+import "strings"
+
+// PermissionSet is a user's resolved permissions. Besides exact slugs it understands
+// module wildcards: holding "car-*" grants every slug starting with "car-", and "*" grants everything.
+type PermissionSet struct {
+	exact    map[string]bool
+	prefixes []string
+	all      bool
+}
+
+func NewPermissionSet(slugs []string) PermissionSet {
+	s := PermissionSet{exact: make(map[string]bool, len(slugs))}
+	for _, slug := range slugs {
+		switch {
+		case slug == "*":
+			s.all = true
+		case strings.HasSuffix(slug, "-*"):
+			s.prefixes = append(s.prefixes, strings.TrimSuffix(slug, "*"))
+		default:
+			s.exact[slug] = true
+		}
+	}
+	return s
+}
+
+// Has reports whether the set grants slug, directly or through a wildcard.
+func (s PermissionSet) Has(slug string) bool {
+	if s.all || s.exact[slug] {
+		return true
+	}
+	for _, p := range s.prefixes {
+		if strings.HasPrefix(slug, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAny reports whether the set grants at least one of slugs.
+func (s PermissionSet) HasAny(slugs ...string) bool {
+	for _, slug := range slugs {
+		if s.Has(slug) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAll reports whether the set grants every one of slugs.
+func (s PermissionSet) HasAll(slugs ...string) bool {
+	for _, slug := range slugs {
+		if !s.Has(slug) {
+			return false
+		}
+	}
+	return true
+}
@@ -7,7 +7,8 @@ import (
 )
 
 type RAGService interface {
-	Ask(ctx context.Context, query string) (*rag.AskResult, error)
+	Ask(ctx context.Context, userID int64, query string) (*rag.AskResult, error)
+	Feedback(ctx context.Context, queryID, userID int64, helpful bool, comment *string) error
 	IndexCars(ctx context.Context) (int, error)
 }
 
@@ -19,8 +20,12 @@ func NewRAGService(r *rag.RAG) RAGService {
 	return &ragService{rag: r}
 }
 
-func (s *ragService) Ask(ctx context.Context, query string) (*rag.AskResult, error) {
-	return s.rag.Ask(ctx, query)
+func (s *ragService) Ask(ctx context.Context, userID int64, query string) (*rag.AskResult, error) {
+	return s.rag.Ask(ctx, userID, query)
+}
+
+func (s *ragService) Feedback(ctx context.Context, queryID, userID int64, helpful bool, comment *string) error {
+	return s.rag.Feedback(ctx, queryID, userID, helpful, comment)
 }
 
 func (s *ragService) IndexCars(ctx context.Context) (int, error) {
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// reportCache keeps computed reports for a fixed TTL, keyed by the report, its parameters and
+// the caller's data scope. Expired entries are dropped whenever a report is stored.
+type reportCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]reportCacheEntry
+	now     func() time.Time
+}
+
+type reportCacheEntry struct {
+	report  interface{}
+	expires time.Time
+}
+
+func newReportCache(ttl time.Duration) *reportCache {
+	return &reportCache{ttl: ttl, entries: make(map[string]reportCacheEntry), now: time.Now}
+}
+
+func (c *reportCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok && c.now().Before(e.expires) {
+		return e.report, true
+	}
+	return nil, false
+}
+
+func (c *reportCache) put(key string, report interface{}) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = reportCacheEntry{report: report, expires: now.Add(c.ttl)}
+}
+
+// clear drops every cached report.
+func (c *reportCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]reportCacheEntry)
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// agingBucketStarts are the first day in stock of each inventory aging bucket.
+var agingBucketStarts = []int{0, 31, 61, 91, 181}
+
+// ReportService computes sales and inventory reports. Every report is cached per period,
+// parameters, data scope and refresh of the report views; a period whose from is after its
+// to is ErrBadRequest.
+type ReportService interface {
+	// InventoryAging buckets the cars in stock by days in stock, for cars that came into
+	// stock in the period.
+	InventoryAging(ctx context.Context, q dto.ReportQuery) (*dto.InventoryAgingReport, error)
+	// SellThrough compares the cars sold in the period with those in stock, per make, model
+	// or body type (make by default).
+	SellThrough(ctx context.Context, q dto.SellThroughQuery) (*dto.SellThroughReport, error)
+	// Revenue totals the sales of each month of the period and their margin over landed cost.
+	Revenue(ctx context.Context, q dto.ReportQuery) (*dto.RevenueReport, error)
+	// Receivables lists the purchases made in the period that installments have not yet paid off.
+	Receivables(ctx context.Context, q dto.ReportQuery) (*dto.ReceivablesReport, error)
+	// StockValue values the cars in stock per location, for cars that came into stock in the period.
+	StockValue(ctx context.Context, q dto.ReportQuery) (*dto.StockValueReport, error)
+	// Views returns the last refresh of each materialized view behind the reports.
+	Views(ctx context.Context) ([]models.ReportViewRefresh, error)
+	// RefreshViews refreshes the report views now, empties the report cache and returns the
+	// views' refreshes. It returns ErrConflict while another refresh is running.
+	RefreshViews(ctx context.Context) ([]models.ReportViewRefresh, error)
+	// RunRefresher refreshes the report views every interval until ctx is cancelled.
+	RunRefresher(ctx context.Context, interval time.Duration)
+}
+
+type reportService struct {
+	repo  repository.ReportRepository
+	cache *reportCache
+}
+
+// NewReportService caches reports for cacheTTL; 0 disables the cache.
+func NewReportService(repo repository.ReportRepository, cacheTTL time.Duration) ReportService {
+	return &reportService{repo: repo, cache: newReportCache(cacheTTL)}
+}
+
+func (s *reportService) InventoryAging(ctx context.Context, q dto.ReportQuery) (*dto.InventoryAgingReport, error) {
+	report, err := s.cached(ctx, "inventory-aging", q, "", func(f repository.ReportFilter, p dto.ReportPeriod) (interface{}, error) {
+		rows, err := s.repo.StockAging(ctx, f, agingBucketStarts[1:])
+		if err != nil {
+			return nil, err
+		}
+		return agingReport(p, rows), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report.(*dto.InventoryAgingReport), nil
+}
+
+// agingReport labels the aging buckets, including empty ones, and averages the days in stock.
+func agingReport(p dto.ReportPeriod, rows []repository.StockAgingRow) *dto.InventoryAgingReport {
+	report := &dto.InventoryAgingReport{ReportPeriod: p, Buckets: make([]models.StockAgingBucket, len(agingBucketStarts))}
+	for i, start := range agingBucketStarts {
+		b := models.StockAgingBucket{MinDays: start, Label: strconv.Itoa(start) + "+"}
+		if i+1 < len(agingBucketStarts) {
+			end := agingBucketStarts[i+1] - 1
+			b.MaxDays = &end
+			b.Label = fmt.Sprintf("%d-%d", start, end)
+		}
+		report.Buckets[i] = b
+	}
+	var days int64
+	for _, row := range rows {
+		if row.Bucket < 0 || row.Bucket >= len(report.Buckets) {
+			continue
+		}
+		b := &report.Buckets[row.Bucket]
+		b.Cars, b.ListValue, b.CostValue = row.Cars, row.ListValue, row.CostValue
+		report.Cars += row.Cars
+		days += row.TotalDays
+	}
+	if report.Cars > 0 {
+		report.AverageDays = math.Round(float64(days)/float64(report.Cars)*10) / 10
+	}
+	return report
+}
+
+func (s *reportService) SellThrough(ctx context.Context, q dto.SellThroughQuery) (*dto.SellThroughReport, error) {
+	groupBy := q.GroupBy
+	if groupBy == "" {
+		groupBy = "make"
+	}
+	report, err := s.cached(ctx, "sell-through", q.ReportQuery, groupBy, func(f repository.ReportFilter, p dto.ReportPeriod) (interface{}, error) {
+		rows, err := s.repo.SellThrough(ctx, f, groupBy)
+		if err != nil {
+			return nil, err
+		}
+		for i := range rows {
+			if total := rows[i].Sold + rows[i].InStock; total > 0 {
+				rows[i].Rate = math.Round(float64(rows[i].Sold)/float64(total)*10000) / 10000
+			}
+		}
+		if rows == nil {
+			rows = []models.SellThroughRow{}
+		}
+		return &dto.SellThroughReport{ReportPeriod: p, GroupBy: groupBy, Groups: rows}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report.(*dto.SellThroughReport), nil
+}
+
+func (s *reportService) Revenue(ctx context.Context, q dto.ReportQuery) (*dto.RevenueReport, error) {
+	report, err := s.cached(ctx, "revenue", q, "", func(f repository.ReportFilter, p dto.ReportPeriod) (interface{}, error) {
+		months, err := s.repo.Revenue(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		for i := range months {
+			m := &months[i]
+			m.Margin = math.Round((m.CostedRevenue-m.Cost)*100) / 100
+			if m.CostedRevenue > 0 {
+				percent := math.Round(m.Margin/m.CostedRevenue*10000) / 100
+				m.MarginPercent = &percent
+			}
+		}
+		if months == nil {
+			months = []models.MonthlyRevenue{}
+		}
+		return &dto.RevenueReport{ReportPeriod: p, Months: months}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report.(*dto.RevenueReport), nil
+}
+
+func (s *reportService) Receivables(ctx context.Context, q dto.ReportQuery) (*dto.ReceivablesReport, error) {
+	report, err := s.cached(ctx, "receivables", q, "", func(f repository.ReportFilter, p dto.ReportPeriod) (interface{}, error) {
+		rows, err := s.repo.Receivables(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		report := &dto.ReceivablesReport{ReportPeriod: p, Receivables: rows}
+		for _, r := range rows {
+			report.TotalOutstanding += r.Outstanding
+		}
+		report.TotalOutstanding = math.Round(report.TotalOutstanding*100) / 100
+		if report.Receivables == nil {
+			report.Receivables = []models.Receivable{}
+		}
+		return report, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report.(*dto.ReceivablesReport), nil
+}
+
+func (s *reportService) StockValue(ctx context.Context, q dto.ReportQuery) (*dto.StockValueReport, error) {
+	report, err := s.cached(ctx, "stock-value", q, "", func(f repository.ReportFilter, p dto.ReportPeriod) (interface{}, error) {
+		rows, err := s.repo.StockValue(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		if rows == nil {
+			rows = []models.StockValueRow{}
+		}
+		return &dto.StockValueReport{ReportPeriod: p, Locations: rows}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report.(*dto.StockValueReport), nil
+}
+
+// cached returns the cached report for the period, parameters and the data scope in ctx, or
+// builds and caches it.
+func (s *reportService) cached(ctx context.Context, name string, q dto.ReportQuery, params string,
+	build func(f repository.ReportFilter, p dto.ReportPeriod) (interface{}, error)) (interface{}, error) {
+	f := repository.ReportFilter{From: q.From}
+	if q.To != nil {
+		// Include the whole of the "to" day.
+		end := q.To.AddDate(0, 0, 1)
+		f.To = &end
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return nil, fmt.Errorf("%w: from must not be after to", utils.ErrBadRequest)
+	}
+
+	views, err := s.repo.ViewRefreshes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	asOf := dataAsOf(views)
+	// Keying on the views' refresh means a refresh by any instance retires cached reports.
+	scope, _ := repository.ScopeFromContext(ctx)
+	key := fmt.Sprintf("%s|%s|%s|%s|%+v|%s", name, reportDate(q.From), reportDate(q.To), params, scope, refreshKey(views))
+	if report, ok := s.cache.get(key); ok {
+		return report, nil
+	}
+	report, err := build(f, dto.ReportPeriod{From: q.From, To: q.To, GeneratedAt: s.cache.now(), DataAsOf: asOf})
+	if err != nil {
+		return nil, err
+	}
+	s.cache.put(key, report)
+	return report, nil
+}
+
+// dataAsOf returns the oldest last refresh of views, or nil if one was never refreshed.
+func dataAsOf(views []models.ReportViewRefresh) *time.Time {
+	var oldest *time.Time
+	for _, v := range views {
+		if v.RefreshedAt == nil {
+			return nil
+		}
+		if oldest == nil || v.RefreshedAt.Before(*oldest) {
+			oldest = v.RefreshedAt
+		}
+	}
+	return oldest
+}
+
+// refreshKey identifies the current refresh of every view.
+func refreshKey(views []models.ReportViewRefresh) string {
+	var key string
+	for _, v := range views {
+		if v.RefreshedAt != nil {
+			key += v.View + "@" + strconv.FormatInt(v.RefreshedAt.UnixNano(), 10) + ";"
+		}
+	}
+	return key
+}
+
+func (s *reportService) Views(ctx context.Context) ([]models.ReportViewRefresh, error) {
+	views, err := s.repo.ViewRefreshes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if views == nil {
+		views = []models.ReportViewRefresh{}
+	}
+	return views, nil
+}
+
+func (s *reportService) RefreshViews(ctx context.Context) ([]models.ReportViewRefresh, error) {
+	err := s.repo.RefreshViews(ctx)
+	if errors.Is(err, repository.ErrRefreshInProgress) {
+		return nil, utils.ErrConflict
+	}
+	// Even a partial refresh changes what the reports read.
+	s.cache.clear()
+	if err != nil {
+		return nil, err
+	}
+	return s.Views(ctx)
+}
+
+func (s *reportService) RunRefresher(ctx context.Context, interval time.Duration) {
+	logger := utils.GetLogger()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RefreshViews(ctx); err != nil && err != utils.ErrConflict {
+				logger.Printf("report view refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+func reportDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
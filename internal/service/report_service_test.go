@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// MockReportRepository returns canned rows and counts the queries it answers.
+type MockReportRepository struct {
+	aging       []repository.StockAgingRow
+	sell        []models.SellThroughRow
+	revenue     []models.MonthlyRevenue
+	receivables []models.Receivable
+	views       []models.ReportViewRefresh
+	refreshErr  error
+	refreshes   int
+	calls       int
+	filter      repository.ReportFilter
+}
+
+func (m *MockReportRepository) StockAging(ctx context.Context, f repository.ReportFilter, bucketStarts []int) ([]repository.StockAgingRow, error) {
+	m.calls++
+	m.filter = f
+	return m.aging, nil
+}
+
+func (m *MockReportRepository) SellThrough(ctx context.Context, f repository.ReportFilter, groupBy string) ([]models.SellThroughRow, error) {
+	m.calls++
+	rows := make([]models.SellThroughRow, len(m.sell))
+	copy(rows, m.sell)
+	return rows, nil
+}
+
+func (m *MockReportRepository) Revenue(ctx context.Context, f repository.ReportFilter) ([]models.MonthlyRevenue, error) {
+	m.calls++
+	return m.revenue, nil
+}
+
+func (m *MockReportRepository) Receivables(ctx context.Context, f repository.ReportFilter) ([]models.Receivable, error) {
+	m.calls++
+	return m.receivables, nil
+}
+
+func (m *MockReportRepository) StockValue(ctx context.Context, f repository.ReportFilter) ([]models.StockValueRow, error) {
+	m.calls++
+	return nil, nil
+}
+
+func (m *MockReportRepository) RefreshViews(ctx context.Context) error {
+	m.refreshes++
+	return m.refreshErr
+}
+
+func (m *MockReportRepository) ViewRefreshes(ctx context.Context) ([]models.ReportViewRefresh, error) {
+	return m.views, nil
+}
+
+func TestReports(t *testing.T) {
+	ctx := context.Background()
+	date := func(s string) *time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return &d
+	}
+
+	t.Run("InventoryAgingBuckets", func(t *testing.T) {
+		repo := &MockReportRepository{aging: []repository.StockAgingRow{
+			{Bucket: 0, Cars: 2, TotalDays: 20, ListValue: 5000000},
+			{Bucket: 4, Cars: 1, TotalDays: 200},
+		}}
+		report, err := NewReportService(repo, 0).InventoryAging(ctx, dto.ReportQuery{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(report.Buckets) != 5 || report.Buckets[0].Label != "0-30" || report.Buckets[3].Label != "91-180" {
+			t.Fatalf("Expected five labelled buckets, got %+v", report.Buckets)
+		}
+		if last := report.Buckets[4]; last.Label != "181+" || last.MaxDays != nil || last.Cars != 1 {
+			t.Errorf("Expected an open-ended 181+ bucket with one car, got %+v", last)
+		}
+		if report.Buckets[1].Cars != 0 || report.Buckets[0].ListValue != 5000000 {
+			t.Errorf("Expected empty buckets to be filled in, got %+v", report.Buckets)
+		}
+		if report.Cars != 3 || report.AverageDays != 73.3 {
+			t.Errorf("Expected 3 cars averaging 73.3 days, got %d and %v", report.Cars, report.AverageDays)
+		}
+	})
+
+	t.Run("SellThroughRate", func(t *testing.T) {
+		repo := &MockReportRepository{sell: []models.SellThroughRow{{Group: "Toyota", Sold: 1, InStock: 2}, {Group: "Honda"}}}
+		report, err := NewReportService(repo, 0).SellThrough(ctx, dto.SellThroughQuery{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if report.GroupBy != "make" || report.Groups[0].Rate != 0.3333 || report.Groups[1].Rate != 0 {
+			t.Errorf("Expected make grouping with rates 0.3333 and 0, got %+v", report)
+		}
+	})
+
+	t.Run("RevenueMargin", func(t *testing.T) {
+		repo := &MockReportRepository{revenue: []models.MonthlyRevenue{
+			{Revenue: 5000000, CostedRevenue: 4000000, Cost: 3000000, CarsWithoutCost: 1},
+			{Revenue: 1000000},
+		}}
+		report, err := NewReportService(repo, 0).Revenue(ctx, dto.ReportQuery{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		first := report.Months[0]
+		if first.Margin != 1000000 || first.MarginPercent == nil || *first.MarginPercent != 25 {
+			t.Errorf("Expected a 1000000 margin at 25%%, got %+v", first)
+		}
+		if report.Months[1].MarginPercent != nil {
+			t.Errorf("Expected no margin percent without costed sales, got %v", *report.Months[1].MarginPercent)
+		}
+	})
+
+	t.Run("ReceivablesTotal", func(t *testing.T) {
+		repo := &MockReportRepository{receivables: []models.Receivable{{Outstanding: 150000.25}, {Outstanding: 50000.5}}}
+		report, err := NewReportService(repo, 0).Receivables(ctx, dto.ReportQuery{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if report.TotalOutstanding != 200000.75 {
+			t.Errorf("Expected 200000.75 outstanding, got %v", report.TotalOutstanding)
+		}
+	})
+
+	t.Run("EmptyReportsHaveEmptyLists", func(t *testing.T) {
+		report, err := NewReportService(&MockReportRepository{}, 0).StockValue(ctx, dto.ReportQuery{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if report.Locations == nil {
+			t.Error("Expected an empty list of locations, got nil")
+		}
+	})
+
+	t.Run("InclusiveTo", func(t *testing.T) {
+		repo := &MockReportRepository{}
+		if _, err := NewReportService(repo, 0).InventoryAging(ctx, dto.ReportQuery{From: date("2026-01-01"), To: date("2026-01-31")}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !repo.filter.To.Equal(*date("2026-02-01")) {
+			t.Errorf("Expected the filter to end on 2026-02-01, got %v", repo.filter.To)
+		}
+	})
+
+	t.Run("FromAfterTo", func(t *testing.T) {
+		_, err := NewReportService(&MockReportRepository{}, 0).Revenue(ctx, dto.ReportQuery{From: date("2026-02-01"), To: date("2026-01-01")})
+		if !errors.Is(err, utils.ErrBadRequest) {
+			t.Errorf("Expected ErrBadRequest, got %v", err)
+		}
+	})
+
+	t.Run("Cache", func(t *testing.T) {
+		repo := &MockReportRepository{}
+		svc := NewReportService(repo, time.Minute).(*reportService)
+		now := time.Now()
+		svc.cache.now = func() time.Time { return now }
+		q := dto.ReportQuery{From: date("2026-01-01")}
+		dhaka := repository.WithScope(ctx, repository.Scope{Locations: []string{"Dhaka"}})
+
+		svc.InventoryAging(ctx, q)
+		svc.InventoryAging(ctx, q)
+		if repo.calls != 1 {
+			t.Errorf("Expected a cached report on the second call, got %d queries", repo.calls)
+		}
+		svc.InventoryAging(ctx, dto.ReportQuery{})
+		svc.InventoryAging(dhaka, q)
+		svc.StockValue(ctx, q)
+		if repo.calls != 4 {
+			t.Errorf("Expected another period, scope or report to miss the cache, got %d queries", repo.calls)
+		}
+		now = now.Add(time.Minute)
+		svc.InventoryAging(ctx, q)
+		if repo.calls != 5 {
+			t.Errorf("Expected an expired report to be recomputed, got %d queries", repo.calls)
+		}
+	})
+
+	t.Run("DataAsOf", func(t *testing.T) {
+		older, newer := time.Now().Add(-time.Hour), time.Now()
+		repo := &MockReportRepository{views: []models.ReportViewRefresh{
+			{View: "report_car_costs", RefreshedAt: &newer},
+			{View: "report_sales", RefreshedAt: &older},
+		}}
+		report, err := NewReportService(repo, 0).Revenue(ctx, dto.ReportQuery{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if report.DataAsOf == nil || !report.DataAsOf.Equal(older) {
+			t.Errorf("Expected data as of the oldest refresh, got %v", report.DataAsOf)
+		}
+		repo.views = append(repo.views, models.ReportViewRefresh{View: "report_receivables"})
+		if report, _ := NewReportService(repo, 0).Revenue(ctx, dto.ReportQuery{}); report.DataAsOf != nil {
+			t.Errorf("Expected no data_as_of while a view was never refreshed, got %v", report.DataAsOf)
+		}
+	})
+
+	t.Run("RefreshViewsClearsCache", func(t *testing.T) {
+		repo := &MockReportRepository{}
+		svc := NewReportService(repo, time.Minute)
+		svc.StockValue(ctx, dto.ReportQuery{})
+		if _, err := svc.RefreshViews(ctx); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		svc.StockValue(ctx, dto.ReportQuery{})
+		if repo.refreshes != 1 || repo.calls != 2 {
+			t.Errorf("Expected one refresh and a recomputed report, got %d refreshes and %d queries", repo.refreshes, repo.calls)
+		}
+	})
+
+	t.Run("RefreshByAnotherInstanceMissesCache", func(t *testing.T) {
+		before, after := time.Now().Add(-time.Hour), time.Now()
+		repo := &MockReportRepository{views: []models.ReportViewRefresh{{View: "report_sales", RefreshedAt: &before}}}
+		svc := NewReportService(repo, time.Minute)
+		svc.Revenue(ctx, dto.ReportQuery{})
+		repo.views[0].RefreshedAt = &after
+		svc.Revenue(ctx, dto.ReportQuery{})
+		if repo.calls != 2 {
+			t.Errorf("Expected a report to be recomputed after the views were refreshed, got %d queries", repo.calls)
+		}
+	})
+
+	t.Run("RefreshInProgress", func(t *testing.T) {
+		repo := &MockReportRepository{refreshErr: repository.ErrRefreshInProgress}
+		if _, err := NewReportService(repo, 0).RefreshViews(ctx); err != utils.ErrConflict {
+			t.Errorf("Expected ErrConflict, got %v", err)
+		}
+	})
+}
@@ -2,9 +2,12 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/events"
 	"github.com/user/car-project/internal/models"
 	"github.com/user/car-project/internal/repository"
 	"github.com/user/car-project/internal/utils"
@@ -16,15 +19,24 @@ type RoleService interface {
 	GetRoleByID(ctx context.Context, id int64) (*dto.RoleResponse, error)
 	UpdateRole(ctx context.Context, id int64, req dto.UpdateRoleRequest) error
 	DeleteRole(ctx context.Context, id int64) error
-	AssignRole(ctx context.Context, req dto.AssignRoleRequest) error
+	AssignRole(ctx context.Context, req dto.AssignRoleRequest, assignedBy int64) error
+	RevokeRole(ctx context.Context, req dto.AssignRoleRequest) error
+	GetRolePermissions(ctx context.Context, roleID int64) (*dto.RolePermissionsResponse, error)
+	GrantPermissions(ctx context.Context, roleID int64, req dto.RolePermissionsRequest, assignedBy int64) (*dto.RolePermissionsResponse, error)
+	RevokePermissions(ctx context.Context, roleID int64, req dto.RolePermissionsRequest) (*dto.RolePermissionsResponse, error)
+	GetUserAccess(ctx context.Context, userID int64) (*dto.UserAccessResponse, error)
 }
 
 type roleService struct {
-	repo repository.RoleRepository
+	repo     repository.RoleRepository
+	permRepo repository.PermissionRepository
+	userRepo repository.UserRepository
+	bus      events.Bus
 }
 
-func NewRoleService(repo repository.RoleRepository) RoleService {
-	return &roleService{repo: repo}
+// NewRoleService publishes events.AccessChanged on bus after every change to role assignments or role permissions.
+func NewRoleService(repo repository.RoleRepository, permRepo repository.PermissionRepository, userRepo repository.UserRepository, bus events.Bus) RoleService {
+	return &roleService{repo: repo, permRepo: permRepo, userRepo: userRepo, bus: bus}
 }
 
 func (s *roleService) CreateRole(ctx context.Context, req dto.CreateRoleRequest) (*dto.RoleResponse, error) {
@@ -105,13 +117,229 @@ func (s *roleService) UpdateRole(ctx context.Context, id int64, req dto.UpdateRo
 		role.Description = req.Description
 	}
 
-	return s.repo.Update(ctx, role)
+	// The role may have been deleted since it was read.
+	if err := s.repo.Update(ctx, role); err == sql.ErrNoRows {
+		return utils.ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
 }
 
 func (s *roleService) DeleteRole(ctx context.Context, id int64) error {
-	return s.repo.Delete(ctx, id)
+	role, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return utils.ErrNotFound
+	}
+	if role.Slug == models.RoleSlugAdmin {
+		return utils.ErrLastAdmin
+	}
+	if err := s.repo.Delete(ctx, id); err == sql.ErrNoRows {
+		return utils.ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	s.bus.Publish(events.AccessChanged, events.AccessChange{})
+	return nil
+}
+
+func (s *roleService) AssignRole(ctx context.Context, req dto.AssignRoleRequest, assignedBy int64) error {
+	if err := s.requireUserAndRole(ctx, req.UserID, req.RoleID); err != nil {
+		return err
+	}
+	perms, err := s.permRepo.GetByRoleID(ctx, req.RoleID)
+	if err != nil {
+		return err
+	}
+	slugs := make([]string, 0, len(perms))
+	for _, p := range perms {
+		slugs = append(slugs, p.Slug)
+	}
+	if err := ensureWithinPermissions(ctx, s.permRepo, assignedBy, slugs); err != nil {
+		return err
+	}
+	if err := s.repo.AssignRoleToUser(ctx, req.UserID, req.RoleID, &assignedBy); err != nil {
+		return err
+	}
+	s.bus.Publish(events.AccessChanged, events.AccessChange{UserID: req.UserID})
+	return nil
+}
+
+func (s *roleService) RevokeRole(ctx context.Context, req dto.AssignRoleRequest) error {
+	role, err := s.repo.GetByID(ctx, req.RoleID)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return utils.ErrNotFound
+	}
+	if role.Slug == models.RoleSlugAdmin {
+		if err := ensureNotLastAdmin(ctx, s.repo, req.UserID); err != nil {
+			return err
+		}
+	}
+
+	removed, err := s.repo.RevokeRoleFromUser(ctx, req.UserID, req.RoleID)
+	if errors.Is(err, repository.ErrLastAdmin) {
+		return utils.ErrLastAdmin
+	}
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return utils.ErrNotFound
+	}
+	s.bus.Publish(events.AccessChanged, events.AccessChange{UserID: req.UserID})
+	return nil
+}
+
+func (s *roleService) GetRolePermissions(ctx context.Context, roleID int64) (*dto.RolePermissionsResponse, error) {
+	if err := s.requireRole(ctx, roleID); err != nil {
+		return nil, err
+	}
+	return s.rolePermissions(ctx, roleID, 0)
+}
+
+// GrantPermissions adds permissions to a role. Unknown permission IDs fail the whole request
+// with utils.ErrBadRequest, and the caller may only grant permissions they hold themselves
+// (utils.ErrForbidden). Permissions the role already has are left untouched.
+func (s *roleService) GrantPermissions(ctx context.Context, roleID int64, req dto.RolePermissionsRequest, assignedBy int64) (*dto.RolePermissionsResponse, error) {
+	if err := s.requireRole(ctx, roleID); err != nil {
+		return nil, err
+	}
+	ids := uniqueIDs(req.PermissionIDs)
+	found, err := s.permRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) != len(ids) {
+		return nil, utils.ErrBadRequest
+	}
+	slugs := make([]string, 0, len(found))
+	for _, p := range found {
+		slugs = append(slugs, p.Slug)
+	}
+	if err := ensureWithinPermissions(ctx, s.permRepo, assignedBy, slugs); err != nil {
+		return nil, err
+	}
+
+	changed, err := s.permRepo.AssignPermissionsToRole(ctx, roleID, ids, &assignedBy)
+	if err != nil {
+		return nil, err
+	}
+	s.bus.Publish(events.AccessChanged, events.AccessChange{})
+	return s.rolePermissions(ctx, roleID, changed)
 }
 
-func (s *roleService) AssignRole(ctx context.Context, req dto.AssignRoleRequest) error {
-	return s.repo.AssignRoleToUser(ctx, req.UserID, req.RoleID)
+func (s *roleService) RevokePermissions(ctx context.Context, roleID int64, req dto.RolePermissionsRequest) (*dto.RolePermissionsResponse, error) {
+	if err := s.requireRole(ctx, roleID); err != nil {
+		return nil, err
+	}
+	changed, err := s.permRepo.RevokePermissionsFromRole(ctx, roleID, uniqueIDs(req.PermissionIDs))
+	if err != nil {
+		return nil, err
+	}
+	s.bus.Publish(events.AccessChanged, events.AccessChange{})
+	return s.rolePermissions(ctx, roleID, changed)
+}
+
+// GetUserAccess returns the user's roles and the union of the permissions those roles grant.
+func (s *roleService) GetUserAccess(ctx context.Context, userID int64) (*dto.UserAccessResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, utils.ErrNotFound
+	}
+
+	roles, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	perms, err := s.permRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.UserAccessResponse{
+		UserID:             userID,
+		Roles:              make([]dto.RoleResponse, 0, len(roles)),
+		Permissions:        perms,
+		PermissionsVersion: user.PermissionsVersion,
+	}
+	if resp.Permissions == nil {
+		resp.Permissions = []string{}
+	}
+	for _, r := range roles {
+		resp.Roles = append(resp.Roles, dto.RoleResponse{
+			ID:          r.ID,
+			Name:        r.Name,
+			Slug:        r.Slug,
+			Description: r.Description,
+			CreatedAt:   r.CreatedAt,
+			UpdatedAt:   r.UpdatedAt,
+		})
+	}
+	return resp, nil
+}
+
+func (s *roleService) rolePermissions(ctx context.Context, roleID int64, changed int64) (*dto.RolePermissionsResponse, error) {
+	perms, err := s.permRepo.GetByRoleID(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.RolePermissionsResponse{
+		RoleID:      roleID,
+		Changed:     changed,
+		Permissions: make([]dto.PermissionResponse, 0, len(perms)),
+	}
+	for _, p := range perms {
+		resp.Permissions = append(resp.Permissions, dto.PermissionResponse{
+			ID:        p.ID,
+			Name:      p.Name,
+			Slug:      p.Slug,
+			Module:    p.Module,
+			CreatedAt: p.CreatedAt,
+			UpdatedAt: p.UpdatedAt,
+		})
+	}
+	return resp, nil
+}
+
+func (s *roleService) requireRole(ctx context.Context, roleID int64) error {
+	role, err := s.repo.GetByID(ctx, roleID)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return utils.ErrNotFound
+	}
+	return nil
+}
+
+func (s *roleService) requireUserAndRole(ctx context.Context, userID, roleID int64) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return utils.ErrNotFound
+	}
+	return s.requireRole(ctx, roleID)
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	out := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
 }
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// PermissionScopeBypass lets a user see every row regardless of their data scopes.
+const PermissionScopeBypass = "data-scope-bypass"
+
+type ScopeService interface {
+	// ResolveScope returns the repository.Scope applied to the user's requests.
+	ResolveScope(ctx context.Context, userID int64) (repository.Scope, error)
+	GetUserScopes(ctx context.Context, userID int64) (*dto.UserScopesResponse, error)
+	AddUserScope(ctx context.Context, userID int64, req dto.UserScopeRequest, assignedBy int64) (*dto.UserScopesResponse, error)
+	RemoveUserScope(ctx context.Context, userID int64, req dto.UserScopeRequest) (*dto.UserScopesResponse, error)
+}
+
+type scopeService struct {
+	repo     repository.UserScopeRepository
+	userRepo repository.UserRepository
+	perms    PermissionService
+}
+
+func NewScopeService(repo repository.UserScopeRepository, userRepo repository.UserRepository, perms PermissionService) ScopeService {
+	return &scopeService{repo: repo, userRepo: userRepo, perms: perms}
+}
+
+// ResolveScope limits users to their assigned values. A user without any assignment of a
+// type sees no rows of that type unless they hold data-scope-bypass.
+func (s *scopeService) ResolveScope(ctx context.Context, userID int64) (repository.Scope, error) {
+	set, err := s.perms.GetUserPermissionSet(ctx, userID)
+	if err != nil {
+		return repository.Scope{}, err
+	}
+	if set.Has(PermissionScopeBypass) {
+		return repository.Scope{Bypass: true}, nil
+	}
+
+	scopes, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return repository.Scope{}, err
+	}
+	scope := repository.Scope{Locations: []string{}, Showrooms: []string{}}
+	for _, sc := range scopes {
+		switch sc.ScopeType {
+		case models.ScopeTypeLocation:
+			scope.Locations = append(scope.Locations, sc.Value)
+		case models.ScopeTypeShowroom:
+			scope.Showrooms = append(scope.Showrooms, sc.Value)
+		}
+	}
+	return scope, nil
+}
+
+func (s *scopeService) GetUserScopes(ctx context.Context, userID int64) (*dto.UserScopesResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, utils.ErrNotFound
+	}
+
+	scope, err := s.ResolveScope(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.UserScopesResponse{UserID: userID, Bypass: scope.Bypass, Locations: []string{}, Showrooms: []string{}}
+
+	// Report assignments even for bypass users so they are visible before the permission is revoked.
+	scopes, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sc := range scopes {
+		switch sc.ScopeType {
+		case models.ScopeTypeLocation:
+			resp.Locations = append(resp.Locations, sc.Value)
+		case models.ScopeTypeShowroom:
+			resp.Showrooms = append(resp.Showrooms, sc.Value)
+		}
+	}
+	return resp, nil
+}
+
+func (s *scopeService) AddUserScope(ctx context.Context, userID int64, req dto.UserScopeRequest, assignedBy int64) (*dto.UserScopesResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, utils.ErrNotFound
+	}
+
+	err = s.repo.Add(ctx, &models.UserScope{
+		UserID:     userID,
+		ScopeType:  req.ScopeType,
+		Value:      req.Value,
+		AssignedBy: &assignedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetUserScopes(ctx, userID)
+}
+
+func (s *scopeService) RemoveUserScope(ctx context.Context, userID int64, req dto.UserScopeRequest) (*dto.UserScopesResponse, error) {
+	removed, err := s.repo.Remove(ctx, userID, req.ScopeType, req.Value)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, utils.ErrNotFound
+	}
+	return s.GetUserScopes(ctx, userID)
+}
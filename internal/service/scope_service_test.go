@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/user/car-project/internal/events"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+)
+
+// MockUserScopeRepository returns fixed scope assignments for every user
+type MockUserScopeRepository struct {
+	repository.UserScopeRepository
+	scopes []models.UserScope
+}
+
+func (m *MockUserScopeRepository) GetByUserID(ctx context.Context, userID int64) ([]models.UserScope, error) {
+	return m.scopes, nil
+}
+
+func TestResolveScope(t *testing.T) {
+	ctx := context.Background()
+	assigned := &MockUserScopeRepository{scopes: []models.UserScope{
+		{UserID: 1, ScopeType: models.ScopeTypeLocation, Value: "Dhaka"},
+		{UserID: 1, ScopeType: models.ScopeTypeShowroom, Value: "Dhaka Showroom"},
+	}}
+
+	t.Run("Bypass", func(t *testing.T) {
+		perms := NewPermissionService(&MockPermissionRepository{slugs: []string{PermissionScopeBypass}}, events.NewBus(), time.Minute)
+		scope, err := NewScopeService(assigned, nil, perms).ResolveScope(ctx, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !scope.Bypass {
+			t.Errorf("Expected bypass scope, got %+v", scope)
+		}
+	})
+
+	t.Run("Assigned", func(t *testing.T) {
+		perms := NewPermissionService(&MockPermissionRepository{slugs: []string{"car-read"}}, events.NewBus(), time.Minute)
+		scope, err := NewScopeService(assigned, nil, perms).ResolveScope(ctx, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if scope.Bypass || len(scope.Locations) != 1 || scope.Locations[0] != "Dhaka" || len(scope.Showrooms) != 1 {
+			t.Errorf("Expected Dhaka location and showroom, got %+v", scope)
+		}
+	})
+
+	t.Run("DenyByDefault", func(t *testing.T) {
+		perms := NewPermissionService(&MockPermissionRepository{slugs: []string{"car-read"}}, events.NewBus(), time.Minute)
+		scope, err := NewScopeService(&MockUserScopeRepository{}, nil, perms).ResolveScope(ctx, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if scope.Bypass || scope.Locations == nil || len(scope.Locations) != 0 {
+			t.Errorf("Expected an empty, non-nil location scope, got %+v", scope)
+		}
+	})
+}
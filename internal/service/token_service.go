@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// ClientInfo describes the client making an authentication request.
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+type TokenService interface {
+	IssueTokens(ctx context.Context, userID int64, client ClientInfo) (*dto.TokenResponse, error)
+	Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*dto.TokenResponse, error)
+	Logout(ctx context.Context, userID int64, claims *utils.JWTClaims, refreshToken string) error
+	RevokeAllForUser(ctx context.Context, userID int64) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	// RunCleanup deletes refresh tokens and denylisted access tokens that have expired every
+	// interval until ctx is cancelled.
+	RunCleanup(ctx context.Context, interval time.Duration)
+}
+
+// AccessResolver looks up the roles and permissions embedded into access tokens.
+type AccessResolver interface {
+	GetUserAccess(ctx context.Context, userID int64) (*dto.UserAccessResponse, error)
+}
+
+type tokenService struct {
+	repo       repository.TokenRepository
+	userRepo   repository.UserRepository
+	access     AccessResolver
+	keys       *utils.KeySet
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewTokenService issues token pairs. When access is non-nil, role and permission slugs are
+// embedded into every access token; the user's permissions_version is always embedded.
+func NewTokenService(repo repository.TokenRepository, userRepo repository.UserRepository, access AccessResolver, keys *utils.KeySet, accessTTL, refreshTTL time.Duration) TokenService {
+	return &tokenService{
+		repo:       repo,
+		userRepo:   userRepo,
+		access:     access,
+		keys:       keys,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// IssueTokens starts a new refresh token family for a fresh login.
+func (s *tokenService) IssueTokens(ctx context.Context, userID int64, client ClientInfo) (*dto.TokenResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, utils.ErrUnauthorized
+	}
+	resp, token, err := s.newPair(ctx, user, uuid.New().String(), client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRefreshToken(ctx, token); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Refresh rotates a refresh token. Presenting a token that was already rotated or revoked
+// means it leaked, so the whole family is revoked and utils.ErrTokenReused is returned.
+func (s *tokenService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*dto.TokenResponse, error) {
+	current, err := s.repo.GetRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, utils.ErrUnauthorized
+	}
+	if current.RevokedAt != nil {
+		return nil, s.revokeReusedFamily(ctx, current.FamilyID)
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, utils.ErrUnauthorized
+	}
+
+	user, err := s.userRepo.GetByID(ctx, current.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		if err := s.repo.RevokeFamily(ctx, current.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, utils.ErrUnauthorized
+	}
+
+	resp, next, err := s.newPair(ctx, user, current.FamilyID, client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRefreshToken(ctx, current.ID, next); err != nil {
+		if errors.Is(err, repository.ErrTokenAlreadyRotated) {
+			return nil, s.revokeReusedFamily(ctx, current.FamilyID)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *tokenService) revokeReusedFamily(ctx context.Context, familyID string) error {
+	if err := s.repo.RevokeFamily(ctx, familyID); err != nil {
+		return err
+	}
+	utils.GetLogger().Printf("refresh token reuse detected; revoked token family %s", familyID)
+	return utils.ErrTokenReused
+}
+
+// Logout denylists the caller's access token and, when given, revokes the refresh token's family
+// if it belongs to userID, the authenticated principal. API-key callers have no claims.
+func (s *tokenService) Logout(ctx context.Context, userID int64, claims *utils.JWTClaims, refreshToken string) error {
+	if claims != nil && claims.ID != "" && claims.ExpiresAt != nil {
+		if err := s.repo.RevokeAccessToken(ctx, claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+			return err
+		}
+	}
+	if refreshToken == "" {
+		return nil
+	}
+
+	token, err := s.repo.GetRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	// Ignore tokens that belong to someone else rather than letting callers revoke them.
+	if token == nil || token.UserID != userID {
+		return nil
+	}
+	return s.repo.RevokeFamily(ctx, token.FamilyID)
+}
+
+func (s *tokenService) RevokeAllForUser(ctx context.Context, userID int64) error {
+	return s.repo.RevokeAllForUser(ctx, userID)
+}
+
+func (s *tokenService) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	return s.repo.IsAccessTokenRevoked(ctx, jti)
+}
+
+func (s *tokenService) newPair(ctx context.Context, user *models.User, familyID string, client ClientInfo) (*dto.TokenResponse, *models.RefreshToken, error) {
+	userID := user.ID
+	app := utils.JWTClaims{UserID: userID, PermissionsVersion: user.PermissionsVersion}
+	if s.access != nil {
+		grants, err := s.access.GetUserAccess(ctx, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		app.Permissions = grants.Permissions
+		for _, r := range grants.Roles {
+			app.Roles = append(app.Roles, r.Slug)
+		}
+	}
+
+	access, claims, err := utils.GenerateToken(app, s.keys, s.accessTTL)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	token := &models.RefreshToken{
+		UserID:          userID,
+		TokenHash:       hash,
+		FamilyID:        familyID,
+		AccessJTI:       claims.ID,
+		AccessExpiresAt: claims.ExpiresAt.Time,
+		ExpiresAt:       time.Now().Add(s.refreshTTL),
+		UserAgent:       optionalString(truncateString(client.UserAgent, 255)),
+		IPAddress:       optionalString(client.IPAddress),
+	}
+
+	return &dto.TokenResponse{
+		Token:            access,
+		ExpiresAt:        claims.ExpiresAt.Time,
+		RefreshToken:     raw,
+		RefreshExpiresAt: token.ExpiresAt,
+	}, token, nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func truncateString(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen]
+}
+
+func (s *tokenService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.repo.DeleteExpired(ctx); err != nil {
+				utils.GetLogger().Printf("expired token cleanup failed: %v", err)
+			}
+		}
+	}
+}
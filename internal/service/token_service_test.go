@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// MockTokenRepository keeps refresh tokens and the access token denylist in memory
+type MockTokenRepository struct {
+	tokens  []*models.RefreshToken
+	revoked map[string]bool
+}
+
+func newMockTokenRepository() *MockTokenRepository {
+	return &MockTokenRepository{revoked: make(map[string]bool)}
+}
+
+func (m *MockTokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	token.ID = int64(len(m.tokens) + 1)
+	m.tokens = append(m.tokens, token)
+	return nil
+}
+
+func (m *MockTokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	for _, t := range m.tokens {
+		if t.TokenHash == hash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockTokenRepository) ReplaceRefreshToken(ctx context.Context, oldID int64, next *models.RefreshToken) error {
+	old := m.tokens[oldID-1]
+	if old.RevokedAt != nil {
+		return repository.ErrTokenAlreadyRotated
+	}
+	now := time.Now()
+	old.RevokedAt = &now
+	if err := m.CreateRefreshToken(ctx, next); err != nil {
+		return err
+	}
+	old.ReplacedBy = &next.ID
+	return nil
+}
+
+func (m *MockTokenRepository) revokeWhere(match func(*models.RefreshToken) bool) {
+	now := time.Now()
+	for _, t := range m.tokens {
+		if match(t) {
+			m.revoked[t.AccessJTI] = true
+			if t.RevokedAt == nil {
+				t.RevokedAt = &now
+			}
+		}
+	}
+}
+
+func (m *MockTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	m.revokeWhere(func(t *models.RefreshToken) bool { return t.FamilyID == familyID })
+	return nil
+}
+
+func (m *MockTokenRepository) RevokeAllForUser(ctx context.Context, userID int64) error {
+	m.revokeWhere(func(t *models.RefreshToken) bool { return t.UserID == userID })
+	return nil
+}
+
+func (m *MockTokenRepository) RevokeAccessToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	m.revoked[jti] = true
+	return nil
+}
+
+func (m *MockTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return m.revoked[jti], nil
+}
+
+func (m *MockTokenRepository) DeleteExpired(ctx context.Context) error { return nil }
+
+// MockUserRepository returns a single active user for any ID
+type MockUserRepository struct {
+	repository.UserRepository
+	user *models.User
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	return m.user, nil
+}
+
+func TestTokenRefresh(t *testing.T) {
+	ctx := context.Background()
+	users := &MockUserRepository{user: &models.User{ID: 1, IsActive: true}}
+
+	t.Run("Rotates", func(t *testing.T) {
+		svc := NewTokenService(newMockTokenRepository(), users, nil, utils.NewHMACKeySet("secret"), time.Minute, time.Hour)
+		first, err := svc.IssueTokens(ctx, 1, ClientInfo{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		second, err := svc.Refresh(ctx, first.RefreshToken, ClientInfo{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if second.RefreshToken == first.RefreshToken {
+			t.Error("Expected a new refresh token after rotation")
+		}
+	})
+
+	t.Run("ReuseRevokesFamily", func(t *testing.T) {
+		repo := newMockTokenRepository()
+		svc := NewTokenService(repo, users, nil, utils.NewHMACKeySet("secret"), time.Minute, time.Hour)
+		first, _ := svc.IssueTokens(ctx, 1, ClientInfo{})
+		second, _ := svc.Refresh(ctx, first.RefreshToken, ClientInfo{})
+
+		if _, err := svc.Refresh(ctx, first.RefreshToken, ClientInfo{}); err != utils.ErrTokenReused {
+			t.Fatalf("Expected ErrTokenReused, got %v", err)
+		}
+		if _, err := svc.Refresh(ctx, second.RefreshToken, ClientInfo{}); err == nil {
+			t.Error("Expected the rotated-to token to be revoked with its family")
+		}
+		claims, err := utils.ValidateToken(second.Token, utils.NewHMACKeySet("secret"))
+		if err != nil {
+			t.Fatalf("Expected valid access token, got %v", err)
+		}
+		if revoked, _ := svc.IsRevoked(ctx, claims.ID); !revoked {
+			t.Error("Expected the family's live access token to be denylisted")
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		svc := NewTokenService(newMockTokenRepository(), users, nil, utils.NewHMACKeySet("secret"), time.Minute, -time.Hour)
+		first, _ := svc.IssueTokens(ctx, 1, ClientInfo{})
+		if _, err := svc.Refresh(ctx, first.RefreshToken, ClientInfo{}); err != utils.ErrUnauthorized {
+			t.Errorf("Expected ErrUnauthorized, got %v", err)
+		}
+	})
+
+	t.Run("LogoutIgnoresOtherUsersToken", func(t *testing.T) {
+		svc := NewTokenService(newMockTokenRepository(), users, nil, utils.NewHMACKeySet("secret"), time.Minute, time.Hour)
+		first, _ := svc.IssueTokens(ctx, 1, ClientInfo{})
+		// An API-key caller has no claims.
+		if err := svc.Logout(ctx, 2, nil, first.RefreshToken); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := svc.Refresh(ctx, first.RefreshToken, ClientInfo{}); err != nil {
+			t.Errorf("Expected another user's refresh token to survive, got %v", err)
+		}
+	})
+}
+
+type fakeAccessResolver struct{}
+
+func (fakeAccessResolver) GetUserAccess(ctx context.Context, userID int64) (*dto.UserAccessResponse, error) {
+	return &dto.UserAccessResponse{
+		UserID:      userID,
+		Roles:       []dto.RoleResponse{{Slug: "seller"}},
+		Permissions: []string{"car-read"},
+	}, nil
+}
+
+func TestTokenClaimsEmbedAccess(t *testing.T) {
+	users := &MockUserRepository{user: &models.User{ID: 1, IsActive: true, PermissionsVersion: 7}}
+	svc := NewTokenService(newMockTokenRepository(), users, fakeAccessResolver{}, utils.NewHMACKeySet("secret"), time.Minute, time.Hour)
+
+	pair, err := svc.IssueTokens(context.Background(), 1, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	claims, err := utils.ValidateToken(pair.Token, utils.NewHMACKeySet("secret"))
+	if err != nil {
+		t.Fatalf("Expected valid access token, got %v", err)
+	}
+	if claims.PermissionsVersion != 7 {
+		t.Errorf("Expected permissions_version 7, got %d", claims.PermissionsVersion)
+	}
+	if len(claims.Roles) != 1 || claims.Roles[0] != "seller" || len(claims.Permissions) != 1 {
+		t.Errorf("Expected embedded roles and permissions, got %v %v", claims.Roles, claims.Permissions)
+	}
+}
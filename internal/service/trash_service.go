@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/car-project/internal/dto"
+	"github.com/user/car-project/internal/repository"
+	"github.com/user/car-project/internal/utils"
+)
+
+// TrashService lists soft-deleted cars and users and permanently removes those that have
+// been in the trash longer than the retention period. Restoring is done by CarService and
+// UserService.
+type TrashService interface {
+	GetTrash(ctx context.Context) (*dto.TrashResponse, error)
+	// Purge removes every car and user deleted more than the retention period ago.
+	Purge(ctx context.Context) (*dto.PurgeResponse, error)
+	// RunPurger calls Purge every interval until ctx is cancelled.
+	RunPurger(ctx context.Context, interval time.Duration)
+}
+
+type trashService struct {
+	cars      repository.CarRepository
+	users     repository.UserRepository
+	retention time.Duration
+	now       func() time.Time
+}
+
+func NewTrashService(cars repository.CarRepository, users repository.UserRepository, retention time.Duration) TrashService {
+	return &trashService{cars: cars, users: users, retention: retention, now: time.Now}
+}
+
+func (s *trashService) GetTrash(ctx context.Context) (*dto.TrashResponse, error) {
+	cars, err := s.cars.GetDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.users.GetDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.TrashResponse{
+		RetentionDays: int(s.retention / (24 * time.Hour)),
+		Cars:          []dto.TrashedCar{},
+		Users:         []dto.TrashedUser{},
+	}
+	for _, c := range cars {
+		resp.Cars = append(resp.Cars, dto.TrashedCar{Car: c, PurgeAt: c.DeletedAt.Add(s.retention)})
+	}
+	for _, u := range users {
+		resp.Users = append(resp.Users, dto.TrashedUser{
+			UserResponse: dto.UserResponse{
+				ID:          u.ID,
+				Name:        u.Name,
+				Username:    u.Username,
+				Email:       u.Email,
+				IsActive:    u.IsActive,
+				LastLoginAt: u.LastLoginAt,
+				CreatedAt:   u.CreatedAt,
+				UpdatedAt:   u.UpdatedAt,
+			},
+			DeletedAt: *u.DeletedAt,
+			PurgeAt:   u.DeletedAt.Add(s.retention),
+		})
+	}
+	return resp, nil
+}
+
+func (s *trashService) Purge(ctx context.Context) (*dto.PurgeResponse, error) {
+	cutoff := s.now().Add(-s.retention)
+	cars, err := s.cars.Purge(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.users.Purge(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.PurgeResponse{CarsPurged: cars, UsersPurged: users}, nil
+}
+
+func (s *trashService) RunPurger(ctx context.Context, interval time.Duration) {
+	logger := utils.GetLogger()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			res, err := s.Purge(ctx)
+			if err != nil {
+				logger.Printf("trash purge failed: %v", err)
+				continue
+			}
+			if res.CarsPurged > 0 || res.UsersPurged > 0 {
+				logger.Printf("trash purge removed %d cars and %d users", res.CarsPurged, res.UsersPurged)
+			}
+		}
+	}
+}
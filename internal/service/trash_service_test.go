@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/user/car-project/internal/models"
+	"github.com/user/car-project/internal/repository"
+)
+
+// MockTrashUserRepository holds deleted users and records the purge cutoff
+type MockTrashUserRepository struct {
+	repository.UserRepository
+	deleted []models.User
+	cutoff  time.Time
+}
+
+func (m *MockTrashUserRepository) GetDeleted(ctx context.Context) ([]models.User, error) {
+	return m.deleted, nil
+}
+
+func (m *MockTrashUserRepository) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	m.cutoff = cutoff
+	return 0, nil
+}
+
+func TestTrashService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	retention := 30 * 24 * time.Hour
+	old := now.Add(-40 * 24 * time.Hour)
+	recent := now.Add(-24 * time.Hour)
+
+	cars := &MockRepository{cars: []models.Car{{ID: 1, DeletedAt: &old}, {ID: 2, DeletedAt: &recent}}}
+	users := &MockTrashUserRepository{deleted: []models.User{{ID: 7, Username: "gone", DeletedAt: &recent}}}
+	svc := &trashService{cars: cars, users: users, retention: retention, now: func() time.Time { return now }}
+
+	t.Run("ListsPurgeTimes", func(t *testing.T) {
+		trash, err := svc.GetTrash(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if trash.RetentionDays != 30 {
+			t.Errorf("Expected 30 retention days, got %d", trash.RetentionDays)
+		}
+		if len(trash.Cars) != 2 || !trash.Cars[1].PurgeAt.Equal(recent.Add(retention)) {
+			t.Errorf("Expected car 2 to be purged at %v, got %+v", recent.Add(retention), trash.Cars)
+		}
+		if len(trash.Users) != 1 || !trash.Users[0].PurgeAt.Equal(recent.Add(retention)) {
+			t.Errorf("Expected user 7 to be purged at %v, got %+v", recent.Add(retention), trash.Users)
+		}
+	})
+
+	t.Run("PurgesOnlyExpired", func(t *testing.T) {
+		res, err := svc.Purge(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if res.CarsPurged != 1 {
+			t.Errorf("Expected 1 car purged, got %d", res.CarsPurged)
+		}
+		if want := now.Add(-retention); !users.cutoff.Equal(want) {
+			t.Errorf("Expected user cutoff %v, got %v", want, users.cutoff)
+		}
+	})
+}
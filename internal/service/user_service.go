@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/user/car-project/internal/dto"
@@ -12,29 +14,48 @@ import (
 )
 
 type UserService interface {
-	CreateUser(ctx context.Context, req dto.CreateUserRequest) (*dto.UserResponse, error)
+	CreateUser(ctx context.Context, req dto.CreateUserRequest, createdBy int64) (*dto.UserResponse, error)
 	GetUsers(ctx context.Context) ([]dto.UserResponse, error)
 	GetUserByID(ctx context.Context, id int64) (*dto.UserResponse, error)
-	UpdateUser(ctx context.Context, id int64, req dto.UpdateUserRequest) error
-	DeleteUser(ctx context.Context, id int64) error
-	Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error)
+	// UpdateUser and DeleteUser fail with ErrPreconditionFailed unless the user is still at version.
+	UpdateUser(ctx context.Context, id, version int64, req dto.UpdateUserRequest, updatedBy int64) (*dto.UserResponse, error)
+	DeleteUser(ctx context.Context, id, version int64, deletedBy int64) error
+	// RestoreUser takes a deleted user out of the trash. It fails with ErrAlreadyExists when
+	// another user has taken their username or email in the meantime.
+	RestoreUser(ctx context.Context, id int64, restoredBy int64) error
+	Login(ctx context.Context, req dto.LoginRequest, client ClientInfo) (*dto.LoginResponse, error)
+	GetLoginEvents(ctx context.Context, userID int64, limit int) ([]dto.LoginEventResponse, error)
+}
+
+// LoginPolicy limits password guessing. Once a username or an IP reaches its failure limit
+// within the Lockout window, further attempts are refused until the window passes.
+type LoginPolicy struct {
+	MaxFailuresPerUser int
+	MaxFailuresPerIP   int
+	Lockout            time.Duration
 }
 
 type userService struct {
-	repo           repository.UserRepository
-	jwtSecret      string
-	jwtExpiryHours int
+	repo      repository.UserRepository
+	events    repository.LoginEventRepository
+	roles     repository.RoleRepository
+	tokens    TokenService
+	policy    LoginPolicy
+	passwords utils.PasswordPolicy
 }
 
-func NewUserService(repo repository.UserRepository, jwtSecret string, jwtExpiryHours int) UserService {
+func NewUserService(repo repository.UserRepository, events repository.LoginEventRepository, roles repository.RoleRepository, tokens TokenService, policy LoginPolicy, passwords utils.PasswordPolicy) UserService {
 	return &userService{
-		repo:           repo,
-		jwtSecret:      jwtSecret,
-		jwtExpiryHours: jwtExpiryHours,
+		repo:      repo,
+		events:    events,
+		roles:     roles,
+		tokens:    tokens,
+		policy:    policy,
+		passwords: passwords,
 	}
 }
 
-func (s *userService) CreateUser(ctx context.Context, req dto.CreateUserRequest) (*dto.UserResponse, error) {
+func (s *userService) CreateUser(ctx context.Context, req dto.CreateUserRequest, createdBy int64) (*dto.UserResponse, error) {
 	// Check if already exists
 	existingUser, _ := s.repo.GetByEmail(ctx, req.Email)
 	if existingUser != nil {
@@ -46,6 +67,10 @@ func (s *userService) CreateUser(ctx context.Context, req dto.CreateUserRequest)
 		return nil, utils.ErrAlreadyExists
 	}
 
+	if problems := s.passwords.Validate(req.Password); len(problems) > 0 {
+		return nil, &utils.PasswordPolicyError{Problems: problems}
+	}
+
 	// Hash password
 	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -58,6 +83,8 @@ func (s *userService) CreateUser(ctx context.Context, req dto.CreateUserRequest)
 		Email:        req.Email,
 		PasswordHash: string(hashedBytes),
 		IsActive:     true,
+		CreatedBy:    &createdBy,
+		UpdatedBy:    &createdBy,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
@@ -74,6 +101,7 @@ func (s *userService) CreateUser(ctx context.Context, req dto.CreateUserRequest)
 		IsActive:  user.IsActive,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
+		Version:   user.Version,
 	}, nil
 }
 
@@ -94,6 +122,7 @@ func (s *userService) GetUsers(ctx context.Context) ([]dto.UserResponse, error)
 			LastLoginAt: u.LastLoginAt,
 			CreatedAt:   u.CreatedAt,
 			UpdatedAt:   u.UpdatedAt,
+			Version:     u.Version,
 		})
 	}
 	return userDTOs, nil
@@ -117,16 +146,23 @@ func (s *userService) GetUserByID(ctx context.Context, id int64) (*dto.UserRespo
 		LastLoginAt: user.LastLoginAt,
 		CreatedAt:   user.CreatedAt,
 		UpdatedAt:   user.UpdatedAt,
+		Version:     user.Version,
 	}, nil
 }
 
-func (s *userService) UpdateUser(ctx context.Context, id int64, req dto.UpdateUserRequest) error {
+func (s *userService) UpdateUser(ctx context.Context, id, version int64, req dto.UpdateUserRequest, updatedBy int64) (*dto.UserResponse, error) {
 	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if user == nil {
-		return utils.ErrNotFound
+		return nil, utils.ErrNotFound
+	}
+	if user.Version != version {
+		return nil, utils.ErrPreconditionFailed
+	}
+	if err := ensureCanManageUser(ctx, s.roles, updatedBy, id); err != nil {
+		return nil, err
 	}
 
 	if req.Name != nil {
@@ -136,41 +172,101 @@ func (s *userService) UpdateUser(ctx context.Context, id int64, req dto.UpdateUs
 		user.Email = *req.Email
 	}
 	if req.IsActive != nil {
+		if user.IsActive && !*req.IsActive {
+			if err := ensureNotLastAdmin(ctx, s.roles, id); err != nil {
+				return nil, err
+			}
+		}
 		user.IsActive = *req.IsActive
 	}
+	user.UpdatedBy = &updatedBy
 
-	return s.repo.Update(ctx, user)
+	if err := userError(s.repo.Update(ctx, user)); err != nil {
+		return nil, err
+	}
+	return &dto.UserResponse{
+		ID:          user.ID,
+		Name:        user.Name,
+		Username:    user.Username,
+		Email:       user.Email,
+		IsActive:    user.IsActive,
+		LastLoginAt: user.LastLoginAt,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Version:     user.Version,
+	}, nil
 }
 
-func (s *userService) DeleteUser(ctx context.Context, id int64) error {
-	return s.repo.Delete(ctx, id)
+func (s *userService) DeleteUser(ctx context.Context, id, version int64, deletedBy int64) error {
+	if err := ensureCanManageUser(ctx, s.roles, deletedBy, id); err != nil {
+		return err
+	}
+	if err := ensureNotLastAdmin(ctx, s.roles, id); err != nil {
+		return err
+	}
+	return userError(s.repo.Delete(ctx, id, version, &deletedBy))
 }
 
-func (s *userService) Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error) {
+func (s *userService) RestoreUser(ctx context.Context, id int64, restoredBy int64) error {
+	return userError(s.repo.Restore(ctx, id, &restoredBy))
+}
+
+// userError maps repository errors for missing, duplicate or concurrently modified users to service errors.
+func userError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return utils.ErrNotFound
+	case errors.Is(err, repository.ErrDuplicate):
+		return utils.ErrAlreadyExists
+	case errors.Is(err, repository.ErrVersionConflict):
+		return utils.ErrPreconditionFailed
+	case errors.Is(err, repository.ErrLastAdmin):
+		return utils.ErrLastAdmin
+	}
+	return err
+}
+
+func (s *userService) Login(ctx context.Context, req dto.LoginRequest, client ClientInfo) (*dto.LoginResponse, error) {
+	attempt, err := s.beginLogin(ctx, req.Username, client)
+	if err != nil {
+		return nil, err
+	}
+
 	user, err := s.repo.GetByUsername(ctx, req.Username)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
+		s.finishLogin(ctx, attempt, nil, models.LoginOutcomeUnknownUser)
 		return nil, utils.ErrUnauthorized
 	}
 
 	if !user.IsActive {
+		s.finishLogin(ctx, attempt, &user.ID, models.LoginOutcomeInactive)
 		return nil, utils.ErrUnauthorized
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		s.finishLogin(ctx, attempt, &user.ID, models.LoginOutcomeInvalidPassword)
 		return nil, utils.ErrUnauthorized
 	}
 
-	// Generate real JWT token
-	token, err := utils.GenerateToken(user.ID, s.jwtSecret, s.jwtExpiryHours)
+	// Issue a short-lived access token and a rotating refresh token
+	tokens, err := s.tokens.IssueTokens(ctx, user.ID, client)
 	if err != nil {
 		return nil, err
 	}
 
+	s.finishLogin(ctx, attempt, &user.ID, models.LoginOutcomeSuccess)
+	if err := s.repo.UpdateLastLogin(ctx, user.ID); err != nil {
+		utils.GetLogger().Printf("Failed to update last_login_at for user %d: %v", user.ID, err)
+	} else {
+		now := time.Now()
+		user.LastLoginAt = &now
+	}
+
 	return &dto.LoginResponse{
-		Token: token,
+		TokenResponse: *tokens,
 		User: dto.UserResponse{
 			ID:          user.ID,
 			Name:        user.Name,
@@ -183,3 +279,66 @@ func (s *userService) Login(ctx context.Context, req dto.LoginRequest) (*dto.Log
 		},
 	}, nil
 }
+
+// beginLogin records the attempt and returns a *utils.LockoutError when the username or IP
+// has too many recent failures. The attempt counts as a failure until finishLogin is called.
+func (s *userService) beginLogin(ctx context.Context, username string, client ClientInfo) (*models.LoginEvent, error) {
+	attempt, stats, err := s.events.BeginAttempt(ctx, repository.LoginAttempt{
+		Username:           truncateString(username, 80),
+		IPAddress:          optionalString(client.IPAddress),
+		UserAgent:          optionalString(truncateString(client.UserAgent, 255)),
+		Since:              time.Now().Add(-s.policy.Lockout),
+		MaxFailuresPerUser: s.policy.MaxFailuresPerUser,
+		MaxFailuresPerIP:   s.policy.MaxFailuresPerIP,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if attempt.Outcome == models.LoginOutcomeLockedOut {
+		return nil, s.lockoutError(stats)
+	}
+	return attempt, nil
+}
+
+// lockoutError reports the lockout as ending once the most recent failure leaves the window.
+func (s *userService) lockoutError(stats *repository.FailureStats) error {
+	retryAfter := s.policy.Lockout
+	if stats.LastFailure != nil {
+		retryAfter = time.Until(stats.LastFailure.Add(s.policy.Lockout))
+	}
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+	return &utils.LockoutError{RetryAfter: retryAfter}
+}
+
+// finishLogin sets the outcome of a login attempt. Failures are logged but never block the
+// login response; an attempt left pending keeps counting as a failure.
+func (s *userService) finishLogin(ctx context.Context, attempt *models.LoginEvent, userID *int64, outcome string) {
+	if err := s.events.FinishAttempt(ctx, attempt.ID, userID, outcome); err != nil {
+		utils.GetLogger().Printf("Failed to record login outcome for %q: %v", attempt.Username, err)
+	}
+}
+
+func (s *userService) GetLoginEvents(ctx context.Context, userID int64, limit int) ([]dto.LoginEventResponse, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	events, err := s.events.GetByUserID(ctx, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	eventDTOs := make([]dto.LoginEventResponse, 0, len(events))
+	for _, e := range events {
+		eventDTOs = append(eventDTOs, dto.LoginEventResponse{
+			ID:        e.ID,
+			Username:  e.Username,
+			IPAddress: e.IPAddress,
+			UserAgent: e.UserAgent,
+			Outcome:   e.Outcome,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	return eventDTOs, nil
+}
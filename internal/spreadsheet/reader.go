@@ -0,0 +1,66 @@
+// Package spreadsheet reads and writes the tabular files used for bulk import and export:
+// CSV and XLSX (Office Open XML). Only what those features need is supported: the first
+// worksheet of a workbook, cell values as text, and no styles or formulas.
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupportedFormat is returned for files that are neither .csv nor .xlsx.
+var ErrUnsupportedFormat = errors.New("unsupported spreadsheet format; use .csv or .xlsx")
+
+// Format is a spreadsheet file type.
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+// FormatOf returns the format implied by a file name's extension.
+func FormatOf(name string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return CSV, nil
+	case ".xlsx":
+		return XLSX, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Read returns every row of a CSV file or of the first worksheet of an XLSX file, as text.
+// Rows may have different lengths.
+func Read(format Format, r io.Reader) ([][]string, error) {
+	switch format {
+	case CSV:
+		return ReadCSV(r)
+	case XLSX:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return ReadXLSX(bytes.NewReader(data), int64(len(data)))
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// ReadCSV reads comma-separated rows, skipping a UTF-8 byte order mark as written by Excel.
+func ReadCSV(r io.Reader) ([][]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+	}
+	return rows, nil
+}
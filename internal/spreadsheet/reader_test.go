@@ -0,0 +1,60 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	rows, err := Read(CSV, strings.NewReader("\ufeffmake,model\nToyota, Corolla\nHonda\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := [][]string{{"make", "model"}, {"Toyota", "Corolla"}, {"Honda"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected %q, got %q", want, rows)
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+			xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="Stock" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Target="worksheets/stock.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>make</t></si><si><r><t>Toy</t></r><r><t>ota</t></r></si></sst>`,
+		"xl/worksheets/stock.xml": `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="inlineStr"><is><t>year</t></is></c></row>
+			<row r="3"><c r="A3" t="s"><v>1</v></c><c r="B3" t="b"><v>1</v></c><c r="C3"><v>2019</v></c></row>
+			</sheetData></worksheet>`,
+	}
+	for name, body := range parts {
+		w, _ := zw.Create(name)
+		w.Write([]byte(body))
+	}
+	zw.Close()
+
+	rows, err := Read(XLSX, &buf)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := [][]string{{"make", "", "year"}, nil, {"Toyota", "TRUE", "2019"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected %q, got %q", want, rows)
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	if f, err := FormatOf("Stock.XLSX"); err != nil || f != XLSX {
+		t.Errorf("Expected xlsx, got %q, %v", f, err)
+	}
+	if _, err := FormatOf("stock.xls"); err != ErrUnsupportedFormat {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+}
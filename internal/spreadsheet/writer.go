@@ -0,0 +1,118 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Writer writes rows to a spreadsheet as they are produced, without holding them in memory.
+type Writer interface {
+	// WriteRow appends one row. Values may be strings, integers, floats, time.Time or
+	// pointers to them; nil values and nil pointers become empty cells. Numbers are written
+	// as numeric cells in XLSX.
+	WriteRow(values ...interface{}) error
+	// Close finishes the file. It does not close the underlying io.Writer.
+	Close() error
+}
+
+// NewWriter returns a Writer for format that writes to w.
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case CSV:
+		return NewCSVWriter(w)
+	case XLSX:
+		return NewXLSXWriter(w, "Sheet1")
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// csvWriter writes UTF-8 CSV with a byte order mark, so Excel detects the encoding.
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVWriter returns a Writer for comma-separated values.
+func NewCSVWriter(w io.Writer) (Writer, error) {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: csv.NewWriter(w)}, nil
+}
+
+func (c *csvWriter) WriteRow(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		v = cellValue(v)
+		if s, ok := v.(string); ok {
+			record[i] = escapeFormula(s)
+		} else {
+			record[i] = formatCell(v)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula prefixes text that a spreadsheet application would evaluate as a formula
+// with an apostrophe, so that exported data cannot run formulas when the file is opened.
+func escapeFormula(s string) string {
+	if s != "" {
+		switch s[0] {
+		case '=', '+', '-', '@', '\t', '\r':
+			return "'" + s
+		}
+	}
+	return s
+}
+
+// cellValue dereferences pointers and turns nil into an empty string.
+func cellValue(v interface{}) interface{} {
+	if v == nil {
+		return ""
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	return rv.Interface()
+}
+
+// isNumber reports whether v, as returned by cellValue, is written as a number.
+func isNumber(v interface{}) bool {
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// formatCell returns the text of a value returned by cellValue.
+func formatCell(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case time.Time:
+		if x.IsZero() {
+			return ""
+		}
+		return x.Format("2006-01-02 15:04:05")
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(x), 'f', -1, 32)
+	}
+	return fmt.Sprint(v)
+}
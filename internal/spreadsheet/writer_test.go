@@ -0,0 +1,62 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	year := int16(2019)
+	var noColor *string
+	created := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	want := [][]string{
+		{"ref_no", "year", "color", "note", "created_at"},
+		{"R1", "2019", "", "a < b & c", "2024-05-01 09:30:00"},
+	}
+
+	for _, format := range []Format{CSV, XLSX} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(format, &buf)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			w.WriteRow("ref_no", "year", "color", "note", "created_at")
+			w.WriteRow("R1", &year, noColor, "a < b & c", created)
+			if err := w.Close(); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			rows, err := Read(format, &buf)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(rows, want) {
+				t.Errorf("Expected %q, got %q", want, rows)
+			}
+		})
+	}
+}
+
+func TestCSVEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewCSVWriter(&buf)
+	w.WriteRow("=HYPERLINK(\"x\")", -5)
+	w.Close()
+	if got := buf.String(); got != "\ufeff\"'=HYPERLINK(\"\"x\"\")\",-5\n" {
+		t.Errorf("Expected the formula to be escaped, got %q", got)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("Expected %s for %d, got %s", want, i, got)
+		}
+		if back, _ := columnIndex(want + "1"); back != i {
+			t.Errorf("Expected %s to map back to %d, got %d", want, i, back)
+		}
+	}
+}
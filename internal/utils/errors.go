@@ -1,6 +1,9 @@
 package utils
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrNotFound      = errors.New("resource not found")
@@ -8,4 +11,29 @@ var (
 	ErrBadRequest    = errors.New("bad request")
 	ErrInternal      = errors.New("internal server error")
 	ErrAlreadyExists = errors.New("resource already exists")
+	ErrTokenReused   = errors.New("refresh token reuse detected")
+	ErrLockedOut     = errors.New("too many failed login attempts")
+	ErrWeakPassword  = errors.New("password does not meet the strength policy")
+	ErrForbidden     = errors.New("forbidden")
+	ErrLastAdmin     = errors.New("cannot remove the last active administrator")
+	// ErrConflict means the request is not allowed in the resource's current state.
+	ErrConflict = errors.New("conflicts with the current state")
+	// ErrPreconditionFailed means the resource changed since the client read it (If-Match did not match).
+	ErrPreconditionFailed = errors.New("resource has been modified")
 )
+
+// LockoutError reports a temporary login lockout and when it ends. It matches ErrLockedOut with errors.Is.
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string { return ErrLockedOut.Error() }
+func (e *LockoutError) Unwrap() error { return ErrLockedOut }
+
+// PasswordPolicyError lists the policy rules a password broke. It matches ErrWeakPassword with errors.Is.
+type PasswordPolicyError struct {
+	Problems []string
+}
+
+func (e *PasswordPolicyError) Error() string { return ErrWeakPassword.Error() }
+func (e *PasswordPolicyError) Unwrap() error { return ErrWeakPassword }
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionETag formats a row version as a strong entity tag, e.g. "3".
+func VersionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseVersionETag reads a row version from an If-Match value written by VersionETag. Weak
+// tags, lists and "*" are rejected: If-Match must name the exact version the client read.
+func ParseVersionETag(tag string) (int64, bool) {
+	tag = strings.TrimSpace(tag)
+	if len(tag) < 3 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || v < 1 {
+		return 0, false
+	}
+	return v, true
+}
+
+// SetVersionETag sets the ETag response header to the given row version.
+func SetVersionETag(c *gin.Context, version int64) {
+	c.Header("ETag", VersionETag(version))
+}
+
+// SkipETag makes middleware.ETagMiddleware pass the response through instead of buffering it
+// to compute an ETag. Call it before writing a streamed download that should not be held in memory.
+func SkipETag(c *gin.Context) {
+	c.Set(SkipETagContextKey, true)
+}
+
+// GetIfMatchVersion returns the row version from the request's If-Match header (set by
+// middleware.RequireIfMatch). The second return value is false when there is none.
+func GetIfMatchVersion(c *gin.Context) (int64, bool) {
+	if v, ok := c.Get(IfMatchContextKey); ok {
+		if version, ok := v.(int64); ok {
+			return version, true
+		}
+	}
+	return 0, false
+}
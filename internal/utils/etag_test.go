@@ -0,0 +1,20 @@
+package utils
+
+import "testing"
+
+func TestParseVersionETag(t *testing.T) {
+	t.Run("RoundTrip", func(t *testing.T) {
+		v, ok := ParseVersionETag(VersionETag(42))
+		if !ok || v != 42 {
+			t.Errorf("Expected 42, got %d (ok=%v)", v, ok)
+		}
+	})
+
+	t.Run("Rejects", func(t *testing.T) {
+		for _, tag := range []string{"", "42", `W/"42"`, "*", `"42", "43"`, `"abc"`, `"0"`} {
+			if _, ok := ParseVersionETag(tag); ok {
+				t.Errorf("Expected %q to be rejected", tag)
+			}
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// GroupThousands formats n with comma separators, e.g. 12,500.
+func GroupThousands(n int64) string {
+	if n < 0 {
+		return "-" + GroupThousands(-n)
+	}
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// FormatMoney formats an amount with two decimals and comma separators, e.g. 1,250,000.00.
+func FormatMoney(amount float64) string {
+	cents := int64(math.Round(math.Abs(amount) * 100))
+	s := GroupThousands(cents/100) + "." + strings.TrimPrefix(strconv.FormatInt(100+cents%100, 10), "1")
+	if amount < 0 && cents != 0 {
+		return "-" + s
+	}
+	return s
+}
@@ -0,0 +1,19 @@
+package utils
+
+import "testing"
+
+func TestGroupThousands(t *testing.T) {
+	for n, want := range map[int64]string{0: "0", 999: "999", 1000: "1,000", 1234567: "1,234,567", -4500: "-4,500"} {
+		if got := GroupThousands(n); got != want {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+	}
+}
+
+func TestFormatMoney(t *testing.T) {
+	for amount, want := range map[float64]string{0: "0.00", 5.5: "5.50", 1250000: "1,250,000.00", 0.105: "0.11", -20.07: "-20.07"} {
+		if got := FormatMoney(amount); got != want {
+			t.Errorf("Expected %s for %v, got %s", want, amount, got)
+		}
+	}
+}
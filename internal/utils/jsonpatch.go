@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Patch media types accepted by PATCH endpoints.
+const (
+	MergePatchContentType = "application/merge-patch+json" // RFC 7396
+	JSONPatchContentType  = "application/json-patch+json"  // RFC 6902
+)
+
+// PatchError describes why a patch document could not be applied. It matches ErrBadRequest with errors.Is.
+type PatchError struct {
+	Reason string
+}
+
+func (e *PatchError) Error() string { return e.Reason }
+func (e *PatchError) Unwrap() error { return ErrBadRequest }
+
+func patchErrorf(format string, args ...interface{}) error {
+	return &PatchError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// ApplyPatch applies patch to the JSON document doc. contentType selects the format:
+// JSONPatchContentType for an RFC 6902 operation list, anything else for an RFC 7396 merge patch.
+func ApplyPatch(doc, patch []byte, contentType string) ([]byte, error) {
+	if contentType == JSONPatchContentType {
+		return ApplyJSONPatch(doc, patch)
+	}
+	return MergePatch(doc, patch)
+}
+
+// MergePatch applies an RFC 7396 merge patch: objects are merged recursively, null removes a
+// member and any other value replaces it.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target, p interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, patchErrorf("invalid merge patch: %v", err)
+	}
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergeValue(t[k], v)
+		}
+	}
+	return t
+}
+
+type jsonPatchOp struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// ApplyJSONPatch applies an RFC 6902 JSON Patch (add, remove, replace, move, copy and test).
+// The operations are applied in order and the whole patch fails if any of them does.
+func ApplyJSONPatch(doc, patch []byte) ([]byte, error) {
+	var root interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, err
+	}
+	var ops []jsonPatchOp
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, patchErrorf("invalid JSON patch: %v", err)
+	}
+
+	for i, op := range ops {
+		if op.Path == nil {
+			return nil, patchErrorf("operation %d: missing path", i)
+		}
+		path, err := parsePointer(*op.Path)
+		if err != nil {
+			return nil, patchErrorf("operation %d: %v", i, err)
+		}
+		var value interface{}
+		if op.Op == "add" || op.Op == "replace" || op.Op == "test" {
+			if op.Value == nil {
+				return nil, patchErrorf("operation %d: missing value", i)
+			}
+			if err := json.Unmarshal(*op.Value, &value); err != nil {
+				return nil, patchErrorf("operation %d: invalid value: %v", i, err)
+			}
+		}
+
+		switch op.Op {
+		case "add":
+			root, err = pointerAdd(root, path, value)
+		case "remove":
+			root, _, err = pointerRemove(root, path)
+		case "replace":
+			if root, _, err = pointerRemove(root, path); err == nil {
+				root, err = pointerAdd(root, path, value)
+			}
+		case "move", "copy":
+			if op.From == nil {
+				return nil, patchErrorf("operation %d: missing from", i)
+			}
+			var from []string
+			if from, err = parsePointer(*op.From); err != nil {
+				break
+			}
+			var moved interface{}
+			if op.Op == "move" {
+				root, moved, err = pointerRemove(root, from)
+			} else {
+				moved, err = pointerGet(root, from)
+				moved = deepCopy(moved)
+			}
+			if err == nil {
+				root, err = pointerAdd(root, path, moved)
+			}
+		case "test":
+			var current interface{}
+			if current, err = pointerGet(root, path); err == nil && !reflect.DeepEqual(current, value) {
+				err = fmt.Errorf("test failed at %q", *op.Path)
+			}
+		default:
+			err = fmt.Errorf("unknown op %q", op.Op)
+		}
+		if err != nil {
+			return nil, patchErrorf("operation %d: %v", i, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	max := length - 1
+	if allowEnd {
+		max = length
+	}
+	if i > max {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func pointerGet(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("path member %q not found", token)
+			}
+			node = v
+		case []interface{}:
+			i, err := arrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("cannot traverse into %q", token)
+		}
+	}
+	return node, nil
+}
+
+// pointerAdd returns node with value added at path; adding at the root replaces the document.
+func pointerAdd(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := pointerGet(node, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		p[last] = value
+		return node, nil
+	case []interface{}:
+		i, err := arrayIndex(last, len(p), true)
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, nil)
+		copy(p[i+1:], p[i:])
+		p[i] = value
+		return setParent(node, path[:len(path)-1], p)
+	}
+	return nil, fmt.Errorf("cannot add to %q", last)
+}
+
+// pointerRemove returns node without the value at path, and that value.
+func pointerRemove(node interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, node, nil
+	}
+	parent, err := pointerGet(node, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		v, ok := p[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("path member %q not found", last)
+		}
+		delete(p, last)
+		return node, v, nil
+	case []interface{}:
+		i, err := arrayIndex(last, len(p), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		v := p[i]
+		p = append(p[:i:i], p[i+1:]...)
+		node, err = setParent(node, path[:len(path)-1], p)
+		return node, v, err
+	}
+	return nil, nil, fmt.Errorf("cannot remove from %q", last)
+}
+
+// setParent stores a resized array back at path, since appending may have reallocated it.
+func setParent(node interface{}, path []string, arr []interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return arr, nil
+	}
+	parent, err := pointerGet(node, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		p[last] = arr
+	case []interface{}:
+		i, err := arrayIndex(last, len(p), false)
+		if err != nil {
+			return nil, err
+		}
+		p[i] = arr
+	}
+	return node, nil
+}
+
+func deepCopy(v interface{}) interface{} {
+	b, _ := json.Marshal(v)
+	var out interface{}
+	_ = json.Unmarshal(b, &out)
+	return out
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	doc := []byte(`{"a":"b","c":{"d":"e","f":"g"}}`)
+	out, err := MergePatch(doc, []byte(`{"a":"z","c":{"f":null}}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(out) != `{"a":"z","c":{"d":"e"}}` {
+		t.Errorf("Expected merged document, got %s", out)
+	}
+
+	if _, err := MergePatch(doc, []byte(`{`)); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected bad request for invalid patch, got %v", err)
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	doc := []byte(`{"foo":"bar","list":[1,2],"a/b":1}`)
+
+	t.Run("Operations", func(t *testing.T) {
+		patch := []byte(`[
+			{"op":"test","path":"/foo","value":"bar"},
+			{"op":"replace","path":"/foo","value":"baz"},
+			{"op":"add","path":"/list/1","value":9},
+			{"op":"add","path":"/list/-","value":3},
+			{"op":"remove","path":"/list/0"},
+			{"op":"copy","from":"/foo","path":"/copied"},
+			{"op":"move","from":"/a~1b","path":"/moved"}
+		]`)
+		out, err := ApplyJSONPatch(doc, patch)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if string(out) != `{"copied":"baz","foo":"baz","list":[9,2,3],"moved":1}` {
+			t.Errorf("Unexpected result %s", out)
+		}
+	})
+
+	t.Run("FailsAtomically", func(t *testing.T) {
+		for _, patch := range []string{
+			`[{"op":"test","path":"/foo","value":"nope"}]`,
+			`[{"op":"remove","path":"/missing"}]`,
+			`[{"op":"replace","path":"/list/5","value":1}]`,
+			`[{"op":"add","path":"foo","value":1}]`,
+			`[{"op":"frobnicate","path":"/foo"}]`,
+			`[{"op":"add","path":"/x"}]`,
+		} {
+			if _, err := ApplyJSONPatch(doc, []byte(patch)); !errors.Is(err, ErrBadRequest) {
+				t.Errorf("Expected bad request for %s, got %v", patch, err)
+			}
+		}
+	})
+}
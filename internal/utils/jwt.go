@@ -1,36 +1,61 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type JWTClaims struct {
 	UserID int64 `json:"user_id"`
+	// PermissionsVersion is the user's permissions_version when the token was issued.
+	PermissionsVersion int64 `json:"permissions_version"`
+	// Roles and Permissions are only embedded when JWT_EMBED_PERMISSIONS is enabled.
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID int64, secret string, expiryHours int) (string, error) {
-	claims := JWTClaims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiryHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+// GenerateToken issues an access token valid for ttl from the application claims in c, signed
+// with the key set's signing key and carrying its kid.
+// The returned claims also carry the token's unique ID (jti) and expiry so callers can record or revoke it.
+func GenerateToken(c JWTClaims, keys *KeySet, ttl time.Duration) (string, *JWTClaims, error) {
+	now := time.Now()
+	claims := &c
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	key := keys.Signing()
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	signed, err := token.SignedString(key.signingKey())
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
-func ValidateToken(tokenString string, secret string) (*JWTClaims, error) {
+// ValidateToken verifies the token against the key named by its kid header (or the HMAC
+// secret when it has none) and returns its claims.
+func ValidateToken(tokenString string, keys *KeySet) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
+		kid, _ := token.Header["kid"].(string)
+		key, err := keys.lookup(kid, token.Method.Alg())
+		if err != nil {
+			return nil, err
 		}
-		return []byte(secret), nil
+		return key.verificationKey(), nil
 	})
 
 	if err != nil {
@@ -43,3 +68,20 @@ func ValidateToken(tokenString string, secret string) (*JWTClaims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+// GenerateOpaqueToken returns a random URL-safe token and its SHA-256 hash.
+// Only the hash should be stored; the raw token is handed to the client once.
+func GenerateOpaqueToken() (raw string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	raw = base64.RawURLEncoding.EncodeToString(b)
+	return raw, HashToken(raw), nil
+}
+
+// HashToken returns the hex SHA-256 of an opaque token for storage and lookup.
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
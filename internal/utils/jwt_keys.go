@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTKey is one key used to sign or verify access tokens. Asymmetric keys (RS256, EdDSA)
+// are identified by ID, the RFC 7638 thumbprint of the public key, which is sent as the
+// token's kid header. HMAC keys have no ID and are never published.
+type JWTKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey // nil for verification-only keys
+	Public  crypto.PublicKey
+	Secret  []byte // HS256 only
+}
+
+func (k *JWTKey) signingKey() interface{} {
+	if k.Secret != nil {
+		return k.Secret
+	}
+	return k.Private
+}
+
+func (k *JWTKey) verificationKey() interface{} {
+	if k.Secret != nil {
+		return k.Secret
+	}
+	return k.Public
+}
+
+// KeySet holds the key that signs new access tokens and every key still accepted for
+// verification. During rotation the previous keys stay in the set until the tokens they
+// signed have expired.
+type KeySet struct {
+	signing *JWTKey
+	verify  map[string]*JWTKey
+	order   []string
+}
+
+// NewHMACKeySet signs and verifies with a single shared HS256 secret.
+func NewHMACKeySet(secret string) *KeySet {
+	key := &JWTKey{Method: jwt.SigningMethodHS256, Secret: []byte(secret)}
+	return &KeySet{signing: key, verify: map[string]*JWTKey{"": key}, order: []string{""}}
+}
+
+// LoadKeySet reads a PEM private key (RSA or Ed25519) to sign with, plus PEM public or
+// private keys that are only accepted for verification, e.g. the previous signing key.
+func LoadKeySet(signingKeyFile string, verifyKeyFiles []string) (*KeySet, error) {
+	signing, err := loadJWTKey(signingKeyFile, true)
+	if err != nil {
+		return nil, err
+	}
+	set := &KeySet{signing: signing, verify: map[string]*JWTKey{}}
+	set.add(signing)
+	for _, file := range verifyKeyFiles {
+		key, err := loadJWTKey(file, false)
+		if err != nil {
+			return nil, err
+		}
+		set.add(key)
+	}
+	return set, nil
+}
+
+func (s *KeySet) add(k *JWTKey) {
+	if _, ok := s.verify[k.ID]; ok {
+		return
+	}
+	s.verify[k.ID] = k
+	s.order = append(s.order, k.ID)
+}
+
+// Signing returns the key new tokens are signed with.
+func (s *KeySet) Signing() *JWTKey {
+	return s.signing
+}
+
+// lookup returns the verification key for a token's kid and algorithm. Matching the
+// algorithm as well prevents a token from choosing how its own signature is checked.
+func (s *KeySet) lookup(kid, alg string) (*JWTKey, error) {
+	key, ok := s.verify[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if key.Method.Alg() != alg {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key, nil
+}
+
+// JWK is a public key in JSON Web Key form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public halves of every asymmetric verification key. HMAC secrets are omitted.
+func (s *KeySet) JWKS() JWKS {
+	doc := JWKS{Keys: []JWK{}}
+	for _, id := range s.order {
+		key := s.verify[id]
+		if key.Secret != nil {
+			continue
+		}
+		jwk, err := publicJWK(key.Public)
+		if err != nil {
+			continue
+		}
+		jwk.Kid = key.ID
+		jwk.Use = "sig"
+		jwk.Alg = key.Method.Alg()
+		doc.Keys = append(doc.Keys, jwk)
+	}
+	return doc
+}
+
+func loadJWTKey(file string, needPrivate bool) (*JWTKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read JWT key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("JWT key %s: no PEM block found", file)
+	}
+
+	var private crypto.PrivateKey
+	var public crypto.PublicKey
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("JWT key %s: %w", file, err)
+	}
+	if private == nil && needPrivate {
+		return nil, fmt.Errorf("JWT key %s: a private key is required for signing", file)
+	}
+
+	key := &JWTKey{Private: private, Public: public}
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		key.Public = &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Public = k.Public()
+	}
+	switch key.Public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("JWT key %s: only RSA and Ed25519 keys are supported", file)
+	}
+
+	jwk, err := publicJWK(key.Public)
+	if err != nil {
+		return nil, err
+	}
+	key.ID = jwkThumbprint(jwk)
+	return key, nil
+}
+
+func publicJWK(public crypto.PublicKey) (JWK, error) {
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(k)}, nil
+	}
+	return JWK{}, errors.New("unsupported public key type")
+}
+
+// jwkThumbprint computes the RFC 7638 thumbprint: SHA-256 over the required members in
+// lexicographic order.
+func jwkThumbprint(k JWK) string {
+	var members interface{}
+	if k.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.Kty, k.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Crv, k.Kty, k.X}
+	}
+	b, _ := json.Marshal(members)
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestKeySetRotation(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	edDER, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rsaPubDER, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+
+	oldKeys, err := LoadKeySet(writePEM(t, "old.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)), nil)
+	if err != nil {
+		t.Fatalf("Expected RSA key to load, got %v", err)
+	}
+	newKeys, err := LoadKeySet(writePEM(t, "new.pem", "PRIVATE KEY", edDER),
+		[]string{writePEM(t, "old.pub.pem", "PUBLIC KEY", rsaPubDER)})
+	if err != nil {
+		t.Fatalf("Expected Ed25519 key to load, got %v", err)
+	}
+
+	t.Run("VerifiesPreviousKey", func(t *testing.T) {
+		oldToken, _, _ := GenerateToken(JWTClaims{UserID: 1}, oldKeys, time.Minute)
+		newToken, _, _ := GenerateToken(JWTClaims{UserID: 2}, newKeys, time.Minute)
+		if claims, err := ValidateToken(oldToken, newKeys); err != nil || claims.UserID != 1 {
+			t.Errorf("Expected RS256 token to verify after rotation, got %v", err)
+		}
+		if claims, err := ValidateToken(newToken, newKeys); err != nil || claims.UserID != 2 {
+			t.Errorf("Expected EdDSA token to verify, got %v", err)
+		}
+		if _, err := ValidateToken(newToken, oldKeys); err == nil {
+			t.Error("Expected token from an unknown kid to be rejected")
+		}
+	})
+
+	t.Run("RejectsAlgorithmSwitch", func(t *testing.T) {
+		// HS256 signed with the public key bytes must not pass as the RSA key.
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, &JWTClaims{UserID: 1})
+		token.Header["kid"] = oldKeys.Signing().ID
+		forged, _ := token.SignedString(rsaPubDER)
+		if _, err := ValidateToken(forged, newKeys); err == nil {
+			t.Error("Expected HS256 token with an RSA kid to be rejected")
+		}
+	})
+
+	t.Run("JWKS", func(t *testing.T) {
+		doc := newKeys.JWKS()
+		if len(doc.Keys) != 2 {
+			t.Fatalf("Expected 2 keys, got %d", len(doc.Keys))
+		}
+		if doc.Keys[0].Kid != newKeys.Signing().ID || doc.Keys[0].Alg != "EdDSA" || doc.Keys[1].Kty != "RSA" {
+			t.Errorf("Unexpected JWKS %+v", doc.Keys)
+		}
+		if len(NewHMACKeySet("secret").JWKS().Keys) != 0 {
+			t.Error("Expected HMAC secrets to be omitted from the JWKS")
+		}
+	})
+}
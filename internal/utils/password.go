@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// PasswordPolicy describes the minimum strength required for new passwords.
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// Validate returns a human-readable problem for every rule the password breaks, or nil.
+func (p PasswordPolicy) Validate(password string) []string {
+	var upper, lower, digit, symbol bool
+	length := 0
+	for _, r := range password {
+		length++
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+
+	var problems []string
+	if length < p.MinLength {
+		problems = append(problems, fmt.Sprintf("Password must be at least %d characters long", p.MinLength))
+	}
+	if p.RequireUpper && !upper {
+		problems = append(problems, "Password must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		problems = append(problems, "Password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		problems = append(problems, "Password must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		problems = append(problems, "Password must contain a symbol")
+	}
+	return problems
+}
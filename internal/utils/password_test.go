@@ -0,0 +1,17 @@
+package utils
+
+import "testing"
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	if problems := policy.Validate("Str0ng!pass"); len(problems) != 0 {
+		t.Errorf("Expected no problems, got %v", problems)
+	}
+	if problems := policy.Validate("short"); len(problems) != 4 {
+		t.Errorf("Expected 4 problems (length, upper, digit, symbol), got %v", problems)
+	}
+	if problems := (PasswordPolicy{}).Validate(""); len(problems) != 0 {
+		t.Errorf("Expected empty policy to accept anything, got %v", problems)
+	}
+}
@@ -10,8 +10,13 @@ import (
 )
 
 const (
-	DefaultPageSize   = 10
-	TrackIDContextKey = "track_id" // Set by middleware.TrackIDMiddleware; use GetTrackID(c) to read.
+	DefaultPageSize    = 10
+	TrackIDContextKey  = "track_id" // Set by middleware.TrackIDMiddleware; use GetTrackID(c) to read.
+	UserIDContextKey   = "userID"   // Set by middleware.AuthMiddleware; use GetUserID(c) to read.
+	ClaimsContextKey   = "claims"   // Set by middleware.AuthMiddleware; use GetClaims(c) to read.
+	APIKeyContextKey   = "apiKeyID" // Set by middleware.AuthMiddleware when the request used an API key.
+	IfMatchContextKey  = "ifMatch"  // Set by middleware.RequireIfMatch; use GetIfMatchVersion(c) to read.
+	SkipETagContextKey = "skipETag" // Set by SkipETag; middleware.ETagMiddleware then streams the body.
 )
 
 // GetTrackID returns the request's track_id from context (set by TrackIDMiddleware), or a new UUID if not set.
@@ -25,6 +30,34 @@ func GetTrackID(c *gin.Context) string {
 	return uuid.New().String()
 }
 
+// GetUserID returns the authenticated user's ID from context (set by AuthMiddleware).
+// The second return value is false when the request is not authenticated.
+func GetUserID(c *gin.Context) (int64, bool) {
+	v, ok := c.Get(UserIDContextKey)
+	if !ok {
+		return 0, false
+	}
+	switch id := v.(type) {
+	case int64:
+		return id, true
+	case float64:
+		return int64(id), true
+	case int:
+		return int64(id), true
+	}
+	return 0, false
+}
+
+// GetClaims returns the validated JWT claims of the current request (set by AuthMiddleware), or nil.
+func GetClaims(c *gin.Context) *JWTClaims {
+	if v, ok := c.Get(ClaimsContextKey); ok {
+		if claims, ok := v.(*JWTClaims); ok {
+			return claims
+		}
+	}
+	return nil
+}
+
 type ActionLink struct {
 	Rel    string `json:"rel"`
 	Method string `json:"method"`
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidateStruct runs all of the struct's binding rules, as gin does when binding a request.
+func ValidateStruct(obj interface{}) error {
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// ValidateFields runs the struct's binding rules for the named fields only, identified by
+// their JSON names. Partial updates use it so that fields the client did not send are not
+// validated. It returns validator.ValidationErrors, like gin's binding.
+func ValidateFields(obj interface{}, jsonFields []string) error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return binding.Validator.ValidateStruct(obj)
+	}
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	byJSON := map[string]string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = f.Name
+		}
+		byJSON[name] = f.Name
+	}
+	var names []string
+	for _, f := range jsonFields {
+		if name, ok := byJSON[f]; ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return v.StructPartial(obj, names...)
+}
@@ -0,0 +1,18 @@
+-- RAG query log: every question asked, what was retrieved and how users rated the answer
+CREATE TABLE rag_queries (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT,
+    query TEXT NOT NULL,
+    chunk_ids BIGINT[] NOT NULL DEFAULT '{}',
+    answer TEXT,
+    latency_ms INT NOT NULL DEFAULT 0 CHECK (latency_ms >= 0),
+    feedback SMALLINT CHECK (feedback IN (-1, 1)),
+    feedback_comment TEXT,
+    feedback_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_rag_queries_user ON rag_queries(user_id);
+CREATE INDEX idx_rag_queries_created_at ON rag_queries(created_at);
+CREATE INDEX idx_rag_queries_feedback ON rag_queries(feedback) WHERE feedback IS NOT NULL;
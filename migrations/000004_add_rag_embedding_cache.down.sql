@@ -0,0 +1 @@
+DROP TABLE IF EXISTS rag_embedding_cache;
@@ -0,0 +1,9 @@
+-- Embedding cache: one row per (model, sha256 of content) so unchanged text is never re-embedded.
+-- The vector column is unconstrained so models with different dimensions can share the table.
+CREATE TABLE rag_embedding_cache (
+    model VARCHAR(100) NOT NULL,
+    content_hash CHAR(64) NOT NULL,
+    embedding vector NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (model, content_hash)
+);
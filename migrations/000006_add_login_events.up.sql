@@ -0,0 +1,18 @@
+-- ==============================
+-- Login events (audit + lockout counting)
+-- ==============================
+-- One row per login attempt. Failed attempts in the lockout window are counted
+-- per username and per IP to temporarily block password guessing.
+CREATE TABLE login_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT,
+    username VARCHAR(80) NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(255),
+    outcome VARCHAR(30) NOT NULL CHECK (outcome IN ('pending','success','invalid_password','unknown_user','inactive','locked_out')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_login_events_user ON login_events(user_id, created_at);
+CREATE INDEX idx_login_events_username ON login_events(username, created_at);
+CREATE INDEX idx_login_events_ip ON login_events(ip_address, created_at);
@@ -0,0 +1 @@
+ALTER TABLE permission_role DROP COLUMN IF EXISTS assigned_by;
@@ -0,0 +1,4 @@
+-- ==============================
+-- Track who granted each permission to a role (role_user already has assigned_by)
+-- ==============================
+ALTER TABLE permission_role ADD COLUMN assigned_by BIGINT REFERENCES users(id) ON DELETE SET NULL;
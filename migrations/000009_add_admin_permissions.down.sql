@@ -0,0 +1 @@
+DELETE FROM permissions WHERE slug IN ('user-manage', 'role-manage', 'permission-manage');
@@ -0,0 +1,15 @@
+-- ==============================
+-- Admin permissions for user, role and permission management, granted to the admin role
+-- ==============================
+INSERT INTO permissions (name, slug, module) VALUES
+    ('user-manage', 'user-manage', 'admin'),
+    ('role-manage', 'role-manage', 'admin'),
+    ('permission-manage', 'permission-manage', 'admin')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug IN ('user-manage', 'role-manage', 'permission-manage')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS trg_permission_role_permissions_version ON permission_role;
+DROP TRIGGER IF EXISTS trg_role_user_permissions_version ON role_user;
+DROP FUNCTION IF EXISTS bump_permissions_version_permission_role();
+DROP FUNCTION IF EXISTS bump_permissions_version_role_user();
+ALTER TABLE users DROP COLUMN IF EXISTS permissions_version;
@@ -0,0 +1,29 @@
+-- ==============================
+-- Per-user permissions version, bumped whenever the user's effective permissions may change.
+-- Access tokens carry the version they were issued with and are rejected once it is stale.
+-- ==============================
+ALTER TABLE users ADD COLUMN permissions_version BIGINT NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION bump_permissions_version_role_user() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE users SET permissions_version = permissions_version + 1
+    WHERE id = COALESCE(NEW.user_id, OLD.user_id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION bump_permissions_version_permission_role() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE users SET permissions_version = permissions_version + 1
+    WHERE id IN (SELECT user_id FROM role_user WHERE role_id = COALESCE(NEW.role_id, OLD.role_id));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_role_user_permissions_version
+AFTER INSERT OR DELETE ON role_user
+FOR EACH ROW EXECUTE FUNCTION bump_permissions_version_role_user();
+
+CREATE TRIGGER trg_permission_role_permissions_version
+AFTER INSERT OR DELETE ON permission_role
+FOR EACH ROW EXECUTE FUNCTION bump_permissions_version_permission_role();
@@ -0,0 +1,4 @@
+DELETE FROM permissions WHERE slug IN ('data-scope-bypass', 'payment-read');
+DROP INDEX IF EXISTS idx_payment_history_showroom;
+DROP INDEX IF EXISTS idx_cars_location;
+DROP TABLE IF EXISTS user_scopes;
@@ -0,0 +1,44 @@
+-- ==============================
+-- Row-level data scopes: which car locations and payment showrooms a user may access
+-- ==============================
+CREATE TABLE user_scopes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    scope_type VARCHAR(20) NOT NULL CHECK (scope_type IN ('location','showroom')),
+    value VARCHAR(255) NOT NULL,
+    assigned_by BIGINT,
+    assigned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, scope_type, value),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (assigned_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_user_scopes_user ON user_scopes(user_id);
+CREATE INDEX idx_cars_location ON cars(location);
+CREATE INDEX idx_payment_history_showroom ON payment_history(showroom_name);
+
+-- Scoping is deny-by-default, so keep existing users seeing the locations and showrooms
+-- that exist today; restrict them afterwards through /users/:id/scopes.
+INSERT INTO user_scopes (user_id, scope_type, value)
+SELECT u.id, 'location', v.location
+FROM users u
+CROSS JOIN (SELECT DISTINCT location FROM cars WHERE location IS NOT NULL) v
+WHERE u.deleted_at IS NULL
+UNION
+SELECT u.id, 'showroom', v.showroom_name
+FROM users u
+CROSS JOIN (SELECT DISTINCT showroom_name FROM payment_history WHERE showroom_name IS NOT NULL) v
+WHERE u.deleted_at IS NULL
+ON CONFLICT DO NOTHING;
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('data-scope-bypass', 'data-scope-bypass', 'admin'),
+    ('payment-read', 'payment-read', 'finance')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE (r.slug = 'admin' AND p.slug IN ('data-scope-bypass', 'payment-read'))
+   OR (r.slug = 'accountman' AND p.slug = 'payment-read')
+ON CONFLICT DO NOTHING;
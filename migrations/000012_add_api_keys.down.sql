@@ -0,0 +1,3 @@
+DELETE FROM users WHERE id IN (SELECT user_id FROM api_keys);
+DROP TABLE IF EXISTS api_keys;
+DELETE FROM permissions WHERE slug = 'api-key-manage';
@@ -0,0 +1,33 @@
+-- ==============================
+-- API keys for service-to-service integrations. Each key acts as its own service user,
+-- created with the key and holding the key's role, so permission checks treat it like any user.
+-- ==============================
+CREATE TABLE api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(150) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    user_id BIGINT NOT NULL,
+    role_id BIGINT NOT NULL,
+    allowed_ips TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_api_keys_user ON api_keys(user_id);
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('api-key-manage', 'api-key-manage', 'admin')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug = 'api-key-manage'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+DELETE FROM permissions WHERE slug = 'audit-read';
+DROP TABLE IF EXISTS audit_log;
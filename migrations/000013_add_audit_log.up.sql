@@ -0,0 +1,30 @@
+-- ==============================
+-- Audit trail: one row per create/update/delete, written in the same transaction as the change
+-- ==============================
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_user_id BIGINT,
+    actor_api_key_id BIGINT,
+    action VARCHAR(20) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    before JSONB,
+    after JSONB,
+    track_id VARCHAR(64),
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id, created_at);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_user_id, created_at);
+CREATE INDEX idx_audit_log_created ON audit_log(created_at);
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('audit-read', 'audit-read', 'admin')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug = 'audit-read'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,14 @@
+DELETE FROM permissions WHERE slug = 'trash-manage';
+
+-- Rows still in the trash would violate the restored constraints.
+DELETE FROM cars WHERE deleted_at IS NOT NULL;
+DELETE FROM users WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS uq_users_email_active;
+DROP INDEX IF EXISTS uq_users_username_active;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+ALTER TABLE users ADD CONSTRAINT users_username_key UNIQUE (username);
+DROP INDEX IF EXISTS uq_cars_ref_no_active;
+ALTER TABLE cars ADD CONSTRAINT cars_ref_no_key UNIQUE (ref_no);
+DROP INDEX IF EXISTS idx_cars_deleted_at;
+ALTER TABLE cars DROP COLUMN IF EXISTS deleted_at;
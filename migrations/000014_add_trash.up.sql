@@ -0,0 +1,24 @@
+-- ==============================
+-- Soft delete for cars, and unique values that only apply to rows not in the trash
+-- ==============================
+ALTER TABLE cars ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX idx_cars_deleted_at ON cars(deleted_at);
+
+-- Deleted cars and users no longer block reuse of their ref_no, username or email.
+ALTER TABLE cars DROP CONSTRAINT IF EXISTS cars_ref_no_key;
+CREATE UNIQUE INDEX uq_cars_ref_no_active ON cars(ref_no) WHERE deleted_at IS NULL;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_username_key;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX uq_users_username_active ON users(username) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX uq_users_email_active ON users(email) WHERE deleted_at IS NULL;
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('trash-manage', 'trash-manage', 'admin')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug = 'trash-manage'
+ON CONFLICT DO NOTHING;
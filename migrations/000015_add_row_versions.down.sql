@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS trg_users_version ON users;
+DROP TRIGGER IF EXISTS trg_cars_version ON cars;
+DROP FUNCTION IF EXISTS bump_row_version();
+ALTER TABLE users DROP COLUMN IF EXISTS version;
+ALTER TABLE cars DROP COLUMN IF EXISTS version;
@@ -0,0 +1,28 @@
+-- ==============================
+-- Row versions for optimistic concurrency. An UPDATE that changes an editable column bumps
+-- version; clients send the version they read (as the ETag) in If-Match and the update only
+-- applies if it still matches.
+-- ==============================
+ALTER TABLE cars ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE users ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+-- The trigger arguments are the columns the server maintains; changing only those, such as a
+-- user's last login, keeps the version so it does not fail clients' edits. A statement can
+-- still bump it explicitly with SET version = version + 1.
+CREATE OR REPLACE FUNCTION bump_row_version() RETURNS TRIGGER AS $$
+BEGIN
+    IF (to_jsonb(NEW) - TG_ARGV) IS DISTINCT FROM (to_jsonb(OLD) - TG_ARGV) THEN
+        NEW.version := OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_cars_version
+BEFORE UPDATE ON cars
+FOR EACH ROW EXECUTE FUNCTION bump_row_version('version', 'updated_at');
+
+CREATE TRIGGER trg_users_version
+BEFORE UPDATE ON users
+FOR EACH ROW EXECUTE FUNCTION bump_row_version('version', 'updated_at', 'updated_by',
+    'last_login_at', 'password_hash', 'permissions_version');
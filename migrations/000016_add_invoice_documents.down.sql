@@ -0,0 +1,9 @@
+DELETE FROM permissions WHERE slug = 'invoice-manage';
+
+-- Order documents have no car and would violate the restored constraint.
+DELETE FROM documents WHERE car_id IS NULL;
+
+DROP INDEX IF EXISTS idx_documents_order;
+ALTER TABLE documents DROP CONSTRAINT IF EXISTS documents_owner_check;
+ALTER TABLE documents DROP COLUMN IF EXISTS order_id;
+ALTER TABLE documents ALTER COLUMN car_id SET NOT NULL;
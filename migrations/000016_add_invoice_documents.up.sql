@@ -0,0 +1,18 @@
+-- ==============================
+-- Generated invoices are stored as documents of an order rather than a car
+-- ==============================
+ALTER TABLE documents ALTER COLUMN car_id DROP NOT NULL;
+ALTER TABLE documents ADD COLUMN order_id BIGINT REFERENCES orders(id) ON DELETE CASCADE;
+ALTER TABLE documents ADD CONSTRAINT documents_owner_check CHECK (car_id IS NOT NULL OR order_id IS NOT NULL);
+CREATE INDEX idx_documents_order ON documents(order_id);
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('invoice-manage', 'invoice-manage', 'finance')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug = 'invoice-manage'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE slug = 'car-merge';
+
+DROP INDEX IF EXISTS idx_cars_engine_number_key;
+DROP INDEX IF EXISTS idx_cars_chassis_no_key;
+DROP INDEX IF EXISTS idx_cars_ref_no_key;
@@ -0,0 +1,17 @@
+-- ==============================
+-- Duplicate car detection: identifiers compared ignoring case, spaces, dashes and other punctuation
+-- ==============================
+CREATE INDEX idx_cars_ref_no_key ON cars ((UPPER(REGEXP_REPLACE(ref_no, '[^[:alnum:]]', '', 'g'))));
+CREATE INDEX idx_cars_chassis_no_key ON cars ((UPPER(REGEXP_REPLACE(chassis_no_full, '[^[:alnum:]]', '', 'g'))));
+CREATE INDEX idx_cars_engine_number_key ON cars ((UPPER(REGEXP_REPLACE(engine_number, '[^[:alnum:]]', '', 'g'))));
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('car-merge', 'car-merge', 'car')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug = 'car-merge'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,20 @@
+-- ==============================
+-- Car status history: every status a car has had, who set it and why
+-- ==============================
+CREATE TABLE car_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    car_id BIGINT NOT NULL,
+    from_status car_status_enum,
+    to_status car_status_enum NOT NULL,
+    reason TEXT,
+    changed_by BIGINT,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (car_id) REFERENCES cars(id) ON DELETE CASCADE,
+    FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_car_status_history_car ON car_status_history(car_id, changed_at);
+
+-- Cars created without a status never got the column default; start everyone's history now.
+UPDATE cars SET status = 'available' WHERE status IS NULL;
+INSERT INTO car_status_history (car_id, to_status, changed_at)
+SELECT id, status, created_at FROM cars;
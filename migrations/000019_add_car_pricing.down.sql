@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE slug IN ('order-create', 'price-override');
+DROP TABLE IF EXISTS car_price_history;
+DROP TABLE IF EXISTS car_prices;
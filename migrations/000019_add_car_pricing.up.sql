@@ -0,0 +1,37 @@
+-- ==============================
+-- Car pricing: list and minimum sale price per car, in BDT like the landed cost, and their history
+-- ==============================
+CREATE TABLE car_prices (
+    car_id BIGINT PRIMARY KEY,
+    list_price DECIMAL(15,2) NOT NULL CHECK (list_price >= 0),
+    min_price DECIMAL(15,2) CHECK (min_price >= 0 AND min_price <= list_price),
+    updated_by BIGINT,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (car_id) REFERENCES cars(id) ON DELETE CASCADE,
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE car_price_history (
+    id BIGSERIAL PRIMARY KEY,
+    car_id BIGINT NOT NULL,
+    list_price DECIMAL(15,2) NOT NULL,
+    min_price DECIMAL(15,2),
+    reason TEXT,
+    changed_by BIGINT,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (car_id) REFERENCES cars(id) ON DELETE CASCADE,
+    FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_car_price_history_car ON car_price_history(car_id, changed_at);
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('order-create', 'order-create', 'finance'),
+    ('price-override', 'price-override', 'finance')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug IN ('order-create', 'price-override')
+ON CONFLICT DO NOTHING;
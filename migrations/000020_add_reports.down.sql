@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE slug = 'report-read';
+DROP INDEX IF EXISTS idx_payment_history_purchase_date;
+DROP INDEX IF EXISTS idx_orders_created_at;
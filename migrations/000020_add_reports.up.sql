@@ -0,0 +1,13 @@
+CREATE INDEX idx_orders_created_at ON orders(created_at);
+CREATE INDEX idx_payment_history_purchase_date ON payment_history(purchase_date);
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('report-read', 'report-read', 'finance')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug IN ('admin', 'accountman') AND p.slug = 'report-read'
+ON CONFLICT DO NOTHING;
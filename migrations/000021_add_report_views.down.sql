@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE slug = 'report-manage';
+DROP TABLE IF EXISTS report_view_refreshes;
+DROP MATERIALIZED VIEW IF EXISTS report_receivables;
+DROP MATERIALIZED VIEW IF EXISTS report_sales;
+DROP MATERIALIZED VIEW IF EXISTS report_car_costs;
@@ -0,0 +1,64 @@
+-- Snapshots behind the reports, refreshed concurrently by the API. Each needs a unique
+-- index so REFRESH MATERIALIZED VIEW CONCURRENTLY can be used.
+
+-- Landed cost and first LC date of every car that is on an LC
+CREATE MATERIALIZED VIEW report_car_costs AS
+SELECT lc.car_id,
+       MIN(l.lc_date) AS first_lc_date,
+       SUM(COALESCE(ph.total_bdt, 0) + COALESCE(ph.govt_duty, 0) + COALESCE(ph.cnf_amount, 0) + COALESCE(ph.miscellaneous, 0))
+           FILTER (WHERE ph.id IS NOT NULL) AS cost_basis
+FROM lc_cars lc
+JOIN lcs l ON l.id = lc.lc_id
+LEFT JOIN purchase_history ph ON ph.lc_car_id = lc.id
+GROUP BY lc.car_id;
+CREATE UNIQUE INDEX idx_report_car_costs_car ON report_car_costs(car_id);
+
+-- One row per item of an approved, shipped or delivered order. The car's location is left
+-- out: reports join cars so data scopes apply to where the car is now.
+CREATE MATERIALIZED VIEW report_sales AS
+SELECT oi.id AS order_item_id, oi.order_id, oi.car_id, o.created_at AS sold_at,
+       oi.quantity, oi.price * oi.quantity AS amount, k.cost_basis
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+LEFT JOIN report_car_costs k ON k.car_id = oi.car_id
+WHERE o.status IN ('approved', 'shipped', 'delivered');
+CREATE UNIQUE INDEX idx_report_sales_item ON report_sales(order_item_id);
+CREATE INDEX idx_report_sales_sold_at ON report_sales(sold_at);
+
+-- Customer purchases not yet paid in full by their installments
+CREATE MATERIALIZED VIEW report_receivables AS
+SELECT ph.id AS payment_id, ph.car_id, ph.customer_name, ph.showroom_name, ph.purchase_date,
+       ph.purchase_amount AS amount, COALESCE(SUM(i.amount), 0) AS paid,
+       ph.purchase_amount - COALESCE(SUM(i.amount), 0) AS outstanding,
+       MAX(i.installment_date) AS last_payment_date
+FROM payment_history ph
+LEFT JOIN installments i ON i.payment_history_id = ph.id
+WHERE ph.purchase_amount IS NOT NULL
+GROUP BY ph.id
+HAVING ph.purchase_amount - COALESCE(SUM(i.amount), 0) > 0;
+CREATE UNIQUE INDEX idx_report_receivables_payment ON report_receivables(payment_id);
+
+-- Last refresh of each view
+CREATE TABLE report_view_refreshes (
+    view_name VARCHAR(64) PRIMARY KEY,
+    refreshed_at TIMESTAMP,
+    duration_ms BIGINT,
+    failed_at TIMESTAMP,
+    last_error TEXT
+);
+INSERT INTO report_view_refreshes (view_name, refreshed_at) VALUES
+    ('report_car_costs', CURRENT_TIMESTAMP),
+    ('report_sales', CURRENT_TIMESTAMP),
+    ('report_receivables', CURRENT_TIMESTAMP)
+ON CONFLICT (view_name) DO NOTHING;
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('report-manage', 'report-manage', 'admin')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug = 'report-manage'
+ON CONFLICT DO NOTHING;
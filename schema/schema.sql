@@ -427,3 +427,495 @@ CREATE INDEX idx_rag_chunks_source ON rag_chunks(source_type, source_id);
 -- HNSW index for fast approximate nearest neighbor search (cosine distance)
 CREATE INDEX idx_rag_chunks_embedding ON rag_chunks
     USING hnsw (embedding vector_cosine_ops);
+
+-- RAG query log: every question asked, what was retrieved and how users rated the answer
+CREATE TABLE rag_queries (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT,
+    query TEXT NOT NULL,
+    chunk_ids BIGINT[] NOT NULL DEFAULT '{}',
+    answer TEXT,
+    latency_ms INT NOT NULL DEFAULT 0 CHECK (latency_ms >= 0),
+    feedback SMALLINT CHECK (feedback IN (-1, 1)),
+    feedback_comment TEXT,
+    feedback_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_rag_queries_user ON rag_queries(user_id);
+CREATE INDEX idx_rag_queries_created_at ON rag_queries(created_at);
+CREATE INDEX idx_rag_queries_feedback ON rag_queries(feedback) WHERE feedback IS NOT NULL;
+
+-- Embedding cache: one row per (model, sha256 of content) so unchanged text is never re-embedded.
+-- The vector column is unconstrained so models with different dimensions can share the table.
+CREATE TABLE rag_embedding_cache (
+    model VARCHAR(100) NOT NULL,
+    content_hash CHAR(64) NOT NULL,
+    embedding vector NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (model, content_hash)
+);
+
+-- ==============================
+-- Refresh tokens (rotating, stored hashed)
+-- ==============================
+-- Every refresh rotates the token: the old row is revoked and points at its replacement.
+-- All tokens descending from one login share a family_id so reuse of a rotated token
+-- can revoke the whole family. access_jti is the access token issued alongside, so
+-- revoking a family also denylists the access token that is still live.
+CREATE TABLE refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    family_id UUID NOT NULL,
+    access_jti VARCHAR(64) NOT NULL,
+    access_expires_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    replaced_by BIGINT,
+    user_agent VARCHAR(255),
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (replaced_by) REFERENCES refresh_tokens(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_refresh_tokens_user ON refresh_tokens(user_id);
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
+
+-- Access token denylist: checked by AuthMiddleware on every request.
+-- Rows can be deleted once expires_at has passed since the token is rejected anyway.
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    user_id BIGINT,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+-- ==============================
+-- Login events (audit + lockout counting)
+-- ==============================
+-- One row per login attempt. Failed attempts in the lockout window are counted
+-- per username and per IP to temporarily block password guessing.
+CREATE TABLE login_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT,
+    username VARCHAR(80) NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(255),
+    outcome VARCHAR(30) NOT NULL CHECK (outcome IN ('pending','success','invalid_password','unknown_user','inactive','locked_out')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_login_events_user ON login_events(user_id, created_at);
+CREATE INDEX idx_login_events_username ON login_events(username, created_at);
+CREATE INDEX idx_login_events_ip ON login_events(ip_address, created_at);
+
+-- ==============================
+-- Password reset tokens (single use, stored hashed)
+-- ==============================
+CREATE TABLE password_reset_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_password_reset_tokens_user ON password_reset_tokens(user_id);
+
+-- ==============================
+-- Track who granted each permission to a role (role_user already has assigned_by)
+-- ==============================
+ALTER TABLE permission_role ADD COLUMN assigned_by BIGINT REFERENCES users(id) ON DELETE SET NULL;
+
+-- ==============================
+-- Admin permissions for user, role and permission management, granted to the admin role
+-- ==============================
+INSERT INTO permissions (name, slug, module) VALUES
+    ('user-manage', 'user-manage', 'admin'),
+    ('role-manage', 'role-manage', 'admin'),
+    ('permission-manage', 'permission-manage', 'admin')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug IN ('user-manage', 'role-manage', 'permission-manage')
+ON CONFLICT DO NOTHING;
+
+-- ==============================
+-- Per-user permissions version, bumped whenever the user's effective permissions may change.
+-- Access tokens carry the version they were issued with and are rejected once it is stale.
+-- ==============================
+ALTER TABLE users ADD COLUMN permissions_version BIGINT NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION bump_permissions_version_role_user() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE users SET permissions_version = permissions_version + 1
+    WHERE id = COALESCE(NEW.user_id, OLD.user_id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION bump_permissions_version_permission_role() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE users SET permissions_version = permissions_version + 1
+    WHERE id IN (SELECT user_id FROM role_user WHERE role_id = COALESCE(NEW.role_id, OLD.role_id));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_role_user_permissions_version
+AFTER INSERT OR DELETE ON role_user
+FOR EACH ROW EXECUTE FUNCTION bump_permissions_version_role_user();
+
+CREATE TRIGGER trg_permission_role_permissions_version
+AFTER INSERT OR DELETE ON permission_role
+FOR EACH ROW EXECUTE FUNCTION bump_permissions_version_permission_role();
+
+-- ==============================
+-- Row-level data scopes: which car locations and payment showrooms a user may access
+-- ==============================
+CREATE TABLE user_scopes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    scope_type VARCHAR(20) NOT NULL CHECK (scope_type IN ('location','showroom')),
+    value VARCHAR(255) NOT NULL,
+    assigned_by BIGINT,
+    assigned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, scope_type, value),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (assigned_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_user_scopes_user ON user_scopes(user_id);
+CREATE INDEX idx_cars_location ON cars(location);
+CREATE INDEX idx_payment_history_showroom ON payment_history(showroom_name);
+
+-- Scoping is deny-by-default, so keep existing users seeing the locations and showrooms
+-- that exist today; restrict them afterwards through /users/:id/scopes.
+INSERT INTO user_scopes (user_id, scope_type, value)
+SELECT u.id, 'location', v.location
+FROM users u
+CROSS JOIN (SELECT DISTINCT location FROM cars WHERE location IS NOT NULL) v
+WHERE u.deleted_at IS NULL
+UNION
+SELECT u.id, 'showroom', v.showroom_name
+FROM users u
+CROSS JOIN (SELECT DISTINCT showroom_name FROM payment_history WHERE showroom_name IS NOT NULL) v
+WHERE u.deleted_at IS NULL
+ON CONFLICT DO NOTHING;
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('data-scope-bypass', 'data-scope-bypass', 'admin'),
+    ('payment-read', 'payment-read', 'finance')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE (r.slug = 'admin' AND p.slug IN ('data-scope-bypass', 'payment-read'))
+   OR (r.slug = 'accountman' AND p.slug = 'payment-read')
+ON CONFLICT DO NOTHING;
+
+-- ==============================
+-- API keys for service-to-service integrations. Each key acts as its own service user,
+-- created with the key and holding the key's role, so permission checks treat it like any user.
+-- ==============================
+CREATE TABLE api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(150) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    user_id BIGINT NOT NULL,
+    role_id BIGINT NOT NULL,
+    allowed_ips TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_by BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_api_keys_user ON api_keys(user_id);
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('api-key-manage', 'api-key-manage', 'admin')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug = 'api-key-manage'
+ON CONFLICT DO NOTHING;
+
+-- ==============================
+-- Audit trail: one row per create/update/delete, written in the same transaction as the change
+-- ==============================
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_user_id BIGINT,
+    actor_api_key_id BIGINT,
+    action VARCHAR(20) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    before JSONB,
+    after JSONB,
+    track_id VARCHAR(64),
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id, created_at);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_user_id, created_at);
+CREATE INDEX idx_audit_log_created ON audit_log(created_at);
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('audit-read', 'audit-read', 'admin')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug = 'audit-read'
+ON CONFLICT DO NOTHING;
+
+-- ==============================
+-- Soft delete for cars, and unique values that only apply to rows not in the trash
+-- ==============================
+ALTER TABLE cars ADD COLUMN deleted_at TIMESTAMP;
+CREATE INDEX idx_cars_deleted_at ON cars(deleted_at);
+
+-- Deleted cars and users no longer block reuse of their ref_no, username or email.
+ALTER TABLE cars DROP CONSTRAINT IF EXISTS cars_ref_no_key;
+CREATE UNIQUE INDEX uq_cars_ref_no_active ON cars(ref_no) WHERE deleted_at IS NULL;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_username_key;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX uq_users_username_active ON users(username) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX uq_users_email_active ON users(email) WHERE deleted_at IS NULL;
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('trash-manage', 'trash-manage', 'admin')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug = 'trash-manage'
+ON CONFLICT DO NOTHING;
+
+-- ==============================
+-- Row versions for optimistic concurrency. An UPDATE that changes an editable column bumps
+-- version; clients send the version they read (as the ETag) in If-Match and the update only
+-- applies if it still matches.
+-- ==============================
+ALTER TABLE cars ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE users ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+-- The trigger arguments are the columns the server maintains; changing only those, such as a
+-- user's last login, keeps the version so it does not fail clients' edits. A statement can
+-- still bump it explicitly with SET version = version + 1.
+CREATE OR REPLACE FUNCTION bump_row_version() RETURNS TRIGGER AS $$
+BEGIN
+    IF (to_jsonb(NEW) - TG_ARGV) IS DISTINCT FROM (to_jsonb(OLD) - TG_ARGV) THEN
+        NEW.version := OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_cars_version
+BEFORE UPDATE ON cars
+FOR EACH ROW EXECUTE FUNCTION bump_row_version('version', 'updated_at');
+
+CREATE TRIGGER trg_users_version
+BEFORE UPDATE ON users
+FOR EACH ROW EXECUTE FUNCTION bump_row_version('version', 'updated_at', 'updated_by',
+    'last_login_at', 'password_hash', 'permissions_version');
+
+-- ==============================
+-- Generated invoices are stored as documents of an order rather than a car
+-- ==============================
+ALTER TABLE documents ALTER COLUMN car_id DROP NOT NULL;
+ALTER TABLE documents ADD COLUMN order_id BIGINT REFERENCES orders(id) ON DELETE CASCADE;
+ALTER TABLE documents ADD CONSTRAINT documents_owner_check CHECK (car_id IS NOT NULL OR order_id IS NOT NULL);
+CREATE INDEX idx_documents_order ON documents(order_id);
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('invoice-manage', 'invoice-manage', 'finance')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug = 'invoice-manage'
+ON CONFLICT DO NOTHING;
+
+-- ==============================
+-- Duplicate car detection: identifiers compared ignoring case, spaces, dashes and other punctuation
+-- ==============================
+CREATE INDEX idx_cars_ref_no_key ON cars ((UPPER(REGEXP_REPLACE(ref_no, '[^[:alnum:]]', '', 'g'))));
+CREATE INDEX idx_cars_chassis_no_key ON cars ((UPPER(REGEXP_REPLACE(chassis_no_full, '[^[:alnum:]]', '', 'g'))));
+CREATE INDEX idx_cars_engine_number_key ON cars ((UPPER(REGEXP_REPLACE(engine_number, '[^[:alnum:]]', '', 'g'))));
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('car-merge', 'car-merge', 'car')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug = 'car-merge'
+ON CONFLICT DO NOTHING;
+
+-- ==============================
+-- Car status history: every status a car has had, who set it and why
+-- ==============================
+CREATE TABLE car_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    car_id BIGINT NOT NULL,
+    from_status car_status_enum,
+    to_status car_status_enum NOT NULL,
+    reason TEXT,
+    changed_by BIGINT,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (car_id) REFERENCES cars(id) ON DELETE CASCADE,
+    FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_car_status_history_car ON car_status_history(car_id, changed_at);
+
+-- Cars created without a status never got the column default; start everyone's history now.
+UPDATE cars SET status = 'available' WHERE status IS NULL;
+INSERT INTO car_status_history (car_id, to_status, changed_at)
+SELECT id, status, created_at FROM cars;
+
+-- ==============================
+-- Car pricing: list and minimum sale price per car, in BDT like the landed cost, and their history
+-- ==============================
+CREATE TABLE car_prices (
+    car_id BIGINT PRIMARY KEY,
+    list_price DECIMAL(15,2) NOT NULL CHECK (list_price >= 0),
+    min_price DECIMAL(15,2) CHECK (min_price >= 0 AND min_price <= list_price),
+    updated_by BIGINT,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (car_id) REFERENCES cars(id) ON DELETE CASCADE,
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE car_price_history (
+    id BIGSERIAL PRIMARY KEY,
+    car_id BIGINT NOT NULL,
+    list_price DECIMAL(15,2) NOT NULL,
+    min_price DECIMAL(15,2),
+    reason TEXT,
+    changed_by BIGINT,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (car_id) REFERENCES cars(id) ON DELETE CASCADE,
+    FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_car_price_history_car ON car_price_history(car_id, changed_at);
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('order-create', 'order-create', 'finance'),
+    ('price-override', 'price-override', 'finance')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug IN ('order-create', 'price-override')
+ON CONFLICT DO NOTHING;
+
+CREATE INDEX idx_orders_created_at ON orders(created_at);
+CREATE INDEX idx_payment_history_purchase_date ON payment_history(purchase_date);
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('report-read', 'report-read', 'finance')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug IN ('admin', 'accountman') AND p.slug = 'report-read'
+ON CONFLICT DO NOTHING;
+
+-- Snapshots behind the reports, refreshed concurrently by the API. Each needs a unique
+-- index so REFRESH MATERIALIZED VIEW CONCURRENTLY can be used.
+
+-- Landed cost and first LC date of every car that is on an LC
+CREATE MATERIALIZED VIEW report_car_costs AS
+SELECT lc.car_id,
+       MIN(l.lc_date) AS first_lc_date,
+       SUM(COALESCE(ph.total_bdt, 0) + COALESCE(ph.govt_duty, 0) + COALESCE(ph.cnf_amount, 0) + COALESCE(ph.miscellaneous, 0))
+           FILTER (WHERE ph.id IS NOT NULL) AS cost_basis
+FROM lc_cars lc
+JOIN lcs l ON l.id = lc.lc_id
+LEFT JOIN purchase_history ph ON ph.lc_car_id = lc.id
+GROUP BY lc.car_id;
+CREATE UNIQUE INDEX idx_report_car_costs_car ON report_car_costs(car_id);
+
+-- One row per item of an approved, shipped or delivered order. The car's location is left
+-- out: reports join cars so data scopes apply to where the car is now.
+CREATE MATERIALIZED VIEW report_sales AS
+SELECT oi.id AS order_item_id, oi.order_id, oi.car_id, o.created_at AS sold_at,
+       oi.quantity, oi.price * oi.quantity AS amount, k.cost_basis
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+LEFT JOIN report_car_costs k ON k.car_id = oi.car_id
+WHERE o.status IN ('approved', 'shipped', 'delivered');
+CREATE UNIQUE INDEX idx_report_sales_item ON report_sales(order_item_id);
+CREATE INDEX idx_report_sales_sold_at ON report_sales(sold_at);
+
+-- Customer purchases not yet paid in full by their installments
+CREATE MATERIALIZED VIEW report_receivables AS
+SELECT ph.id AS payment_id, ph.car_id, ph.customer_name, ph.showroom_name, ph.purchase_date,
+       ph.purchase_amount AS amount, COALESCE(SUM(i.amount), 0) AS paid,
+       ph.purchase_amount - COALESCE(SUM(i.amount), 0) AS outstanding,
+       MAX(i.installment_date) AS last_payment_date
+FROM payment_history ph
+LEFT JOIN installments i ON i.payment_history_id = ph.id
+WHERE ph.purchase_amount IS NOT NULL
+GROUP BY ph.id
+HAVING ph.purchase_amount - COALESCE(SUM(i.amount), 0) > 0;
+CREATE UNIQUE INDEX idx_report_receivables_payment ON report_receivables(payment_id);
+
+-- Last refresh of each view
+CREATE TABLE report_view_refreshes (
+    view_name VARCHAR(64) PRIMARY KEY,
+    refreshed_at TIMESTAMP,
+    duration_ms BIGINT,
+    failed_at TIMESTAMP,
+    last_error TEXT
+);
+INSERT INTO report_view_refreshes (view_name, refreshed_at) VALUES
+    ('report_car_costs', CURRENT_TIMESTAMP),
+    ('report_sales', CURRENT_TIMESTAMP),
+    ('report_receivables', CURRENT_TIMESTAMP)
+ON CONFLICT (view_name) DO NOTHING;
+
+INSERT INTO permissions (name, slug, module) VALUES
+    ('report-manage', 'report-manage', 'admin')
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO permission_role (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.slug = 'admin' AND p.slug = 'report-manage'
+ON CONFLICT DO NOTHING;
@@ -10,6 +10,9 @@ TRUNCATE
   car_details,
   documents,
   car_photos,
+  car_status_history,
+  car_price_history,
+  car_prices,
   stocks,
   purchase_history,
   carts,
@@ -21,5 +24,19 @@ TRUNCATE
   permissions,
   roles,
   users,
-  rag_chunks
+  rag_chunks,
+  rag_queries,
+  rag_embedding_cache,
+  refresh_tokens,
+  revoked_tokens,
+  login_events,
+  password_reset_tokens,
+  user_scopes,
+  api_keys,
+  audit_log
 RESTART IDENTITY CASCADE;
+
+-- Empty the report snapshots too
+REFRESH MATERIALIZED VIEW report_car_costs;
+REFRESH MATERIALIZED VIEW report_sales;
+REFRESH MATERIALIZED VIEW report_receivables;